@@ -2,16 +2,55 @@ package main
 
 import (
 	"Ship_Manager/internal/server"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
 )
 
 func main() {
-	server := server.NewServer()
+	config := server.ConfigFromEnv(os.Getenv)
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+
+	s := server.New(config,
+		server.WithLogger(logger),
+		server.WithMiddleware(server.Logging(logger), server.Recover(logger)),
+	)
+	httpServer, grpcServer := s.HTTPServer(), s.GRPCServer()
+
+	if grpcServer != nil {
+		go func() {
+			logger.Printf("gRPC server is running at address %s", grpcServer.Addr)
+			if err := grpcServer.ListenAndServe(); err != nil {
+				panic(fmt.Sprintf("cannot start gRPC server: %s", err))
+			}
+		}()
+	}
+
+	// Stop serving on interrupt, then stop the tenants' webhook deliveries
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
+		}
+	}()
 
-	log.Printf("Server is running at address %s", server.Addr)
-	err := server.ListenAndServe()
-	if err != nil {
+	logger.Printf("Server is running at address %s", httpServer.Addr)
+	err := httpServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		panic(fmt.Sprintf("cannot start server: %s", err))
 	}
+	s.Close()
 }
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"Ship_Manager/internal/repositories"
+	"Ship_Manager/internal/services"
+	"Ship_Manager/internal/tenants"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// catalogue is the set of operations shipctl can run, either against a local
+// sizes file or against a running server.
+type catalogue interface {
+	Add(size int) error
+	Remove(size int) error
+	List() ([]int, error)
+	Clear() error
+	Calculate(order int) (services.CalculationResult, error)
+	Optimize(req services.OptimizeRequest) ([]services.Recommendation, error)
+}
+
+// fileCatalogue keeps the pack sizes in a plain text file and runs the solver in process.
+type fileCatalogue struct {
+	path string
+}
+
+func newFileCatalogue(path string) *fileCatalogue {
+	return &fileCatalogue{path: path}
+}
+
+// load reads the sizes file into a fresh service. A missing file is an empty catalogue.
+// The sizes are added through the service, so a file is held to the same limits as the API.
+func (fc *fileCatalogue) load() (services.PackageService, error) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+
+	f, err := os.Open(fc.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return service, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sizes, err := readSizes(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fc.path, err)
+	}
+	for _, size := range sizes {
+		if err := service.AddPack(size); err != nil && err != repositories.ErrSizeAlreadyExists {
+			return nil, fmt.Errorf("%s: pack size %d: %w", fc.path, size, err)
+		}
+	}
+	return service, nil
+}
+
+func (fc *fileCatalogue) save(service services.PackageService) error {
+	f, err := os.Create(fc.path)
+	if err != nil {
+		return err
+	}
+	if err := writeSizes(f, service.GetPackSizes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (fc *fileCatalogue) Add(size int) error {
+	service, err := fc.load()
+	if err != nil {
+		return err
+	}
+	if err := service.AddPack(size); err != nil {
+		return err
+	}
+	return fc.save(service)
+}
+
+func (fc *fileCatalogue) Remove(size int) error {
+	service, err := fc.load()
+	if err != nil {
+		return err
+	}
+	if err := service.RemovePack(size); err != nil {
+		return err
+	}
+	return fc.save(service)
+}
+
+func (fc *fileCatalogue) List() ([]int, error) {
+	service, err := fc.load()
+	if err != nil {
+		return nil, err
+	}
+	return service.GetPackSizes(), nil
+}
+
+func (fc *fileCatalogue) Clear() error {
+	service, err := fc.load()
+	if err != nil {
+		return err
+	}
+	service.ClearPacks()
+	return fc.save(service)
+}
+
+func (fc *fileCatalogue) Calculate(order int) (services.CalculationResult, error) {
+	service, err := fc.load()
+	if err != nil {
+		return services.CalculationResult{}, err
+	}
+	result, err := service.Calculate(order)
+	if err != nil {
+		return services.CalculationResult{}, err
+	}
+	// The version of a catalogue loaded from a file means nothing outside this run
+	return services.NewCalculationResult(order, result.Packs), nil
+}
+
+func (fc *fileCatalogue) Optimize(req services.OptimizeRequest) ([]services.Recommendation, error) {
+	service, err := fc.load()
+	if err != nil {
+		return nil, err
+	}
+	return service.OptimizeCatalogue(req)
+}
+
+// remoteCatalogue talks to a running server over its HTTP API.
+type remoteCatalogue struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func newRemoteCatalogue(baseURL, apiKey string) *remoteCatalogue {
+	return &remoteCatalogue{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  http.DefaultClient,
+	}
+}
+
+// do sends a request and decodes a JSON response into out, if given.
+func (rc *remoteCatalogue) do(method, path string, form url.Values, out any) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequest(method, rc.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return rc.send(req, out)
+}
+
+// postJSON sends in as a JSON body and decodes a JSON response into out.
+func (rc *remoteCatalogue) postJSON(path string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, rc.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return rc.send(req, out)
+}
+
+// send executes a request and decodes a JSON response into out, if given.
+func (rc *remoteCatalogue) send(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	if rc.apiKey != "" {
+		req.Header.Set(tenants.APIKeyHeader, rc.apiKey)
+	}
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return &statusError{Method: req.Method, Path: req.URL.Path, StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(msg))}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// statusError is a response with a status other than 200 OK.
+type statusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s", e.Method, e.Path, e.Status, e.Body)
+}
+
+// hasStatus reports whether err is a response with the given status code.
+func hasStatus(err error, code int) bool {
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == code
+}
+
+func (rc *remoteCatalogue) Add(size int) error {
+	err := rc.do(http.MethodPost, "/pack-sizes", url.Values{"size": {strconv.Itoa(size)}}, nil)
+	if hasStatus(err, http.StatusConflict) {
+		return repositories.ErrSizeAlreadyExists
+	}
+	return err
+}
+
+func (rc *remoteCatalogue) Remove(size int) error {
+	err := rc.do(http.MethodDelete, "/pack-sizes/"+strconv.Itoa(size), nil, nil)
+	if hasStatus(err, http.StatusNotFound) {
+		return repositories.ErrSizeNotFound
+	}
+	return err
+}
+
+func (rc *remoteCatalogue) List() ([]int, error) {
+	var sizes []int
+	if err := rc.do(http.MethodGet, "/pack-sizes", nil, &sizes); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+func (rc *remoteCatalogue) Clear() error {
+	return rc.do(http.MethodDelete, "/pack-sizes", nil, nil)
+}
+
+func (rc *remoteCatalogue) Calculate(order int) (services.CalculationResult, error) {
+	var packs map[int]int
+	if err := rc.do(http.MethodPost, "/calculate", url.Values{"order": {strconv.Itoa(order)}}, &packs); err != nil {
+		return services.CalculationResult{}, err
+	}
+	return services.NewCalculationResult(order, packs), nil
+}
+
+func (rc *remoteCatalogue) Optimize(req services.OptimizeRequest) ([]services.Recommendation, error) {
+	var recommendations []services.Recommendation
+	if err := rc.postJSON("/optimize", req, &recommendations); err != nil {
+		return nil, err
+	}
+	return recommendations, nil
+}
+
+// readSizes parses a sizes file. It accepts a JSON array, or sizes separated by
+// whitespace or commas with "#" starting a comment. Every size must be positive.
+func readSizes(r io.Reader) ([]int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		var sizes []int
+		if err := json.Unmarshal([]byte(trimmed), &sizes); err != nil {
+			return nil, err
+		}
+		for i, size := range sizes {
+			if size <= 0 {
+				return nil, fmt.Errorf("entry %d: invalid pack size %d", i+1, size)
+			}
+		}
+		return sizes, nil
+	}
+
+	var sizes []int
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			size, err := strconv.Atoi(field)
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("line %d: invalid pack size %q", line, field)
+			}
+			sizes = append(sizes, size)
+		}
+	}
+	return sizes, scanner.Err()
+}
+
+// writeSizes writes one size per line in descending order.
+func writeSizes(w io.Writer, sizes []int) error {
+	sorted := append([]int{}, sizes...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	for _, size := range sorted {
+		if _, err := fmt.Fprintln(w, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
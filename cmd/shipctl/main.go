@@ -0,0 +1,226 @@
+// Command shipctl calculates pack combinations and manages the pack catalogue
+// from the command line, either offline against a sizes file or against a
+// running Ship Manager server.
+package main
+
+import (
+	"Ship_Manager/internal/services"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+const usage = `Usage: shipctl [flags] <command> [arguments]
+
+Commands:
+  calculate ORDER...   calculate packs for one or more order sizes
+  list                 list the pack sizes
+  add SIZE...          add pack sizes
+  remove SIZE...       remove pack sizes
+  clear                remove all pack sizes
+  import FILE          add every pack size listed in FILE
+  export [FILE]        write the pack sizes to FILE, or stdout
+  optimize [-objective excess|packs] [-top N] K ORDERS_FILE [CANDIDATE...]
+                       recommend the best K pack sizes for the order sizes in
+                       ORDERS_FILE, chosen from the candidates or, by default,
+                       the current sizes and the orders
+
+Flags:
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "shipctl:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the arguments and executes a single command.
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("shipctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	sizesFile := flags.String("sizes", "pack-sizes.txt", "sizes file used when no server is given")
+	serverURL := flags.String("server", os.Getenv("SHIPCTL_SERVER"), "base URL of a running server, e.g. http://localhost:8080")
+	apiKey := flags.String("api-key", os.Getenv("SHIPCTL_API_KEY"), "API key identifying the tenant on the server")
+	format := flags.String("o", formatTable, "output format: table, json or csv")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !validFormat(*format) {
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no command given")
+	}
+
+	var cat catalogue = newFileCatalogue(*sizesFile)
+	if *serverURL != "" {
+		cat = newRemoteCatalogue(*serverURL, *apiKey)
+	}
+
+	command, rest := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "calculate":
+		orders, err := parseInts(rest, "order size")
+		if err != nil {
+			return err
+		}
+		results := make([]services.CalculationResult, 0, len(orders))
+		for _, order := range orders {
+			result, err := cat.Calculate(order)
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+		return printResults(stdout, *format, results)
+
+	case "list":
+		sizes, err := cat.List()
+		if err != nil {
+			return err
+		}
+		return printSizes(stdout, *format, sizes)
+
+	case "add", "remove":
+		sizes, err := parseInts(rest, "pack size")
+		if err != nil {
+			return err
+		}
+		for _, size := range sizes {
+			if command == "add" {
+				err = cat.Add(size)
+			} else {
+				err = cat.Remove(size)
+			}
+			if err != nil {
+				return fmt.Errorf("%s %d: %w", command, size, err)
+			}
+		}
+		return nil
+
+	case "clear":
+		return cat.Clear()
+
+	case "import":
+		if len(rest) != 1 {
+			return errors.New("import expects exactly one file")
+		}
+		f, err := os.Open(rest[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		sizes, err := readSizes(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rest[0], err)
+		}
+		for _, size := range sizes {
+			if err := cat.Add(size); err != nil {
+				return fmt.Errorf("add %d: %w", size, err)
+			}
+		}
+		return nil
+
+	case "export":
+		sizes, err := cat.List()
+		if err != nil {
+			return err
+		}
+		if len(rest) == 0 {
+			return writeSizes(stdout, sizes)
+		}
+		f, err := os.Create(rest[0])
+		if err != nil {
+			return err
+		}
+		if err := writeSizes(f, sizes); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+
+	case "optimize":
+		req, err := parseOptimize(rest, stderr)
+		if err != nil {
+			return err
+		}
+		recommendations, err := cat.Optimize(req)
+		if err != nil {
+			return err
+		}
+		return printRecommendations(stdout, *format, recommendations)
+
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// parseOptimize reads the flags and arguments of the optimize command.
+func parseOptimize(args []string, stderr io.Writer) (services.OptimizeRequest, error) {
+	flags := flag.NewFlagSet("shipctl optimize", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	objective := flags.String("objective", services.ObjectiveExcess, "minimise total \"excess\" or \"packs\"")
+	top := flags.Int("top", 5, "number of recommendations to print")
+	if err := flags.Parse(args); err != nil {
+		return services.OptimizeRequest{}, err
+	}
+	if flags.NArg() < 2 {
+		return services.OptimizeRequest{}, errors.New("optimize expects K and an orders file")
+	}
+
+	k, err := strconv.Atoi(flags.Arg(0))
+	if err != nil || k <= 0 {
+		return services.OptimizeRequest{}, fmt.Errorf("invalid number of pack sizes %q", flags.Arg(0))
+	}
+
+	f, err := os.Open(flags.Arg(1))
+	if err != nil {
+		return services.OptimizeRequest{}, err
+	}
+	defer f.Close()
+	orders, err := readSizes(f)
+	if err != nil {
+		return services.OptimizeRequest{}, fmt.Errorf("%s: %w", flags.Arg(1), err)
+	}
+
+	var candidates []int
+	if flags.NArg() > 2 {
+		if candidates, err = parseInts(flags.Args()[2:], "pack size"); err != nil {
+			return services.OptimizeRequest{}, err
+		}
+	}
+
+	return services.OptimizeRequest{
+		Orders:          orders,
+		K:               k,
+		Candidates:      candidates,
+		Objective:       *objective,
+		Recommendations: *top,
+	}, nil
+}
+
+// parseInts converts the command arguments to positive integers.
+func parseInts(args []string, what string) ([]int, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected at least one %s", what)
+	}
+	values := make([]int, 0, len(args))
+	for _, arg := range args {
+		value, err := strconv.Atoi(arg)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", what, arg)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"Ship_Manager/internal/server"
+	"Ship_Manager/internal/services"
+	"Ship_Manager/internal/tenants"
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCommand(t *testing.T, args ...string) string {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(args, &stdout, &stderr)
+	require.NoError(t, err, stderr.String())
+	return stdout.String()
+}
+
+func TestOfflineCatalogue(t *testing.T) {
+	sizesFile := filepath.Join(t.TempDir(), "sizes.txt")
+
+	runCommand(t, "-sizes", sizesFile, "add", "250", "500", "1000")
+	runCommand(t, "-sizes", sizesFile, "remove", "1000")
+	assert.Equal(t, "[500,250]\n", runCommand(t, "-sizes", sizesFile, "-o", "json", "list"))
+
+	t.Run("Calculate as JSON", func(t *testing.T) {
+		var results []services.CalculationResult
+		out := runCommand(t, "-sizes", sizesFile, "-o", "json", "calculate", "501")
+		require.NoError(t, json.Unmarshal([]byte(out), &results))
+		assert.Equal(t, []services.CalculationResult{services.NewCalculationResult(501, map[int]int{500: 1, 250: 1})}, results)
+	})
+
+	t.Run("Calculate as CSV", func(t *testing.T) {
+		out := runCommand(t, "-sizes", sizesFile, "-o", "csv", "calculate", "501")
+		assert.Equal(t, "order,pack_size,count,total,excess,packs_count\n501,500,1,750,249,2\n501,250,1,750,249,2\n", out)
+	})
+
+	t.Run("Calculate as table", func(t *testing.T) {
+		out := runCommand(t, "-sizes", sizesFile, "calculate", "1", "501")
+		assert.Contains(t, out, "BREAKDOWN")
+		assert.Contains(t, out, "1x500 1x250")
+	})
+
+	t.Run("Import and export", func(t *testing.T) {
+		importFile := filepath.Join(t.TempDir(), "import.txt")
+		require.NoError(t, os.WriteFile(importFile, []byte("# promotional sizes\n2000, 5000\n"), 0o644))
+
+		runCommand(t, "-sizes", sizesFile, "import", importFile)
+		assert.Equal(t, "5000\n2000\n500\n250\n", runCommand(t, "-sizes", sizesFile, "export"))
+
+		runCommand(t, "-sizes", sizesFile, "clear")
+		assert.Equal(t, "", runCommand(t, "-sizes", sizesFile, "export"))
+	})
+
+	t.Run("Optimize", func(t *testing.T) {
+		ordersFile := filepath.Join(t.TempDir(), "orders.txt")
+		require.NoError(t, os.WriteFile(ordersFile, []byte("300\n700\n1000\n"), 0o644))
+
+		out := runCommand(t, "-sizes", sizesFile, "-o", "csv", "optimize", "-top", "1", "2", ordersFile)
+		assert.Equal(t, "rank,sizes,total_excess,total_packs,average_excess,average_packs\n1,700 300,0,4,0.00,1.33\n", out)
+
+		out = runCommand(t, "-sizes", sizesFile, "optimize", "-objective", "packs", "1", ordersFile, "500", "1000")
+		assert.Contains(t, out, "AVG PACKS")
+		assert.Contains(t, out, "1000")
+	})
+
+	t.Run("Sizes too large to calculate with", func(t *testing.T) {
+		largeFile := filepath.Join(t.TempDir(), "large.txt")
+		require.NoError(t, os.WriteFile(largeFile, []byte("5000000\n4999999\n"), 0o644))
+
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"-sizes", largeFile, "calculate", "100"}, &stdout, &stderr)
+		assert.ErrorIs(t, err, services.ErrTableTooLarge)
+		assert.NotContains(t, stdout.String(), "TOTAL")
+	})
+}
+
+func TestRemoteCatalogue(t *testing.T) {
+	s := server.New(server.Config{})
+	ts := httptest.NewServer(s.RegisterRoutes())
+	defer ts.Close()
+
+	runCommand(t, "-server", ts.URL, "add", "250", "500", "1000")
+	runCommand(t, "-server", ts.URL, "remove", "1000")
+	assert.Equal(t, "[500,250]\n", runCommand(t, "-server", ts.URL, "-o", "json", "list"))
+
+	out := runCommand(t, "-server", ts.URL, "-o", "csv", "calculate", "501")
+	assert.Equal(t, "order,pack_size,count,total,excess,packs_count\n501,500,1,750,249,2\n501,250,1,750,249,2\n", out)
+
+	ordersFile := filepath.Join(t.TempDir(), "orders.txt")
+	require.NoError(t, os.WriteFile(ordersFile, []byte("250 500 750"), 0o644))
+	var recommendations []services.Recommendation
+	out = runCommand(t, "-server", ts.URL, "-o", "json", "optimize", "-top", "1", "1", ordersFile)
+	require.NoError(t, json.Unmarshal([]byte(out), &recommendations))
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, []int{250}, recommendations[0].Sizes)
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"-server", ts.URL, "add", "500"}, &stdout, &stderr)
+	assert.ErrorContains(t, err, "already exists")
+
+	err = run([]string{"-server", ts.URL, "remove", "2000"}, &stdout, &stderr)
+	assert.ErrorContains(t, err, "pack size not found")
+
+	// Other 404s are not mistaken for a missing size
+	err = run([]string{"-server", ts.URL + "/wrong", "list"}, &stdout, &stderr)
+	assert.ErrorContains(t, err, "404 Not Found")
+
+	runCommand(t, "-server", ts.URL, "clear")
+	assert.True(t, strings.HasPrefix(runCommand(t, "-server", ts.URL, "list"), "PACK SIZE"))
+}
+
+func TestRemoteTenant(t *testing.T) {
+	s := server.New(server.Config{Tenants: tenants.Config{APIKeys: map[string]string{"acme-key": "acme"}}})
+	ts := httptest.NewServer(s.RegisterRoutes())
+	defer ts.Close()
+
+	runCommand(t, "-server", ts.URL, "-api-key", "acme-key", "add", "250")
+	assert.Equal(t, "[250]\n", runCommand(t, "-server", ts.URL, "-api-key", "acme-key", "-o", "json", "list"))
+	assert.Equal(t, "[]\n", runCommand(t, "-server", ts.URL, "-o", "json", "list"))
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"-server", ts.URL, "-api-key", "wrong", "list"}, &stdout, &stderr)
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestReadSizes(t *testing.T) {
+	sizes, err := readSizes(strings.NewReader("[250, 500]"))
+	require.NoError(t, err)
+	assert.Equal(t, []int{250, 500}, sizes)
+
+	_, err = readSizes(strings.NewReader("250\nabc\n"))
+	assert.ErrorContains(t, err, "line 2")
+
+	// Both formats reject sizes that are not positive
+	_, err = readSizes(strings.NewReader("250\n-5\n"))
+	assert.ErrorContains(t, err, "line 2")
+	_, err = readSizes(strings.NewReader("[250, 0, -5]"))
+	assert.ErrorContains(t, err, "entry 2")
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"Ship_Manager/internal/services"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Supported output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+func validFormat(format string) bool {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return true
+	}
+	return false
+}
+
+// sortedSizes returns the pack sizes of a result in descending order.
+func sortedSizes(packs map[int]int) []int {
+	sizes := make([]int, 0, len(packs))
+	for size := range packs {
+		sizes = append(sizes, size)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	return sizes
+}
+
+// printResults writes calculation results in the requested format.
+func printResults(w io.Writer, format string, results []services.CalculationResult) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"order", "pack_size", "count", "total", "excess", "packs_count"})
+		for _, result := range results {
+			for _, size := range sortedSizes(result.Packs) {
+				cw.Write([]string{
+					strconv.Itoa(result.OrderSize),
+					strconv.Itoa(size),
+					strconv.Itoa(result.Packs[size]),
+					strconv.Itoa(result.Total),
+					strconv.Itoa(result.ExcessItems),
+					strconv.Itoa(result.PacksCount),
+				})
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ORDER\tTOTAL\tEXCESS\tPACKS\tBREAKDOWN")
+		for _, result := range results {
+			breakdown := make([]string, 0, len(result.Packs))
+			for _, size := range sortedSizes(result.Packs) {
+				breakdown = append(breakdown, fmt.Sprintf("%dx%d", result.Packs[size], size))
+			}
+			fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%s\n",
+				result.OrderSize, result.Total, result.ExcessItems, result.PacksCount, strings.Join(breakdown, " "))
+		}
+		return tw.Flush()
+	}
+}
+
+// printSizes writes the pack sizes in the requested format.
+func printSizes(w io.Writer, format string, sizes []int) error {
+	switch format {
+	case formatJSON:
+		if sizes == nil {
+			sizes = []int{}
+		}
+		enc := json.NewEncoder(w)
+		return enc.Encode(sizes)
+
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"pack_size"})
+		for _, size := range sizes {
+			cw.Write([]string{strconv.Itoa(size)})
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PACK SIZE")
+		for _, size := range sizes {
+			fmt.Fprintln(tw, size)
+		}
+		return tw.Flush()
+	}
+}
+
+// printRecommendations writes ranked catalogue recommendations in the requested format.
+func printRecommendations(w io.Writer, format string, recommendations []services.Recommendation) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(recommendations)
+
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"rank", "sizes", "total_excess", "total_packs", "average_excess", "average_packs"})
+		for _, r := range recommendations {
+			cw.Write([]string{
+				strconv.Itoa(r.Rank),
+				joinSizes(r.Sizes),
+				strconv.Itoa(r.TotalExcess),
+				strconv.Itoa(r.TotalPacks),
+				strconv.FormatFloat(r.AverageExcess, 'f', 2, 64),
+				strconv.FormatFloat(r.AveragePacks, 'f', 2, 64),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "RANK\tSIZES\tEXCESS\tPACKS\tAVG EXCESS\tAVG PACKS")
+		for _, r := range recommendations {
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%.2f\t%.2f\n",
+				r.Rank, joinSizes(r.Sizes), r.TotalExcess, r.TotalPacks, r.AverageExcess, r.AveragePacks)
+		}
+		return tw.Flush()
+	}
+}
+
+// joinSizes formats pack sizes as a space separated list.
+func joinSizes(sizes []int) string {
+	parts := make([]string, len(sizes))
+	for i, size := range sizes {
+		parts[i] = strconv.Itoa(size)
+	}
+	return strings.Join(parts, " ")
+}
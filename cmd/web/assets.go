@@ -0,0 +1,91 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// asset is an embedded file together with the hash of its content.
+type asset struct {
+	name    string // Path inside assets/, e.g. "css/output.css"
+	content []byte
+	hash    string
+}
+
+var (
+	// assetsByName indexes the embedded assets by their plain path.
+	assetsByName = map[string]*asset{}
+	// assetsByHashedName indexes the embedded assets by their content-hashed path.
+	assetsByHashedName = map[string]*asset{}
+)
+
+func init() {
+	err := fs.WalkDir(Files, "assets", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := Files.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		a := &asset{
+			name:    strings.TrimPrefix(p, "assets/"),
+			content: content,
+			hash:    hex.EncodeToString(sum[:])[:12],
+		}
+		assetsByName[a.name] = a
+		assetsByHashedName[hashedName(a.name, a.hash)] = a
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// hashedName inserts the hash before the extension: "css/output.css" becomes "css/output.<hash>.css".
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// AssetPath returns the content-hashed URL of an embedded asset.
+// The URL changes whenever the file does, so it can be cached indefinitely.
+func AssetPath(name string) string {
+	a, ok := assetsByName[name]
+	if !ok {
+		return "/assets/" + name
+	}
+	return "/assets/" + hashedName(a.name, a.hash)
+}
+
+// AssetHandler serves the embedded assets under /assets/.
+// Content-hashed URLs are cached for a year; plain URLs must be revalidated using their ETag.
+func AssetHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/assets/")
+
+		if a, ok := assetsByHashedName[name]; ok {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			serveAsset(w, r, a)
+			return
+		}
+		if a, ok := assetsByName[name]; ok {
+			w.Header().Set("Cache-Control", "no-cache")
+			serveAsset(w, r, a)
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
+
+func serveAsset(w http.ResponseWriter, r *http.Request, a *asset) {
+	w.Header().Set("ETag", `"`+a.hash+`"`)
+	http.ServeContent(w, r, a.name, time.Time{}, bytes.NewReader(a.content))
+}
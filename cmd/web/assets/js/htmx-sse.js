@@ -0,0 +1,59 @@
+/*
+ * Minimal Server-Sent Events extension for htmx 2.
+ *
+ * Supports the same attributes as the official htmx "sse" extension for the
+ * parts this application uses:
+ *   hx-ext="sse" sse-connect="/events"  opens an EventSource on the element
+ *   hx-trigger="sse:eventName"          fires on descendants when eventName arrives
+ *
+ * The connection is closed once the element is removed from the page.
+ */
+(function () {
+  "use strict";
+
+  function eventNames(root) {
+    var names = {};
+    var elements = [root].concat(Array.prototype.slice.call(root.querySelectorAll("[hx-trigger]")));
+    elements.forEach(function (elt) {
+      var trigger = elt.getAttribute("hx-trigger") || "";
+      var re = /sse:([\w-]+)/g;
+      var match;
+      while ((match = re.exec(trigger)) !== null) {
+        names[match[1]] = true;
+      }
+    });
+    return Object.keys(names);
+  }
+
+  function connect(elt) {
+    var source = new EventSource(elt.getAttribute("sse-connect"));
+    elt.__htmxSSE = source;
+
+    eventNames(elt).forEach(function (name) {
+      source.addEventListener(name, function (event) {
+        if (!document.body.contains(elt)) {
+          source.close();
+          return;
+        }
+        var targets = [elt].concat(Array.prototype.slice.call(elt.querySelectorAll("[hx-trigger]")));
+        targets.forEach(function (target) {
+          if ((target.getAttribute("hx-trigger") || "").indexOf("sse:" + name) !== -1) {
+            htmx.trigger(target, "sse:" + name, { data: event.data });
+          }
+        });
+      });
+    });
+  }
+
+  htmx.defineExtension("sse", {
+    onEvent: function (name, evt) {
+      if (name !== "htmx:afterProcessNode") {
+        return;
+      }
+      var elt = evt.detail.elt;
+      if (elt.hasAttribute && elt.hasAttribute("sse-connect") && !elt.__htmxSSE) {
+        connect(elt);
+      }
+    }
+  });
+})();
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAssetHandler(t *testing.T) {
+	handler := AssetHandler()
+
+	t.Run("Hashed path is cached", func(t *testing.T) {
+		hashed := AssetPath("js/htmx.min.js")
+		if hashed == "/assets/js/htmx.min.js" || !strings.HasSuffix(hashed, ".js") {
+			t.Fatalf("Expected a content-hashed path, got %s", hashed)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", hashed, nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %d", rr.Code)
+		}
+		if cc := rr.Header().Get("Cache-Control"); !strings.Contains(cc, "immutable") {
+			t.Errorf("Expected immutable Cache-Control, got %q", cc)
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.Contains(ct, "javascript") {
+			t.Errorf("Expected a JavaScript Content-Type, got %q", ct)
+		}
+	})
+
+	t.Run("Plain path is revalidated", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/assets/css/output.css", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status OK, got %d", rr.Code)
+		}
+		if cc := rr.Header().Get("Cache-Control"); cc != "no-cache" {
+			t.Errorf("Expected no-cache, got %q", cc)
+		}
+
+		req := httptest.NewRequest("GET", "/assets/css/output.css", nil)
+		req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotModified {
+			t.Errorf("Expected status Not Modified, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Unknown asset", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/assets/js/missing.js", nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status Not Found, got %d", rr.Code)
+		}
+	})
+}
+
+var (
+	classAttr     = regexp.MustCompile(`class="([^"]*)"|class=\{([^}]*)\}`)
+	classFunc     = regexp.MustCompile(`(?s)func \w*Class\([^)]*\) string \{(.*?)\n\}`)
+	stringLiteral = regexp.MustCompile(`"([^"]*)"`)
+	returnLiteral = regexp.MustCompile(`return "([^"]*)"`)
+	styleBlock    = regexp.MustCompile(`(?s)<style>(.*?)</style>`)
+	selector      = regexp.MustCompile(`\.([\w-]+)`)
+)
+
+// TestStylesheetCoversTemplates checks that output.css was rebuilt with Tailwind after the
+// templates changed: every class they use must have a rule, unless the template styles it.
+func TestStylesheetCoversTemplates(t *testing.T) {
+	css, err := os.ReadFile("assets/css/output.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, _ := filepath.Glob("*.templ")
+
+	for _, name := range templates {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var classes []string
+		for _, m := range classAttr.FindAllStringSubmatch(string(src), -1) {
+			classes = append(classes, strings.Fields(m[1])...)
+			for _, literal := range stringLiteral.FindAllStringSubmatch(m[2], -1) {
+				classes = append(classes, strings.Fields(literal[1])...)
+			}
+		}
+		for _, m := range classFunc.FindAllStringSubmatch(string(src), -1) {
+			for _, literal := range returnLiteral.FindAllStringSubmatch(m[1], -1) {
+				classes = append(classes, strings.Fields(literal[1])...)
+			}
+		}
+		local := make(map[string]bool)
+		for _, m := range styleBlock.FindAllStringSubmatch(string(src), -1) {
+			for _, sel := range selector.FindAllStringSubmatch(m[1], -1) {
+				local[sel[1]] = true
+			}
+		}
+
+		for _, class := range classes {
+			rule := "." + strings.NewReplacer(":", `\:`, "/", `\/`, ".", `\.`).Replace(class) + " {"
+			if !local[class] && !strings.Contains(string(css), rule) {
+				t.Errorf("%s uses class %q, which output.css has no rule for; run make build to regenerate it", name, class)
+			}
+		}
+	}
+}
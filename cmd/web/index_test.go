@@ -0,0 +1,30 @@
+package web
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPackSizesList(t *testing.T) {
+	// The list replaces itself, so the fragment it is swapped for must keep the refresh trigger
+	for _, packSizes := range [][]int{nil, {500, 250}} {
+		var b strings.Builder
+		if err := PackSizesList(packSizes).Render(context.Background(), &b); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+
+		root := regexp.MustCompile(`^\s*<div[^>]*>`).FindString(b.String())
+		for _, attribute := range []string{
+			`id="pack-sizes"`,
+			`hx-trigger="packSizesChanged from:body, sse:packSizesChanged"`,
+			`hx-get="/pack-sizes"`,
+			`hx-swap="outerHTML"`,
+		} {
+			if !strings.Contains(root, attribute) {
+				t.Errorf("Sizes %v: expected the root element %q to have %s", packSizes, root, attribute)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package events provides an in-memory publish/subscribe hub used to push
+// catalogue changes to connected clients.
+package events
+
+import "sync"
+
+// subscriberBuffer is the number of events queued for a subscriber before new ones are dropped.
+const subscriberBuffer = 16
+
+// Event is a named message broadcast to every subscriber.
+type Event struct {
+	Name string
+	Data string
+}
+
+// Hub fans out published events to all current subscribers and handlers.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	handlers    map[*func(Event)]struct{}
+}
+
+// NewHub creates and returns an empty Hub.
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[chan Event]struct{}),
+		handlers:    make(map[*func(Event)]struct{}),
+	}
+}
+
+// Subscribe registers a new subscriber.
+// It returns the channel events are delivered on and a function that removes
+// the subscriber and closes the channel. The function is safe to call more than once.
+func (h *Hub) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Handle registers a handler called with every event from the publishing goroutine,
+// before Publish returns, so unlike a subscriber it never misses an event.
+// It returns a function that removes the handler. The function is safe to call more than once.
+func (h *Hub) Handle(handler func(Event)) func() {
+	key := &handler
+	h.mu.Lock()
+	h.handlers[key] = struct{}{}
+	h.mu.Unlock()
+
+	return func() {
+		h.mu.Lock()
+		delete(h.handlers, key)
+		h.mu.Unlock()
+	}
+}
+
+// Publish delivers the event to every subscriber without blocking, then calls every handler.
+// Subscribers whose buffer is full miss the event.
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	handlers := make([]func(Event), 0, len(h.handlers))
+	for handler := range h.handlers {
+		handlers = append(handlers, *handler)
+	}
+	h.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Len returns the number of current subscribers.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestHub(t *testing.T) {
+	t.Run("Publish reaches every subscriber", func(t *testing.T) {
+		hub := NewHub()
+		first, unsubscribeFirst := hub.Subscribe()
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := hub.Subscribe()
+		defer unsubscribeSecond()
+
+		hub.Publish(Event{Name: "packSizesChanged", Data: "[250]"})
+
+		for _, ch := range []<-chan Event{first, second} {
+			event := <-ch
+			if event.Name != "packSizesChanged" || event.Data != "[250]" {
+				t.Errorf("Unexpected event %+v", event)
+			}
+		}
+	})
+
+	t.Run("Unsubscribe removes the subscriber and closes its channel", func(t *testing.T) {
+		hub := NewHub()
+		ch, unsubscribe := hub.Subscribe()
+		if hub.Len() != 1 {
+			t.Fatalf("Expected 1 subscriber, got %d", hub.Len())
+		}
+
+		unsubscribe()
+		unsubscribe()
+
+		if hub.Len() != 0 {
+			t.Errorf("Expected no subscribers, got %d", hub.Len())
+		}
+		if _, ok := <-ch; ok {
+			t.Errorf("Expected the channel to be closed")
+		}
+
+		// Publishing after unsubscribing must not panic on the closed channel
+		hub.Publish(Event{Name: "packSizesChanged"})
+	})
+
+	t.Run("Handlers get every event", func(t *testing.T) {
+		hub := NewHub()
+		var got []string
+		remove := hub.Handle(func(event Event) { got = append(got, event.Name) })
+
+		for range subscriberBuffer * 2 {
+			hub.Publish(Event{Name: "packSizesChanged"})
+		}
+		remove()
+		remove()
+		hub.Publish(Event{Name: "packSizesChanged"})
+
+		if len(got) != subscriberBuffer*2 {
+			t.Errorf("Expected %d events, got %d", subscriberBuffer*2, len(got))
+		}
+	})
+
+	t.Run("Slow subscribers do not block publishing", func(t *testing.T) {
+		hub := NewHub()
+		_, unsubscribe := hub.Subscribe()
+		defer unsubscribe()
+
+		for range subscriberBuffer * 2 {
+			hub.Publish(Event{Name: "packSizesChanged"})
+		}
+	})
+}
@@ -0,0 +1,674 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: packagespb/packages.proto
+
+package packagespb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AddPackRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Size int64 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+}
+
+func (x *AddPackRequest) Reset() {
+	*x = AddPackRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packagespb_packages_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddPackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddPackRequest) ProtoMessage() {}
+
+func (x *AddPackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_packagespb_packages_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddPackRequest.ProtoReflect.Descriptor instead.
+func (*AddPackRequest) Descriptor() ([]byte, []int) {
+	return file_packagespb_packages_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AddPackRequest) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type RemovePackRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Size int64 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+}
+
+func (x *RemovePackRequest) Reset() {
+	*x = RemovePackRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packagespb_packages_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemovePackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemovePackRequest) ProtoMessage() {}
+
+func (x *RemovePackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_packagespb_packages_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemovePackRequest.ProtoReflect.Descriptor instead.
+func (*RemovePackRequest) Descriptor() ([]byte, []int) {
+	return file_packagespb_packages_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RemovePackRequest) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type ListPackSizesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListPackSizesRequest) Reset() {
+	*x = ListPackSizesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packagespb_packages_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPackSizesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPackSizesRequest) ProtoMessage() {}
+
+func (x *ListPackSizesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_packagespb_packages_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPackSizesRequest.ProtoReflect.Descriptor instead.
+func (*ListPackSizesRequest) Descriptor() ([]byte, []int) {
+	return file_packagespb_packages_proto_rawDescGZIP(), []int{2}
+}
+
+type ClearPacksRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ClearPacksRequest) Reset() {
+	*x = ClearPacksRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packagespb_packages_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClearPacksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearPacksRequest) ProtoMessage() {}
+
+func (x *ClearPacksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_packagespb_packages_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearPacksRequest.ProtoReflect.Descriptor instead.
+func (*ClearPacksRequest) Descriptor() ([]byte, []int) {
+	return file_packagespb_packages_proto_rawDescGZIP(), []int{3}
+}
+
+// PackSizes lists the pack sizes of the catalogue in descending order.
+type PackSizes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sizes []int64 `protobuf:"varint,1,rep,packed,name=sizes,proto3" json:"sizes,omitempty"`
+}
+
+func (x *PackSizes) Reset() {
+	*x = PackSizes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packagespb_packages_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PackSizes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackSizes) ProtoMessage() {}
+
+func (x *PackSizes) ProtoReflect() protoreflect.Message {
+	mi := &file_packagespb_packages_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PackSizes.ProtoReflect.Descriptor instead.
+func (*PackSizes) Descriptor() ([]byte, []int) {
+	return file_packagespb_packages_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PackSizes) GetSizes() []int64 {
+	if x != nil {
+		return x.Sizes
+	}
+	return nil
+}
+
+type CalculateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OrderSize int64 `protobuf:"varint,1,opt,name=order_size,json=orderSize,proto3" json:"order_size,omitempty"`
+}
+
+func (x *CalculateRequest) Reset() {
+	*x = CalculateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packagespb_packages_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CalculateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateRequest) ProtoMessage() {}
+
+func (x *CalculateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_packagespb_packages_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateRequest.ProtoReflect.Descriptor instead.
+func (*CalculateRequest) Descriptor() ([]byte, []int) {
+	return file_packagespb_packages_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CalculateRequest) GetOrderSize() int64 {
+	if x != nil {
+		return x.OrderSize
+	}
+	return 0
+}
+
+// Pack is a number of packs of one size.
+type Pack struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Size  int64 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Count int64 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *Pack) Reset() {
+	*x = Pack{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packagespb_packages_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Pack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pack) ProtoMessage() {}
+
+func (x *Pack) ProtoReflect() protoreflect.Message {
+	mi := &file_packagespb_packages_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pack.ProtoReflect.Descriptor instead.
+func (*Pack) Descriptor() ([]byte, []int) {
+	return file_packagespb_packages_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Pack) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Pack) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type CalculateResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OrderSize int64 `protobuf:"varint,1,opt,name=order_size,json=orderSize,proto3" json:"order_size,omitempty"`
+	// Packs used, largest size first.
+	Packs []*Pack `protobuf:"bytes,2,rep,name=packs,proto3" json:"packs,omitempty"`
+	// Total number of items shipped.
+	Total int64 `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	// Number of items shipped in excess of the order.
+	ExcessItems int64 `protobuf:"varint,4,opt,name=excess_items,json=excessItems,proto3" json:"excess_items,omitempty"`
+	// Total number of packs used.
+	PacksCount int64 `protobuf:"varint,5,opt,name=packs_count,json=packsCount,proto3" json:"packs_count,omitempty"`
+	// Catalogue version the result was calculated against.
+	CatalogueVersion int64 `protobuf:"varint,6,opt,name=catalogue_version,json=catalogueVersion,proto3" json:"catalogue_version,omitempty"`
+}
+
+func (x *CalculateResponse) Reset() {
+	*x = CalculateResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packagespb_packages_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CalculateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateResponse) ProtoMessage() {}
+
+func (x *CalculateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_packagespb_packages_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateResponse.ProtoReflect.Descriptor instead.
+func (*CalculateResponse) Descriptor() ([]byte, []int) {
+	return file_packagespb_packages_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CalculateResponse) GetOrderSize() int64 {
+	if x != nil {
+		return x.OrderSize
+	}
+	return 0
+}
+
+func (x *CalculateResponse) GetPacks() []*Pack {
+	if x != nil {
+		return x.Packs
+	}
+	return nil
+}
+
+func (x *CalculateResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *CalculateResponse) GetExcessItems() int64 {
+	if x != nil {
+		return x.ExcessItems
+	}
+	return 0
+}
+
+func (x *CalculateResponse) GetPacksCount() int64 {
+	if x != nil {
+		return x.PacksCount
+	}
+	return 0
+}
+
+func (x *CalculateResponse) GetCatalogueVersion() int64 {
+	if x != nil {
+		return x.CatalogueVersion
+	}
+	return 0
+}
+
+var File_packagespb_packages_proto protoreflect.FileDescriptor
+
+var file_packagespb_packages_proto_rawDesc = []byte{
+	0x0a, 0x19, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x73, 0x70, 0x62, 0x2f, 0x70, 0x61, 0x63,
+	0x6b, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x73, 0x68, 0x69,
+	0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22, 0x24, 0x0a, 0x0e, 0x41,
+	0x64, 0x64, 0x50, 0x61, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x69, 0x7a,
+	0x65, 0x22, 0x27, 0x0a, 0x11, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x50, 0x61, 0x63, 0x6b, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x22, 0x16, 0x0a, 0x14, 0x4c, 0x69,
+	0x73, 0x74, 0x50, 0x61, 0x63, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x22, 0x13, 0x0a, 0x11, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x50, 0x61, 0x63, 0x6b, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x21, 0x0a, 0x09, 0x50, 0x61, 0x63, 0x6b, 0x53,
+	0x69, 0x7a, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x69, 0x7a, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x03, 0x52, 0x05, 0x73, 0x69, 0x7a, 0x65, 0x73, 0x22, 0x31, 0x0a, 0x10, 0x43, 0x61,
+	0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d,
+	0x0a, 0x0a, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x53, 0x69, 0x7a, 0x65, 0x22, 0x30, 0x0a,
+	0x04, 0x50, 0x61, 0x63, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22,
+	0xe5, 0x01, 0x0a, 0x11, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x5f, 0x73,
+	0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x6f, 0x72, 0x64, 0x65, 0x72,
+	0x53, 0x69, 0x7a, 0x65, 0x12, 0x2a, 0x0a, 0x05, 0x70, 0x61, 0x63, 0x6b, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x63, 0x6b, 0x52, 0x05, 0x70, 0x61, 0x63, 0x6b, 0x73,
+	0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x21, 0x0a, 0x0c, 0x65, 0x78, 0x63, 0x65, 0x73, 0x73,
+	0x5f, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x65, 0x78,
+	0x63, 0x65, 0x73, 0x73, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x61, 0x63,
+	0x6b, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a,
+	0x70, 0x61, 0x63, 0x6b, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2b, 0x0a, 0x11, 0x63, 0x61,
+	0x74, 0x61, 0x6c, 0x6f, 0x67, 0x75, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x6f, 0x67, 0x75, 0x65,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x32, 0xed, 0x03, 0x0a, 0x0e, 0x50, 0x61, 0x63, 0x6b,
+	0x61, 0x67, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x44, 0x0a, 0x07, 0x41, 0x64,
+	0x64, 0x50, 0x61, 0x63, 0x6b, 0x12, 0x1e, 0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61,
+	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x64, 0x64, 0x50, 0x61, 0x63, 0x6b, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61,
+	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x63, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x73,
+	0x12, 0x4a, 0x0a, 0x0a, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x50, 0x61, 0x63, 0x6b, 0x12, 0x21,
+	0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x50, 0x61, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x19, 0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x50, 0x61, 0x63, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x12, 0x50, 0x0a, 0x0d,
+	0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x63, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x12, 0x24, 0x2e,
+	0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x50, 0x61, 0x63, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x63, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x12, 0x4a,
+	0x0a, 0x0a, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x50, 0x61, 0x63, 0x6b, 0x73, 0x12, 0x21, 0x2e, 0x73,
+	0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6c,
+	0x65, 0x61, 0x72, 0x50, 0x61, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x19, 0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x50, 0x61, 0x63, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x12, 0x50, 0x0a, 0x09, 0x43, 0x61,
+	0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x20, 0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61,
+	0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61,
+	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x73, 0x68, 0x69, 0x70,
+	0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x61, 0x6c, 0x63, 0x75,
+	0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x59, 0x0a, 0x0e,
+	0x42, 0x61, 0x74, 0x63, 0x68, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x20,
+	0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x21, 0x2e, 0x73, 0x68, 0x69, 0x70, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42, 0x2a, 0x5a, 0x28, 0x53, 0x68, 0x69, 0x70, 0x5f,
+	0x4d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c,
+	0x2f, 0x67, 0x72, 0x70, 0x63, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65,
+	0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_packagespb_packages_proto_rawDescOnce sync.Once
+	file_packagespb_packages_proto_rawDescData = file_packagespb_packages_proto_rawDesc
+)
+
+func file_packagespb_packages_proto_rawDescGZIP() []byte {
+	file_packagespb_packages_proto_rawDescOnce.Do(func() {
+		file_packagespb_packages_proto_rawDescData = protoimpl.X.CompressGZIP(file_packagespb_packages_proto_rawDescData)
+	})
+	return file_packagespb_packages_proto_rawDescData
+}
+
+var file_packagespb_packages_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_packagespb_packages_proto_goTypes = []any{
+	(*AddPackRequest)(nil),       // 0: shipmanager.v1.AddPackRequest
+	(*RemovePackRequest)(nil),    // 1: shipmanager.v1.RemovePackRequest
+	(*ListPackSizesRequest)(nil), // 2: shipmanager.v1.ListPackSizesRequest
+	(*ClearPacksRequest)(nil),    // 3: shipmanager.v1.ClearPacksRequest
+	(*PackSizes)(nil),            // 4: shipmanager.v1.PackSizes
+	(*CalculateRequest)(nil),     // 5: shipmanager.v1.CalculateRequest
+	(*Pack)(nil),                 // 6: shipmanager.v1.Pack
+	(*CalculateResponse)(nil),    // 7: shipmanager.v1.CalculateResponse
+}
+var file_packagespb_packages_proto_depIdxs = []int32{
+	6, // 0: shipmanager.v1.CalculateResponse.packs:type_name -> shipmanager.v1.Pack
+	0, // 1: shipmanager.v1.PackageService.AddPack:input_type -> shipmanager.v1.AddPackRequest
+	1, // 2: shipmanager.v1.PackageService.RemovePack:input_type -> shipmanager.v1.RemovePackRequest
+	2, // 3: shipmanager.v1.PackageService.ListPackSizes:input_type -> shipmanager.v1.ListPackSizesRequest
+	3, // 4: shipmanager.v1.PackageService.ClearPacks:input_type -> shipmanager.v1.ClearPacksRequest
+	5, // 5: shipmanager.v1.PackageService.Calculate:input_type -> shipmanager.v1.CalculateRequest
+	5, // 6: shipmanager.v1.PackageService.BatchCalculate:input_type -> shipmanager.v1.CalculateRequest
+	4, // 7: shipmanager.v1.PackageService.AddPack:output_type -> shipmanager.v1.PackSizes
+	4, // 8: shipmanager.v1.PackageService.RemovePack:output_type -> shipmanager.v1.PackSizes
+	4, // 9: shipmanager.v1.PackageService.ListPackSizes:output_type -> shipmanager.v1.PackSizes
+	4, // 10: shipmanager.v1.PackageService.ClearPacks:output_type -> shipmanager.v1.PackSizes
+	7, // 11: shipmanager.v1.PackageService.Calculate:output_type -> shipmanager.v1.CalculateResponse
+	7, // 12: shipmanager.v1.PackageService.BatchCalculate:output_type -> shipmanager.v1.CalculateResponse
+	7, // [7:13] is the sub-list for method output_type
+	1, // [1:7] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_packagespb_packages_proto_init() }
+func file_packagespb_packages_proto_init() {
+	if File_packagespb_packages_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_packagespb_packages_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*AddPackRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packagespb_packages_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*RemovePackRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packagespb_packages_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*ListPackSizesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packagespb_packages_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*ClearPacksRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packagespb_packages_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*PackSizes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packagespb_packages_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*CalculateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packagespb_packages_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*Pack); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packagespb_packages_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*CalculateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_packagespb_packages_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_packagespb_packages_proto_goTypes,
+		DependencyIndexes: file_packagespb_packages_proto_depIdxs,
+		MessageInfos:      file_packagespb_packages_proto_msgTypes,
+	}.Build()
+	File_packagespb_packages_proto = out.File
+	file_packagespb_packages_proto_rawDesc = nil
+	file_packagespb_packages_proto_goTypes = nil
+	file_packagespb_packages_proto_depIdxs = nil
+}
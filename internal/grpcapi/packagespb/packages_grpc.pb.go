@@ -0,0 +1,326 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: packagespb/packages.proto
+
+package packagespb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PackageService_AddPack_FullMethodName        = "/shipmanager.v1.PackageService/AddPack"
+	PackageService_RemovePack_FullMethodName     = "/shipmanager.v1.PackageService/RemovePack"
+	PackageService_ListPackSizes_FullMethodName  = "/shipmanager.v1.PackageService/ListPackSizes"
+	PackageService_ClearPacks_FullMethodName     = "/shipmanager.v1.PackageService/ClearPacks"
+	PackageService_Calculate_FullMethodName      = "/shipmanager.v1.PackageService/Calculate"
+	PackageService_BatchCalculate_FullMethodName = "/shipmanager.v1.PackageService/BatchCalculate"
+)
+
+// PackageServiceClient is the client API for PackageService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PackageService manages the pack sizes of a tenant's catalogue and calculates
+// the packs needed to fulfil orders. It mirrors the HTTP package API.
+type PackageServiceClient interface {
+	// AddPack adds a pack size to the catalogue.
+	AddPack(ctx context.Context, in *AddPackRequest, opts ...grpc.CallOption) (*PackSizes, error)
+	// RemovePack removes a pack size from the catalogue.
+	RemovePack(ctx context.Context, in *RemovePackRequest, opts ...grpc.CallOption) (*PackSizes, error)
+	// ListPackSizes returns the pack sizes available now.
+	ListPackSizes(ctx context.Context, in *ListPackSizesRequest, opts ...grpc.CallOption) (*PackSizes, error)
+	// ClearPacks removes every pack size from the catalogue.
+	ClearPacks(ctx context.Context, in *ClearPacksRequest, opts ...grpc.CallOption) (*PackSizes, error)
+	// Calculate returns the packs needed for one order.
+	Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error)
+	// BatchCalculate answers every order received on the stream in the order
+	// it was sent. A failed calculation ends the stream with its error.
+	BatchCalculate(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[CalculateRequest, CalculateResponse], error)
+}
+
+type packageServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPackageServiceClient(cc grpc.ClientConnInterface) PackageServiceClient {
+	return &packageServiceClient{cc}
+}
+
+func (c *packageServiceClient) AddPack(ctx context.Context, in *AddPackRequest, opts ...grpc.CallOption) (*PackSizes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PackSizes)
+	err := c.cc.Invoke(ctx, PackageService_AddPack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) RemovePack(ctx context.Context, in *RemovePackRequest, opts ...grpc.CallOption) (*PackSizes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PackSizes)
+	err := c.cc.Invoke(ctx, PackageService_RemovePack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) ListPackSizes(ctx context.Context, in *ListPackSizesRequest, opts ...grpc.CallOption) (*PackSizes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PackSizes)
+	err := c.cc.Invoke(ctx, PackageService_ListPackSizes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) ClearPacks(ctx context.Context, in *ClearPacksRequest, opts ...grpc.CallOption) (*PackSizes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PackSizes)
+	err := c.cc.Invoke(ctx, PackageService_ClearPacks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CalculateResponse)
+	err := c.cc.Invoke(ctx, PackageService_Calculate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) BatchCalculate(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[CalculateRequest, CalculateResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PackageService_ServiceDesc.Streams[0], PackageService_BatchCalculate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CalculateRequest, CalculateResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PackageService_BatchCalculateClient = grpc.BidiStreamingClient[CalculateRequest, CalculateResponse]
+
+// PackageServiceServer is the server API for PackageService service.
+// All implementations must embed UnimplementedPackageServiceServer
+// for forward compatibility.
+//
+// PackageService manages the pack sizes of a tenant's catalogue and calculates
+// the packs needed to fulfil orders. It mirrors the HTTP package API.
+type PackageServiceServer interface {
+	// AddPack adds a pack size to the catalogue.
+	AddPack(context.Context, *AddPackRequest) (*PackSizes, error)
+	// RemovePack removes a pack size from the catalogue.
+	RemovePack(context.Context, *RemovePackRequest) (*PackSizes, error)
+	// ListPackSizes returns the pack sizes available now.
+	ListPackSizes(context.Context, *ListPackSizesRequest) (*PackSizes, error)
+	// ClearPacks removes every pack size from the catalogue.
+	ClearPacks(context.Context, *ClearPacksRequest) (*PackSizes, error)
+	// Calculate returns the packs needed for one order.
+	Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error)
+	// BatchCalculate answers every order received on the stream in the order
+	// it was sent. A failed calculation ends the stream with its error.
+	BatchCalculate(grpc.BidiStreamingServer[CalculateRequest, CalculateResponse]) error
+	mustEmbedUnimplementedPackageServiceServer()
+}
+
+// UnimplementedPackageServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPackageServiceServer struct{}
+
+func (UnimplementedPackageServiceServer) AddPack(context.Context, *AddPackRequest) (*PackSizes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPack not implemented")
+}
+func (UnimplementedPackageServiceServer) RemovePack(context.Context, *RemovePackRequest) (*PackSizes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovePack not implemented")
+}
+func (UnimplementedPackageServiceServer) ListPackSizes(context.Context, *ListPackSizesRequest) (*PackSizes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPackSizes not implemented")
+}
+func (UnimplementedPackageServiceServer) ClearPacks(context.Context, *ClearPacksRequest) (*PackSizes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearPacks not implemented")
+}
+func (UnimplementedPackageServiceServer) Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Calculate not implemented")
+}
+func (UnimplementedPackageServiceServer) BatchCalculate(grpc.BidiStreamingServer[CalculateRequest, CalculateResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BatchCalculate not implemented")
+}
+func (UnimplementedPackageServiceServer) mustEmbedUnimplementedPackageServiceServer() {}
+func (UnimplementedPackageServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafePackageServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PackageServiceServer will
+// result in compilation errors.
+type UnsafePackageServiceServer interface {
+	mustEmbedUnimplementedPackageServiceServer()
+}
+
+func RegisterPackageServiceServer(s grpc.ServiceRegistrar, srv PackageServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPackageServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PackageService_ServiceDesc, srv)
+}
+
+func _PackageService_AddPack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddPackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).AddPack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackageService_AddPack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).AddPack(ctx, req.(*AddPackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_RemovePack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovePackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).RemovePack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackageService_RemovePack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).RemovePack(ctx, req.(*RemovePackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_ListPackSizes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPackSizesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).ListPackSizes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackageService_ListPackSizes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).ListPackSizes(ctx, req.(*ListPackSizesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_ClearPacks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearPacksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).ClearPacks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackageService_ClearPacks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).ClearPacks(ctx, req.(*ClearPacksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_Calculate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CalculateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).Calculate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackageService_Calculate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).Calculate(ctx, req.(*CalculateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_BatchCalculate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PackageServiceServer).BatchCalculate(&grpc.GenericServerStream[CalculateRequest, CalculateResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PackageService_BatchCalculateServer = grpc.BidiStreamingServer[CalculateRequest, CalculateResponse]
+
+// PackageService_ServiceDesc is the grpc.ServiceDesc for PackageService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PackageService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "shipmanager.v1.PackageService",
+	HandlerType: (*PackageServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddPack",
+			Handler:    _PackageService_AddPack_Handler,
+		},
+		{
+			MethodName: "RemovePack",
+			Handler:    _PackageService_RemovePack_Handler,
+		},
+		{
+			MethodName: "ListPackSizes",
+			Handler:    _PackageService_ListPackSizes_Handler,
+		},
+		{
+			MethodName: "ClearPacks",
+			Handler:    _PackageService_ClearPacks_Handler,
+		},
+		{
+			MethodName: "Calculate",
+			Handler:    _PackageService_Calculate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BatchCalculate",
+			Handler:       _PackageService_BatchCalculate_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "packagespb/packages.proto",
+}
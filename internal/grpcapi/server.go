@@ -0,0 +1,202 @@
+// Package grpcapi serves the package API over gRPC, backed by the same
+// services.PackageService as the HTTP handlers.
+package grpcapi
+
+import (
+	"Ship_Manager/internal/grpcapi/packagespb"
+	"Ship_Manager/internal/repositories"
+	"Ship_Manager/internal/services"
+	"Ship_Manager/internal/tenants"
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceFunc returns the package service of a tenant.
+type ServiceFunc func(tenant string) services.PackageService
+
+// NewServer creates a gRPC server exposing the PackageService. The tenant of every
+// call is resolved from its metadata (x-api-key, authorization, x-tenant-id and the
+// authority) in the same way as HTTP requests.
+func NewServer(config tenants.Config, service ServiceFunc, opts ...grpc.ServerOption) *grpc.Server {
+	r := tenantResolver{config: config}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(r.unary),
+		grpc.ChainStreamInterceptor(r.stream),
+	)
+	server := grpc.NewServer(opts...)
+	packagespb.RegisterPackageServiceServer(server, &packageServer{service: service})
+	return server
+}
+
+// tenantResolver stores the tenant of each call in its context.
+type tenantResolver struct {
+	config tenants.Config
+}
+
+func (tr tenantResolver) resolve(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := http.Header{}
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	tenant, err := tr.config.ResolveCredentials(tenants.APIKey(header), header.Get(tenants.HeaderName), header.Get(":authority"))
+	switch {
+	case err == nil:
+		return tenants.WithTenant(ctx, tenant), nil
+	case errors.Is(err, tenants.ErrUnknownAPIKey), errors.Is(err, tenants.ErrAPIKeyRequired):
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, tenants.ErrTenantMismatch):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, tenants.ErrUnknownTenant):
+		return nil, status.Error(codes.NotFound, err.Error())
+	default:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+}
+
+func (tr tenantResolver) unary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := tr.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (tr tenantResolver) stream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := tr.resolve(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &tenantStream{ServerStream: ss, ctx: ctx})
+}
+
+// tenantStream is a server stream whose context carries the tenant.
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ts *tenantStream) Context() context.Context {
+	return ts.ctx
+}
+
+// packageServer implements packagespb.PackageServiceServer.
+type packageServer struct {
+	packagespb.UnimplementedPackageServiceServer
+	service ServiceFunc
+}
+
+func (ps *packageServer) tenantService(ctx context.Context) services.PackageService {
+	return ps.service(tenants.FromContext(ctx))
+}
+
+func (ps *packageServer) AddPack(ctx context.Context, req *packagespb.AddPackRequest) (*packagespb.PackSizes, error) {
+	if req.GetSize() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "pack size must be positive")
+	}
+	service := ps.tenantService(ctx)
+	if err := service.AddPack(int(req.GetSize())); err != nil {
+		return nil, statusError(err)
+	}
+	return packSizes(service.GetPackSizes()), nil
+}
+
+func (ps *packageServer) RemovePack(ctx context.Context, req *packagespb.RemovePackRequest) (*packagespb.PackSizes, error) {
+	service := ps.tenantService(ctx)
+	if err := service.RemovePack(int(req.GetSize())); err != nil {
+		return nil, statusError(err)
+	}
+	return packSizes(service.GetPackSizes()), nil
+}
+
+func (ps *packageServer) ListPackSizes(ctx context.Context, _ *packagespb.ListPackSizesRequest) (*packagespb.PackSizes, error) {
+	return packSizes(ps.tenantService(ctx).GetPackSizes()), nil
+}
+
+func (ps *packageServer) ClearPacks(ctx context.Context, _ *packagespb.ClearPacksRequest) (*packagespb.PackSizes, error) {
+	service := ps.tenantService(ctx)
+	service.ClearPacks()
+	return packSizes(service.GetPackSizes()), nil
+}
+
+func (ps *packageServer) Calculate(ctx context.Context, req *packagespb.CalculateRequest) (*packagespb.CalculateResponse, error) {
+	return calculate(ps.tenantService(ctx), req)
+}
+
+func (ps *packageServer) BatchCalculate(stream packagespb.PackageService_BatchCalculateServer) error {
+	service := ps.tenantService(stream.Context())
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		resp, err := calculate(service, req)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// calculate answers one calculation request.
+func calculate(service services.PackageService, req *packagespb.CalculateRequest) (*packagespb.CalculateResponse, error) {
+	if req.GetOrderSize() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "order size must be positive")
+	}
+	result, err := service.Calculate(int(req.GetOrderSize()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &packagespb.CalculateResponse{
+		OrderSize:        int64(result.OrderSize),
+		Total:            int64(result.Total),
+		ExcessItems:      int64(result.ExcessItems),
+		PacksCount:       int64(result.PacksCount),
+		CatalogueVersion: int64(result.CatalogueVersion),
+	}
+	for size, count := range result.Packs {
+		resp.Packs = append(resp.Packs, &packagespb.Pack{Size: int64(size), Count: int64(count)})
+	}
+	slices.SortFunc(resp.Packs, func(a, b *packagespb.Pack) int { return cmp.Compare(b.Size, a.Size) })
+	return resp, nil
+}
+
+func packSizes(sizes []int) *packagespb.PackSizes {
+	resp := &packagespb.PackSizes{Sizes: make([]int64, len(sizes))}
+	for i, size := range sizes {
+		resp.Sizes[i] = int64(size)
+	}
+	return resp
+}
+
+// statusError maps service errors to gRPC status codes.
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrSizeAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repositories.ErrSizeNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		// The catalogue cannot fulfil the request, e.g. because of its pack rules
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+}
@@ -0,0 +1,182 @@
+package grpcapi
+
+import (
+	"Ship_Manager/internal/grpcapi/packagespb"
+	"Ship_Manager/internal/repositories"
+	"Ship_Manager/internal/services"
+	"Ship_Manager/internal/tenants"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves the API over an in-memory connection with one service per tenant.
+func newTestClient(t *testing.T, config tenants.Config) packagespb.PackageServiceClient {
+	t.Helper()
+
+	var mu sync.Mutex
+	tenantServices := map[string]services.PackageService{}
+	server := NewServer(config, func(tenant string) services.PackageService {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := tenantServices[tenant]; !ok {
+			tenantServices[tenant] = services.NewPackageService(repositories.NewPackageRepository())
+		}
+		return tenantServices[tenant]
+	})
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return packagespb.NewPackageServiceClient(conn)
+}
+
+func TestPackSizes(t *testing.T) {
+	client := newTestClient(t, tenants.Config{})
+	ctx := context.Background()
+
+	for _, size := range []int64{250, 1000, 500} {
+		_, err := client.AddPack(ctx, &packagespb.AddPackRequest{Size: size})
+		require.NoError(t, err)
+	}
+
+	sizes, err := client.ListPackSizes(ctx, &packagespb.ListPackSizesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1000, 500, 250}, sizes.GetSizes())
+
+	_, err = client.AddPack(ctx, &packagespb.AddPackRequest{Size: 500})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.AddPack(ctx, &packagespb.AddPackRequest{Size: 0})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	sizes, err = client.RemovePack(ctx, &packagespb.RemovePackRequest{Size: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{500, 250}, sizes.GetSizes())
+
+	_, err = client.RemovePack(ctx, &packagespb.RemovePackRequest{Size: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	sizes, err = client.ClearPacks(ctx, &packagespb.ClearPacksRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, sizes.GetSizes())
+}
+
+func TestCalculate(t *testing.T) {
+	client := newTestClient(t, tenants.Config{})
+	ctx := context.Background()
+	for _, size := range []int64{250, 500, 1000, 2000, 5000} {
+		_, err := client.AddPack(ctx, &packagespb.AddPackRequest{Size: size})
+		require.NoError(t, err)
+	}
+
+	resp, err := client.Calculate(ctx, &packagespb.CalculateRequest{OrderSize: 12001})
+	require.NoError(t, err)
+	assert.Equal(t, []*packagespb.Pack{{Size: 5000, Count: 2}, {Size: 2000, Count: 1}, {Size: 250, Count: 1}}, resp.GetPacks())
+	assert.Equal(t, int64(12250), resp.GetTotal())
+	assert.Equal(t, int64(249), resp.GetExcessItems())
+	assert.Equal(t, int64(4), resp.GetPacksCount())
+	assert.Positive(t, resp.GetCatalogueVersion())
+
+	_, err = client.Calculate(ctx, &packagespb.CalculateRequest{OrderSize: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBatchCalculate(t *testing.T) {
+	client := newTestClient(t, tenants.Config{})
+	ctx := context.Background()
+	for _, size := range []int64{250, 500, 1000} {
+		_, err := client.AddPack(ctx, &packagespb.AddPackRequest{Size: size})
+		require.NoError(t, err)
+	}
+
+	t.Run("Answers every order in sequence", func(t *testing.T) {
+		stream, err := client.BatchCalculate(ctx)
+		require.NoError(t, err)
+
+		orders := []int64{1, 251, 1001}
+		for _, order := range orders {
+			require.NoError(t, stream.Send(&packagespb.CalculateRequest{OrderSize: order}))
+		}
+		require.NoError(t, stream.CloseSend())
+
+		var totals []int64
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			totals = append(totals, resp.GetTotal())
+		}
+		assert.Equal(t, []int64{250, 500, 1250}, totals)
+	})
+
+	t.Run("Invalid order ends the stream", func(t *testing.T) {
+		stream, err := client.BatchCalculate(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&packagespb.CalculateRequest{OrderSize: 0}))
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestTenants(t *testing.T) {
+	client := newTestClient(t, tenants.Config{APIKeys: map[string]string{"acme-key": "acme"}, Tenants: []string{"globex"}})
+
+	acme := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "acme-key")
+	globex := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "globex")
+
+	_, err := client.AddPack(acme, &packagespb.AddPackRequest{Size: 250})
+	require.NoError(t, err)
+	_, err = client.AddPack(globex, &packagespb.AddPackRequest{Size: 300})
+	require.NoError(t, err)
+
+	sizes, err := client.ListPackSizes(acme, &packagespb.ListPackSizesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{250}, sizes.GetSizes())
+
+	sizes, err = client.ListPackSizes(globex, &packagespb.ListPackSizesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{300}, sizes.GetSizes())
+
+	unknown := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = client.ListPackSizes(unknown, &packagespb.ListPackSizesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Tenants with an API key cannot be named without it, and unlisted tenants not at all
+	named := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "acme")
+	_, err = client.ListPackSizes(named, &packagespb.ListPackSizesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	unlisted := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "initech")
+	_, err = client.ListPackSizes(unlisted, &packagespb.ListPackSizesRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	mismatch := metadata.AppendToOutgoingContext(acme, "x-tenant-id", "globex")
+	stream, err := client.BatchCalculate(mismatch)
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"Ship_Manager/internal/rates"
+	"Ship_Manager/internal/repositories"
+	"Ship_Manager/internal/services"
+	"Ship_Manager/internal/webhooks"
+	"Ship_Manager/pkg/client"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient serves the handlers backed by a real service and returns a client for them.
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+
+	tables := rates.Tables{{Carrier: "Swift", Bands: []rates.Band{{Zone: "EU", MaxWeight: 10000, Price: 40}, {Zone: "EU", MaxWeight: 20000, Price: 70}}}}
+	ph := NewPackageHandler(services.NewPackageService(repositories.NewPackageRepository(), services.WithRateTables(tables)))
+	outbox, err := webhooks.NewOutbox("")
+	require.NoError(t, err)
+	wh := NewWebhookHandler(webhooks.NewDispatcher(outbox, nil, "default"))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pack-sizes", ph.PackSizes)
+	mux.HandleFunc("POST /pack-sizes", ph.AddPack)
+	mux.HandleFunc("DELETE /pack-sizes", ph.ClearPacks)
+	mux.HandleFunc("DELETE /pack-sizes/{size}", ph.RemovePack)
+	mux.HandleFunc("GET /pack-schedules", ph.PackSchedules)
+	mux.HandleFunc("GET /pack-rules", ph.PackRules)
+	mux.HandleFunc("POST /pack-rules", ph.SetPackRule)
+	mux.HandleFunc("GET /packaging-levels", ph.PackagingLevels)
+	mux.HandleFunc("POST /packaging-levels", ph.AddPackagingLevel)
+	mux.HandleFunc("DELETE /packaging-levels/{name}", ph.RemovePackagingLevel)
+	mux.HandleFunc("POST /calculate", ph.Calculate)
+	mux.HandleFunc("POST /calculate-order", ph.CalculateOrder)
+	mux.HandleFunc("POST /what-if", ph.WhatIf)
+	mux.HandleFunc("POST /optimize", ph.Optimize)
+	mux.HandleFunc("POST /load-containers", ph.LoadContainers)
+	mux.HandleFunc("POST /packing-slip", ph.PackingSlip)
+	mux.HandleFunc("GET /versions", ph.Versions)
+	mux.HandleFunc("GET /versions/diff", ph.VersionDiff)
+	mux.HandleFunc("POST /rollback", ph.Rollback)
+	mux.HandleFunc("GET /webhooks", wh.Webhooks)
+	mux.HandleFunc("POST /webhooks", wh.Subscribe)
+	mux.HandleFunc("DELETE /webhooks/{id}", wh.Unsubscribe)
+	mux.HandleFunc("GET /webhooks/deliveries", wh.Deliveries)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return client.New(server.URL)
+}
+
+func TestClientCatalogue(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	for _, size := range []int{250, 500, 1000} {
+		require.NoError(t, c.AddPack(ctx, size))
+	}
+	sizes, err := c.PackSizes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1000, 500, 250}, sizes)
+
+	err = c.AddPack(ctx, 500)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, "Pack size already exists", apiErr.Message)
+	assert.Equal(t, []client.FieldError{{Field: "size", Message: "already exists"}}, apiErr.Fields)
+
+	err = c.RemovePack(ctx, 42)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+
+	launch := time.Now().AddDate(0, 0, 7).UTC().Truncate(time.Second)
+	require.NoError(t, c.AddScheduledPack(ctx, 2000, client.Schedule{EffectiveFrom: launch}))
+	schedules, err := c.PackSchedules(ctx)
+	require.NoError(t, err)
+	assert.True(t, schedules[2000].EffectiveFrom.Equal(launch))
+
+	sizes, err = c.PackSizesAt(ctx, launch)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2000, 1000, 500, 250}, sizes)
+
+	rules, err := c.SetPackRule(ctx, 250, client.PackRule{MaxCount: 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]client.PackRule{250: {MaxCount: 1}}, rules)
+
+	require.NoError(t, c.RemovePack(ctx, 2000))
+	require.NoError(t, c.ClearPacks(ctx))
+	sizes, err = c.PackSizes(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, sizes)
+}
+
+func TestClientCalculations(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	for _, size := range []int{250, 500, 1000, 2000, 5000} {
+		require.NoError(t, c.AddPack(ctx, size))
+	}
+
+	result, err := c.Calculate(ctx, 12001)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int{5000: 2, 2000: 1, 250: 1}, result.Packs)
+	assert.Equal(t, 249, result.ExcessItems)
+	assert.Equal(t, 6, result.CatalogueVersion)
+
+	result, err = c.CalculateShipments(ctx, 12001, client.ShipmentLimits{MaxItems: 5000})
+	require.NoError(t, err)
+	assert.Len(t, result.Shipments, 3)
+
+	explanation, err := c.Explain(ctx, 251)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int{500: 1}, explanation.Chosen.Packs)
+
+	_, err = c.CalculateExact(ctx, 251)
+	var fit *client.NoExactFit
+	require.ErrorAs(t, err, &fit)
+	assert.Equal(t, 250, fit.Below)
+	assert.Equal(t, 500, fit.Above)
+
+	order, err := c.CalculateOrder(ctx, []client.OrderLine{{SKU: "A", Quantity: 251}, {SKU: "B", Quantity: 10, Sizes: []int{3, 5}}})
+	require.NoError(t, err)
+	assert.Equal(t, 510, order.TotalItems)
+
+	whatIf, err := c.WhatIf(ctx, client.WhatIfRequest{Sizes: []int{300}, Orders: []int{300, 600}})
+	require.NoError(t, err)
+	assert.Equal(t, 0, whatIf.Proposed.TotalExcess)
+
+	recommendations, err := c.Optimize(ctx, client.OptimizeRequest{Orders: []int{300, 600}, K: 1, Candidates: []int{250, 300}})
+	require.NoError(t, err)
+	assert.Equal(t, []int{300}, recommendations[0].Sizes)
+
+	_, err = c.Optimize(ctx, client.OptimizeRequest{Orders: []int{300}, K: 1, Objective: "cheapest"})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+
+	pdf, err := c.PackingSlipPDF(ctx, "SO-1042", result)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+	_, err = c.PackingSlipPDF(ctx, "", result)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+
+	shipped, err := c.CalculateShipping(ctx, 12001, client.ShippingOptions{Zone: "EU", ItemWeight: 1, PackCost: 2})
+	require.NoError(t, err)
+	assert.Equal(t, &client.ShippingEstimate{
+		Zone: "EU", Weight: 12250, PackagingCost: 8, LandedCost: 78,
+		Quotes: []client.Quote{{Carrier: "Swift", Zone: "EU", MaxWeight: 20000, Price: 70}},
+	}, shipped.Shipping)
+
+	_, err = c.CalculateShipping(ctx, 12001, client.ShippingOptions{Zone: "EU"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, []client.FieldError{{Field: "itemWeight", Message: "must be positive for shipping costs"}}, apiErr.Fields)
+
+	loaded, err := c.LoadContainers(ctx, client.ContainerRequest{
+		Order:      12001,
+		Dimensions: map[int]client.PackDimensions{5000: {Volume: 50, Weight: 20}, 2000: {Volume: 20, Weight: 8}, 250: {Volume: 3, Weight: 1}},
+		Containers: []client.Container{{Name: "pallet", Volume: 100, MaxWeight: 45}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []client.ContainerLoad{
+		{Container: "pallet", Packs: map[int]int{5000: 2}, Volume: 100, Weight: 40, VolumeUtilisation: 100, WeightUtilisation: 88.89},
+		{Container: "pallet", Packs: map[int]int{2000: 1, 250: 1}, Volume: 23, Weight: 9, VolumeUtilisation: 23, WeightUtilisation: 20},
+	}, loaded.Containers)
+
+	_, err = c.LoadContainers(ctx, client.ContainerRequest{Packs: map[int]int{5000: 1},
+		Dimensions: map[int]client.PackDimensions{5000: {Volume: 50}}, Containers: []client.Container{{Name: "box", Volume: 10}}})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+}
+
+func TestClientPackagingLevels(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := c.AddPackagingLevel(ctx, "pack", 12, "item")
+	require.NoError(t, err)
+	levels, err := c.AddPackagingLevel(ctx, "pallet", 40, "pack")
+	require.NoError(t, err)
+	assert.Equal(t, []client.PackagingLevel{
+		{Name: "pallet", Quantity: 40, Unit: "pack", Size: 480},
+		{Name: "pack", Quantity: 12, Unit: "item", Size: 12},
+	}, levels)
+
+	result, err := c.Calculate(ctx, 490)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int{480: 1, 12: 1}, result.Packs)
+	assert.Equal(t, []client.LevelCount{
+		{Level: "pallet", Size: 480, Count: 1, Quantity: 40, Unit: "pack"},
+		{Level: "pack", Size: 12, Count: 1, Quantity: 12, Unit: "item"},
+	}, result.Levels)
+	assert.Equal(t, 3, result.CatalogueVersion)
+
+	err = c.RemovePackagingLevel(ctx, "pack")
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+
+	require.NoError(t, c.RemovePackagingLevel(ctx, "pallet"))
+	levels, err = c.PackagingLevels(ctx)
+	require.NoError(t, err)
+	assert.Len(t, levels, 1)
+}
+
+func TestClientHistory(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	require.NoError(t, c.AddPack(ctx, 250))
+	require.NoError(t, c.AddPack(ctx, 500))
+
+	versions, err := c.Versions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+
+	diff, err := c.VersionDiff(ctx, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int{500}, diff.Added)
+
+	restored, err := c.Rollback(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, restored.ID)
+	assert.Equal(t, []int{250}, restored.Sizes)
+
+	_, err = c.VersionDiff(ctx, 1, 99)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestClientWebhooks(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	sub, err := c.Subscribe(ctx, client.Subscription{URL: "https://example.com/hook", Secret: "s", Events: []string{"largeCalculation"}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, sub.ID)
+	assert.Empty(t, sub.Secret)
+
+	subs, err := c.Webhooks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, subs, 1)
+
+	deliveries, err := c.Deliveries(ctx, "failed")
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	require.NoError(t, c.Unsubscribe(ctx, sub.ID))
+	var apiErr *client.Error
+	require.ErrorAs(t, c.Unsubscribe(ctx, sub.ID), &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"Ship_Manager/cmd/web"
+	"Ship_Manager/internal/openapi"
+	"net/http"
+
+	"github.com/a-h/templ"
+)
+
+// Docs handles GET requests for the API documentation page.
+// The page is rendered from the OpenAPI document served at /openapi.json.
+func Docs(w http.ResponseWriter, r *http.Request) {
+	doc, err := openapi.Load()
+	if err != nil {
+		http.Error(w, "An error occurred while loading the API documentation", http.StatusInternalServerError)
+		return
+	}
+	templ.Handler(web.DocsPage(doc)).ServeHTTP(w, r)
+}
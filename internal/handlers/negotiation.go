@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"Ship_Manager/cmd/web"
+	"Ship_Manager/internal/services"
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+// format is a response representation.
+type format int
+
+const (
+	formatJSON format = iota
+	formatCSV
+	formatText
+	formatHTML
+	formatPDF
+)
+
+// mediaTypes maps the media types a client may accept to their formats.
+// When a client accepts several with the same quality, the earliest format wins.
+var mediaTypes = map[string]format{
+	"application/json": formatJSON,
+	"text/csv":         formatCSV,
+	"text/plain":       formatText,
+	"text/html":        formatHTML,
+	"application/pdf":  formatPDF,
+}
+
+// negotiate chooses the response format from the Accept header. htmx requests always
+// get HTML. Requests without a supported media type, or accepting anything, get fallback.
+func negotiate(r *http.Request, fallback format) format {
+	if r.Header.Get("HX-Request") == "true" {
+		return formatHTML
+	}
+
+	type candidate struct {
+		format  format
+		quality float64
+	}
+	var candidates []candidate
+	for _, entry := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, parameters, err := mime.ParseMediaType(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := parameters["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		f, ok := mediaTypes[mediaType]
+		if !ok {
+			if mediaType != "*/*" {
+				continue
+			}
+			f = fallback
+		}
+		candidates = append(candidates, candidate{f, quality})
+	}
+	if len(candidates) == 0 {
+		return fallback
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].quality != candidates[j].quality {
+			return candidates[i].quality > candidates[j].quality
+		}
+		return candidates[i].format < candidates[j].format
+	})
+	return candidates[0].format
+}
+
+// prefersHTML reports whether the response should be an HTML fragment rather than JSON.
+// htmx requests always get HTML; other clients get JSON unless they prefer HTML.
+func prefersHTML(r *http.Request) bool {
+	return negotiate(r, formatJSON) == formatHTML
+}
+
+// errorResponse is the JSON body of an error.
+type errorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"` // Invalid request fields, for validation errors
+}
+
+// writeError reports an error in the negotiated format.
+// HTML errors are rendered as a message and trigger the "errorMessage" event.
+func writeError(w http.ResponseWriter, r *http.Request, fallback format, statusCode int, message string, fields ...FieldError) {
+	body := errorResponse{Error: message, Fields: fields}
+
+	switch negotiate(r, fallback) {
+	case formatHTML:
+		w.Header().Set("HX-Trigger", "errorMessage")
+		w.WriteHeader(statusCode)
+		templ.Handler(web.ErrorMessage(errorMessage(body))).ServeHTTP(w, r)
+	case formatCSV:
+		writeCSV(w, statusCode, errorRecords(body))
+	case formatText:
+		writeText(w, statusCode, errorMessage(body))
+	default:
+		writeJSONStatus(w, statusCode, body)
+	}
+}
+
+// writeValidationError reports the invalid fields of a request with HTTP 400.
+func writeValidationError(w http.ResponseWriter, r *http.Request, fallback format, err *ValidationError) {
+	writeError(w, r, fallback, http.StatusBadRequest, "invalid request", err.Fields...)
+}
+
+// errorMessage formats an error as one line, e.g. "invalid request: size must be positive".
+func errorMessage(body errorResponse) string {
+	if len(body.Fields) == 0 {
+		return body.Error
+	}
+	parts := make([]string, len(body.Fields))
+	for i, field := range body.Fields {
+		parts[i] = field.Field + " " + field.Message
+	}
+	return body.Error + ": " + strings.Join(parts, "; ")
+}
+
+// errorRecords formats an error as CSV records with a header.
+func errorRecords(body errorResponse) [][]string {
+	records := [][]string{{"field", "error"}}
+	if len(body.Fields) == 0 {
+		return append(records, []string{"", body.Error})
+	}
+	for _, field := range body.Fields {
+		records = append(records, []string{field.Field, field.Message})
+	}
+	return records
+}
+
+// writeCSV writes records as the CSV response body with the given status code.
+func writeCSV(w http.ResponseWriter, statusCode int, records [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(statusCode)
+	csv.NewWriter(w).WriteAll(records)
+}
+
+// writeText writes text as the plain text response body with the given status code.
+func writeText(w http.ResponseWriter, statusCode int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+	fmt.Fprintln(w, text)
+}
+
+// sizeRecords formats pack sizes as CSV records with a header.
+func sizeRecords(sizes []int) [][]string {
+	records := [][]string{{"size"}}
+	for _, size := range sizes {
+		records = append(records, []string{strconv.Itoa(size)})
+	}
+	return records
+}
+
+// sizesText formats pack sizes one per line.
+func sizesText(sizes []int) string {
+	lines := make([]string, len(sizes))
+	for i, size := range sizes {
+		lines[i] = strconv.Itoa(size)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// resultSizes returns the pack sizes used in a result, largest first.
+func resultSizes(result services.CalculationResult) []int {
+	sizes := make([]int, 0, len(result.Packs))
+	for size := range result.Packs {
+		sizes = append(sizes, size)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	return sizes
+}
+
+// resultRecords formats a calculation as CSV records: one row per pack size, largest first.
+// Results with packaging levels get a "level" column naming the level of each size.
+func resultRecords(result services.CalculationResult) [][]string {
+	if len(result.Levels) > 0 {
+		records := [][]string{{"level", "size", "count", "items"}}
+		for _, level := range result.Levels {
+			records = append(records, []string{level.Level, strconv.Itoa(level.Size), strconv.Itoa(level.Count), strconv.Itoa(level.Size * level.Count)})
+		}
+		return records
+	}
+
+	records := [][]string{{"size", "count", "items"}}
+	for _, size := range resultSizes(result) {
+		count := result.Packs[size]
+		records = append(records, []string{strconv.Itoa(size), strconv.Itoa(count), strconv.Itoa(size * count)})
+	}
+	return records
+}
+
+// resultText formats a calculation for humans, e.g. "2 x 5000" per line followed by
+// the packaging levels, such as "2 pallets + 1 case", the shipping quotes and the totals.
+func resultText(result services.CalculationResult) string {
+	var b strings.Builder
+	for _, size := range resultSizes(result) {
+		fmt.Fprintf(&b, "%d x %d\n", result.Packs[size], size)
+	}
+	if len(result.Levels) > 0 {
+		fmt.Fprintf(&b, "%s\n", services.DescribeLevels(result.Levels))
+	}
+	if shipping := result.Shipping; shipping != nil {
+		fmt.Fprintf(&b, "Shipping %g to %s:", shipping.Weight, shipping.Zone)
+		if len(shipping.Quotes) == 0 {
+			b.WriteString(" no carrier")
+		}
+		for i, quote := range shipping.Quotes {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, " %s %.2f", quote.Carrier, quote.Price)
+		}
+		fmt.Fprintf(&b, "\nLanded cost %.2f (packaging %.2f)\n", shipping.LandedCost, shipping.PackagingCost)
+	}
+	fmt.Fprintf(&b, "%d packs, %d items for an order of %d (%d excess)", result.PacksCount, result.Total, result.OrderSize, result.ExcessItems)
+	return b.String()
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"Ship_Manager/internal/repositories"
+	"Ship_Manager/internal/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		htmx     bool
+		fallback format
+		want     format
+	}{
+		{name: "No Accept header", fallback: formatJSON, want: formatJSON},
+		{name: "No Accept header with HTML fallback", fallback: formatHTML, want: formatHTML},
+		{name: "htmx", accept: "application/json", htmx: true, fallback: formatJSON, want: formatHTML},
+		{name: "JSON", accept: "application/json", fallback: formatHTML, want: formatJSON},
+		{name: "CSV", accept: "text/csv", fallback: formatJSON, want: formatCSV},
+		{name: "Plain text", accept: "text/plain; charset=utf-8", fallback: formatJSON, want: formatText},
+		{name: "JSON wins a tie with HTML", accept: "text/html, application/json", fallback: formatHTML, want: formatJSON},
+		{name: "Quality", accept: "application/json;q=0.5, text/csv", fallback: formatJSON, want: formatCSV},
+		{name: "Browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", fallback: formatJSON, want: formatHTML},
+		{name: "Anything", accept: "*/*", fallback: formatHTML, want: formatHTML},
+		{name: "Unsupported", accept: "application/xml", fallback: formatJSON, want: formatJSON},
+		{name: "Refused", accept: "text/csv;q=0, text/plain", fallback: formatJSON, want: formatText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.htmx {
+				req.Header.Set("HX-Request", "true")
+			}
+			assert.Equal(t, tt.want, negotiate(req, tt.fallback))
+		})
+	}
+}
+
+func TestRequestFormats(t *testing.T) {
+	t.Run("JSON body", func(t *testing.T) {
+		mockService := new(MockPackageService)
+		handler := NewPackageHandler(mockService)
+		mockService.On("AddPack", 750).Return(nil).Once()
+		mockService.On("GetPackSizes").Return([]int{750}).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/add-pack", strings.NewReader(`{"size": 750}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.AddPack(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[750]`, rr.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("JSON body with string values", func(t *testing.T) {
+		mockService := new(MockPackageService)
+		handler := NewPackageHandler(mockService)
+		mockService.On("ExplainPacks", 251).Return(services.Explanation{Chosen: services.NewCalculationResult(251, map[int]int{500: 1})}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/calculate", strings.NewReader(`{"order": "251", "explain": true}`))
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Query values", func(t *testing.T) {
+		mockService := new(MockPackageService)
+		handler := NewPackageHandler(mockService)
+		mockService.On("Calculate", 250).Return(services.NewCalculationResult(250, map[int]int{250: 1}), nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/calculate?order=250", nil)
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"250": 1}`, rr.Body.String())
+	})
+
+	t.Run("Body wins over the query", func(t *testing.T) {
+		mockService := new(MockPackageService)
+		handler := NewPackageHandler(mockService)
+		mockService.On("Calculate", 500).Return(services.NewCalculationResult(500, map[int]int{500: 1}), nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/calculate?order=250", strings.NewReader(`{"order": 500}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestResponseFormats(t *testing.T) {
+	result := services.NewCalculationResult(12001, map[int]int{5000: 2, 2000: 1, 250: 1})
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{accept: "text/csv", contentType: "text/csv", body: "size,count,items\n5000,2,10000\n2000,1,2000\n250,1,250\n"},
+		{accept: "text/plain", contentType: "text/plain", body: "2 x 5000\n1 x 2000\n1 x 250\n4 packs, 12250 items for an order of 12001 (249 excess)\n"},
+		{accept: "application/json", contentType: "application/json", body: `{"5000":2,"2000":1,"250":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			mockService := new(MockPackageService)
+			handler := NewPackageHandler(mockService)
+			mockService.On("Calculate", 12001).Return(result, nil).Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/calculate?order=12001", nil)
+			req.Header.Set("Accept", tt.accept)
+			rr := httptest.NewRecorder()
+
+			handler.Calculate(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Header().Get("Content-Type"), tt.contentType)
+			if tt.contentType == "application/json" {
+				assert.JSONEq(t, tt.body, rr.Body.String())
+			} else {
+				assert.Equal(t, tt.body, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("Pack sizes as CSV", func(t *testing.T) {
+		mockService := new(MockPackageService)
+		handler := NewPackageHandler(mockService)
+		mockService.On("GetPackSizes").Return([]int{500, 250}).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/pack-sizes", nil)
+		req.Header.Set("Accept", "text/csv")
+		rr := httptest.NewRecorder()
+
+		handler.PackSizes(rr, req)
+
+		assert.Equal(t, "size\n500\n250\n", rr.Body.String())
+	})
+}
+
+func TestValidationErrors(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	decode := func(t *testing.T, rr *httptest.ResponseRecorder) errorResponse {
+		t.Helper()
+		var body errorResponse
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+		return body
+	}
+
+	t.Run("Every invalid field is reported", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/calculate", strings.NewReader(`{"order": -5, "maxItemsPerShipment": "many", "mode": "fast"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, errorResponse{Error: "invalid request", Fields: []FieldError{
+			{Field: "order", Message: "must be positive"},
+			{Field: "maxItemsPerShipment", Message: "must be a whole number"},
+			{Field: "mode", Message: `must be "nearest" or "exact"`},
+		}}, decode(t, rr))
+	})
+
+	t.Run("Missing field", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/add-pack", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.AddPack(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, []FieldError{{Field: "size", Message: "is required"}}, decode(t, rr).Fields)
+	})
+
+	t.Run("Malformed JSON", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/add-pack", strings.NewReader(`{"size": `))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.AddPack(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "body", decode(t, rr).Fields[0].Field)
+	})
+
+	t.Run("Nested value", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/add-pack", strings.NewReader(`{"size": [250]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.AddPack(rr, req)
+
+		assert.Equal(t, []FieldError{{Field: "size", Message: "must be a string, number or boolean"}}, decode(t, rr).Fields)
+	})
+
+	t.Run("Plain text", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/calculate?order=abc", nil)
+		req.Header.Set("Accept", "text/plain")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "invalid request: order must be a whole number\n", rr.Body.String())
+	})
+
+	t.Run("Conflict points at the field", func(t *testing.T) {
+		mockService.On("AddPack", 250).Return(repositories.ErrSizeAlreadyExists).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/add-pack?size=250", nil)
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.AddPack(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, []FieldError{{Field: "size", Message: "already exists"}}, decode(t, rr).Fields)
+	})
+}
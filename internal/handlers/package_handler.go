@@ -4,13 +4,24 @@ import (
 	"Ship_Manager/cmd/web"
 	"Ship_Manager/internal/repositories"
 	"Ship_Manager/internal/services"
+	"Ship_Manager/internal/slips"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/a-h/templ"
 )
 
+// sseHeartbeat is how often a comment is sent to keep idle event streams open.
+const sseHeartbeat = 15 * time.Second
+
 // PackageHandler is responsible for handling HTTP requests related to package management.
 // It provides methods for adding pack sizes, calculating packs for orders, clearing all packs,
 // and retrieving pack sizes.
@@ -25,94 +36,864 @@ func NewPackageHandler(service services.PackageService) *PackageHandler {
 	}
 }
 
-// AddPack handles POST requests to add a new pack size.
-// It expects a form value "size" with the pack size to add.
-// Returns HTTP 409 if the pack size already exists.
+// AddPack handles requests to add a new pack size.
+// It expects a value "size" with the pack size to add, and optionally
+// "effectiveFrom" and "effectiveUntil" dates limiting when the size is available,
+// given as a JSON object, form or query values.
+// Responds with the pack sizes in the format the client accepts, HTML by default.
+// Returns HTTP 400 with the invalid fields, HTTP 409 if the pack size already exists
+// and HTTP 422 if the sizes would need too large a table to calculate with.
 // Triggers "packSizesChanged" event on success.
 func (ph *PackageHandler) AddPack(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	p := readParams(r)
+	size := p.PositiveInt("size")
+	schedule := repositories.Schedule{
+		EffectiveFrom:  p.Date("effectiveFrom"),
+		EffectiveUntil: p.Date("effectiveUntil"),
+	}
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, formatHTML, err)
 		return
 	}
 
-	sizeStr := r.FormValue("size")
-	size, err := strconv.Atoi(sizeStr)
-	if err != nil {
-		http.Error(w, "Invalid pack size", http.StatusBadRequest)
+	var err error
+	if schedule.IsZero() {
+		err = ph.service.AddPack(size)
+	} else {
+		err = ph.service.AddScheduledPack(size, schedule)
+	}
+
+	switch err {
+	case nil:
+	case repositories.ErrSizeAlreadyExists:
+		writeError(w, r, formatHTML, http.StatusConflict, "Pack size already exists", FieldError{Field: "size", Message: "already exists"})
+		return
+	case services.ErrInvalidSchedule:
+		writeError(w, r, formatHTML, http.StatusBadRequest, "The pack size must become available before it is withdrawn",
+			FieldError{Field: "effectiveUntil", Message: "must be after effectiveFrom"})
+		return
+	case services.ErrTableTooLarge:
+		writeError(w, r, formatHTML, http.StatusUnprocessableEntity, "The pack sizes would be too large to calculate with",
+			FieldError{Field: "size", Message: "is too large or too close to the other sizes"})
+		return
+	default:
+		writeError(w, r, formatHTML, http.StatusInternalServerError, "An error occurred while adding the pack size")
 		return
 	}
 
-	err = ph.service.AddPack(size)
+	w.Header().Set("HX-Trigger", "packSizesChanged")
+	writeSizes(w, r, formatHTML, ph.service.GetPackSizes())
+}
 
-	if err != nil {
-		var statusCode int
-		var errorMessage string
+// Calculate handles POST requests to calculate packs for an order.
+// It expects a value "order" with the order size, given as a JSON object, form or query values.
+// Responds in the format the client accepts: an HTML result table for htmx and browser requests,
+// the calculated packs as JSON by default, one row per pack size as CSV, or a summary as plain text.
+// With "explain=true" it also returns the runner-up combinations and the rule that ranked them.
+// With "maxItemsPerShipment" or "maxPacksPerShipment" it returns the full result split into shipments.
+// With "mode=exact" only combinations adding up to exactly the order are returned.
+// With "shipDate" the pack sizes available on that date are used.
+// With a destination "zone" and the "itemWeight", and optionally the "packWeight" and "packCost",
+// it returns the full result with a shipping quote per carrier; "objective=landedCost" then picks
+// the packs with the lowest packaging and shipping cost instead of the fewest items.
+// With "levels=true" JSON clients get the full result, with the packs by level when the catalogue has
+// packaging levels, instead of the packs alone.
+// Returns HTTP 400 with the invalid fields and HTTP 422 if the order cannot be calculated.
+// The catalogue version used is sent in the "X-Catalogue-Version" header.
+func (ph *PackageHandler) Calculate(w http.ResponseWriter, r *http.Request) {
+	p := readParams(r)
+	order := p.PositiveInt("order")
+	shipDate := p.Date("shipDate")
+	explain := p.Bool("explain")
+	levels := p.Bool("levels")
+	limits := shipmentLimits(p)
+	mode := p.String("mode")
+	if mode != "" && mode != "nearest" && mode != "exact" {
+		p.fail("mode", `must be "nearest" or "exact"`)
+	}
+	shipping := shippingOptions(p)
+	if !shipDate.IsZero() && (mode == "exact" || explain || limits != (services.ShipmentLimits{}) || shipping != nil) {
+		p.fail("shipDate", "can only be used for plain calculations")
+	}
+	if shipping != nil && (mode == "exact" || explain || limits != (services.ShipmentLimits{})) {
+		p.fail("zone", "cannot be combined with explain, shipment limits or exact mode")
+	}
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, formatJSON, err)
+		return
+	}
 
-		switch err {
-		case repositories.ErrSizeAlreadyExists:
-			statusCode = http.StatusConflict
-			errorMessage = "Pack size already exists"
+	if mode == "exact" {
+		ph.calculateExact(w, r, order)
+		return
+	}
+
+	if explain {
+		explanation, err := ph.service.ExplainPacks(order)
+		if err != nil {
+			writeError(w, r, formatJSON, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		setCatalogueVersion(w, explanation.Chosen.CatalogueVersion)
+		switch negotiate(r, formatJSON) {
+		case formatHTML:
+			templ.Handler(web.ExplainedResultView(explanation)).ServeHTTP(w, r)
+		case formatJSON:
+			writeJSON(w, explanation)
 		default:
-			statusCode = http.StatusInternalServerError
-			errorMessage = "An error occurred while adding the pack size"
+			writeResult(w, r, explanation.Chosen, explanation.Chosen)
 		}
+		return
+	}
 
-		w.WriteHeader(statusCode)
-		w.Header().Set("HX-Trigger", "errorMessage")
-		templ.Handler(web.ErrorMessage(errorMessage)).ServeHTTP(w, r)
+	if shipping != nil {
+		ph.calculateShipping(w, r, order, *shipping)
 		return
 	}
 
-	w.Header().Set("HX-Trigger", "packSizesChanged")
-	templ.Handler(web.PackSizesList(ph.service.GetPackSizes())).ServeHTTP(w, r)
+	if limits != (services.ShipmentLimits{}) {
+		result, err := ph.service.CalculateShipments(order, limits)
+		switch {
+		case err == nil:
+		case err == services.ErrShipmentLimitTooSmall:
+			writeError(w, r, formatJSON, http.StatusUnprocessableEntity, "No pack size fits within the shipment limit")
+			return
+		case err == services.ErrInvalidShipmentLimits:
+			writeError(w, r, formatJSON, http.StatusBadRequest, "Invalid shipment limits")
+			return
+		default:
+			writeError(w, r, formatJSON, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		setCatalogueVersion(w, result.CatalogueVersion)
+		writeResult(w, r, result, result)
+		return
+	}
+
+	var result services.CalculationResult
+	var err error
+	if shipDate.IsZero() {
+		result, err = ph.service.Calculate(order)
+	} else {
+		result, err = ph.service.CalculateAt(order, shipDate)
+	}
+	if err != nil {
+		writeError(w, r, formatJSON, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	setCatalogueVersion(w, result.CatalogueVersion)
+	if levels {
+		writeResult(w, r, result, result)
+		return
+	}
+	writeResult(w, r, result, result.Packs)
 }
 
-// Calculate handles POST requests to calculate packs for an order.
-// It expects a form value "order" with the order size.
-// Returns a JSON response with the calculated packs.
-func (ph *PackageHandler) Calculate(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// calculateShipping answers a calculation with shipping quotes.
+func (ph *PackageHandler) calculateShipping(w http.ResponseWriter, r *http.Request, order int, options services.ShippingOptions) {
+	result, err := ph.service.CalculateShipping(order, options)
+	var ruleErr *services.RuleError
+	switch {
+	case err == nil:
+	case err == services.ErrNoRateTables:
+		writeError(w, r, formatJSON, http.StatusServiceUnavailable, "No carrier rate tables are loaded")
+		return
+	case err == services.ErrNoQuote, errors.As(err, &ruleErr):
+		writeError(w, r, formatJSON, http.StatusUnprocessableEntity, err.Error())
+		return
+	default:
+		writeError(w, r, formatJSON, http.StatusBadRequest, err.Error())
 		return
 	}
+	setCatalogueVersion(w, result.CatalogueVersion)
+	writeResult(w, r, result, result)
+}
 
-	orderStr := r.FormValue("order")
-	order, err := strconv.Atoi(orderStr)
+// calculateExact answers a calculation in exact mode. When nothing fits exactly
+// it responds with HTTP 422, as JSON suggesting the nearest quantities.
+func (ph *PackageHandler) calculateExact(w http.ResponseWriter, r *http.Request, order int) {
+	packs, err := ph.service.CalculateExact(order)
 	if err != nil {
-		http.Error(w, "Invalid order size", http.StatusBadRequest)
+		var fit *services.NoExactFitError
+		if errors.As(err, &fit) && negotiate(r, formatJSON) == formatJSON {
+			writeJSONStatus(w, http.StatusUnprocessableEntity, map[string]any{
+				"error": err.Error(),
+				"below": fit.Below,
+				"above": fit.Above,
+			})
+			return
+		}
+		writeError(w, r, formatJSON, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
 
-	result := ph.service.CalculatePacks(order)
+	writeResult(w, r, services.NewCalculationResult(order, packs), packs)
+}
+
+// writeResult writes a calculation in the negotiated format. JSON clients get jsonBody.
+func writeResult(w http.ResponseWriter, r *http.Request, result services.CalculationResult, jsonBody any) {
+	switch negotiate(r, formatJSON) {
+	case formatHTML:
+		templ.Handler(web.CalculationResultView(result)).ServeHTTP(w, r)
+	case formatCSV:
+		writeCSV(w, http.StatusOK, resultRecords(result))
+	case formatText:
+		writeText(w, http.StatusOK, resultText(result))
+	default:
+		writeJSON(w, jsonBody)
+	}
+}
+
+// orderRequest is the JSON body accepted by CalculateOrder.
+type orderRequest struct {
+	Lines []services.OrderLine `json:"lines"`
+}
+
+// CalculateOrder handles POST requests to calculate packs for a multi-line order.
+// It expects a JSON body with a "lines" array, each line giving a SKU, a quantity
+// and optionally its own pack sizes and pack costs.
+// Returns a JSON response with the per-line results and the consolidated totals.
+// Returns HTTP 400 with the invalid fields, e.g. "lines[2].quantity", HTTP 413 if the body is
+// larger than 1 MiB and HTTP 422 if the pack sizes of a line are too large to calculate with or
+// its pack rules cannot be met.
+func (ph *PackageHandler) CalculateOrder(w http.ResponseWriter, r *http.Request) {
+	var req orderRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	result, err := ph.service.CalculateOrder(req.Lines)
+	var lineErr *services.LineError
+	switch {
+	case err == nil:
+	case errors.As(err, &lineErr):
+		writeError(w, r, formatJSON, lineErrorStatus(lineErr), "Invalid order line", lineFieldError(lineErr))
+		return
+	case errors.Is(err, services.ErrNoOrderLines):
+		writeError(w, r, formatJSON, http.StatusBadRequest, "Invalid order", FieldError{Field: "lines", Message: "must not be empty"})
+		return
+	case errors.Is(err, services.ErrTooManyOrderLines):
+		writeError(w, r, formatJSON, http.StatusBadRequest, "Invalid order",
+			FieldError{Field: "lines", Message: fmt.Sprintf("must have at most %d lines", services.MaxOrderLines)})
+		return
+	default:
+		writeError(w, r, formatJSON, http.StatusInternalServerError, "An error occurred while calculating the order")
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// lineErrorStatus is HTTP 422 for an order line that is valid but cannot be calculated,
+// and HTTP 400 otherwise.
+func lineErrorStatus(err *services.LineError) int {
+	var ruleErr *services.RuleError
+	if errors.As(err, &ruleErr) || errors.Is(err, services.ErrTableTooLarge) || errors.Is(err, services.ErrOrderTooLarge) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+// lineFieldError reports the field of an order line that failed, e.g. "lines[2].quantity".
+func lineFieldError(err *services.LineError) FieldError {
+	line := fmt.Sprintf("lines[%d]", err.Line-1)
+	var ruleErr *services.RuleError
+	switch {
+	case errors.Is(err, services.ErrInvalidQuantity):
+		return FieldError{Field: line + ".quantity", Message: "must be positive"}
+	case errors.Is(err, services.ErrInvalidPackSize):
+		return FieldError{Field: line + ".sizes", Message: "must all be positive"}
+	case errors.Is(err, services.ErrNoPackSizes):
+		return FieldError{Field: line + ".sizes", Message: "must be given while the catalogue is empty"}
+	case errors.Is(err, services.ErrTooManyPackSizes):
+		return FieldError{Field: line + ".sizes", Message: fmt.Sprintf("must have at most %d sizes", services.MaxPackSizes)}
+	case errors.Is(err, services.ErrTableTooLarge):
+		return FieldError{Field: line + ".sizes", Message: "are too large or too close to each other"}
+	case errors.Is(err, services.ErrOrderTooLarge):
+		return FieldError{Field: line + ".quantity", Message: "is too large for the pack sizes"}
+	case errors.As(err, &ruleErr):
+		return FieldError{Field: line, Message: "cannot meet the pack rules: " + strings.Join(ruleErr.Reasons, "; ")}
+	default:
+		return FieldError{Field: line, Message: err.Err.Error()}
+	}
+}
+
+// whatIfRequest is the JSON body accepted by WhatIf.
+type whatIfRequest struct {
+	Sizes  []int           `json:"sizes"`
+	Orders []int           `json:"orders"`
+	Costs  map[int]float64 `json:"costs"`
+}
 
-	jsonResult, err := json.Marshal(result)
+// WhatIf handles POST requests to compare the current catalogue with a proposed one.
+// It expects a JSON body with the proposed "sizes", a sample of historical "orders"
+// and optionally the "costs" of one pack by size.
+// Returns a JSON response with the metrics of both catalogues. The catalogue is not changed.
+// Returns HTTP 400 with the invalid fields, HTTP 413 if the body is larger than 1 MiB
+// and HTTP 422 if the proposed sizes are too large to calculate with.
+func (ph *PackageHandler) WhatIf(w http.ResponseWriter, r *http.Request) {
+	var req whatIfRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	result, err := ph.service.CompareCatalogue(req.Sizes, req.Orders, req.Costs)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrTableTooLarge):
+		writeError(w, r, formatJSON, http.StatusUnprocessableEntity, "The proposed pack sizes are too large to calculate with",
+			FieldError{Field: "sizes", Message: "are too large or too close to each other"})
+		return
+	default:
+		if field, ok := whatIfFieldError(err); ok {
+			writeValidationError(w, r, formatJSON, &ValidationError{Fields: []FieldError{field}})
+			return
+		}
+		writeError(w, r, formatJSON, http.StatusInternalServerError, "An error occurred while comparing the catalogues")
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// Optimize handles POST requests to recommend the best catalogues of K pack sizes.
+// It expects a JSON body with a sample of historical "orders", the number of sizes "k",
+// and optionally the "candidates" to choose from, the "objective" ("excess" or "packs")
+// and the number of "recommendations".
+// Returns a JSON response with the ranked catalogues and their metrics. The catalogue is not changed.
+// Returns HTTP 400 with the invalid fields and HTTP 413 if the body is larger than 1 MiB.
+func (ph *PackageHandler) Optimize(w http.ResponseWriter, r *http.Request) {
+	var req services.OptimizeRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	recommendations, err := ph.service.OptimizeCatalogue(req)
 	if err != nil {
-		http.Error(w, "Error encoding result", http.StatusInternalServerError)
+		if field, ok := optimizeFieldError(err); ok {
+			writeValidationError(w, r, formatJSON, &ValidationError{Fields: []FieldError{field}})
+			return
+		}
+		writeError(w, r, formatJSON, http.StatusInternalServerError, "An error occurred while optimizing the catalogue")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonResult)
+	writeJSON(w, recommendations)
+}
+
+// sampleOrdersFieldError reports an invalid sample of orders given to an analysis.
+func sampleOrdersFieldError(err error) (FieldError, bool) {
+	switch {
+	case errors.Is(err, services.ErrNoOrders):
+		return FieldError{Field: "orders", Message: "must not be empty"}, true
+	case errors.Is(err, services.ErrTooManyOrders):
+		return FieldError{Field: "orders", Message: fmt.Sprintf("must have at most %d orders", services.MaxSampleOrders)}, true
+	case errors.Is(err, services.ErrInvalidQuantity):
+		return FieldError{Field: "orders", Message: "must all be positive"}, true
+	default:
+		return FieldError{}, false
+	}
+}
+
+// whatIfFieldError reports the field of a what-if comparison that is invalid.
+func whatIfFieldError(err error) (FieldError, bool) {
+	switch {
+	case errors.Is(err, services.ErrNoPackSizes):
+		return FieldError{Field: "sizes", Message: "must not be empty"}, true
+	case errors.Is(err, services.ErrInvalidPackSize):
+		return FieldError{Field: "sizes", Message: "must all be positive"}, true
+	case errors.Is(err, services.ErrTooManyPackSizes):
+		return FieldError{Field: "sizes", Message: fmt.Sprintf("must have at most %d sizes", services.MaxPackSizes)}, true
+	default:
+		return sampleOrdersFieldError(err)
+	}
+}
+
+// optimizeFieldError reports the field of an optimization request that is invalid.
+func optimizeFieldError(err error) (FieldError, bool) {
+	switch {
+	case errors.Is(err, services.ErrInvalidObjective):
+		return FieldError{Field: "objective", Message: `must be "excess" or "packs"`}, true
+	case errors.Is(err, services.ErrTooManyCandidates):
+		return FieldError{Field: "candidates", Message: fmt.Sprintf("must have at most %d sizes", services.MaxCandidates)}, true
+	case errors.Is(err, services.ErrInvalidPackSize):
+		return FieldError{Field: "candidates", Message: "must all be positive"}, true
+	case errors.Is(err, services.ErrNoPackSizes):
+		return FieldError{Field: "candidates", Message: "must be given while the catalogue is empty"}, true
+	case errors.Is(err, services.ErrInvalidCatalogueSize):
+		return FieldError{Field: "k", Message: "must be between 1 and the number of candidates"}, true
+	case errors.Is(err, services.ErrTooManyPackSizes):
+		return FieldError{Field: "k", Message: fmt.Sprintf("must be at most %d", services.MaxPackSizes)}, true
+	default:
+		return sampleOrdersFieldError(err)
+	}
+}
+
+// LoadContainers handles POST requests to load packs into carrier boxes or pallets by volume and weight.
+// It expects a JSON body with the "dimensions" of one pack by size, the "containers" to use in order
+// of preference, and either the "packs" to load or the "order" to calculate them for.
+// Returns a JSON response with the packs in each container and the utilisation of the containers.
+// Returns HTTP 400 for invalid dimensions or containers or more than MaxContainerPacks packs,
+// HTTP 413 for a body over 1 MiB and HTTP 422 if a pack fits in no container or the order cannot
+// be calculated.
+func (ph *PackageHandler) LoadContainers(w http.ResponseWriter, r *http.Request) {
+	var req services.ContainerRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	result, err := ph.service.LoadContainers(req)
+	var ruleErr *services.RuleError
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrTooManyPacks):
+		writeValidationError(w, r, formatJSON, &ValidationError{Fields: []FieldError{
+			{Field: "packs", Message: fmt.Sprintf("must add up to at most %d packs", services.MaxContainerPacks)}}})
+		return
+	case errors.Is(err, services.ErrPackTooLarge), errors.As(err, &ruleErr):
+		writeError(w, r, formatJSON, http.StatusUnprocessableEntity, err.Error())
+		return
+	default:
+		writeError(w, r, formatJSON, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	setCatalogueVersion(w, result.CatalogueVersion)
+	writeJSON(w, result)
+}
+
+// packingSlipRequest is the JSON body accepted by PackingSlip.
+type packingSlipRequest struct {
+	OrderID string                      `json:"orderId"`
+	Order   int                         `json:"order"`
+	Result  *services.CalculationResult `json:"result"`
 }
 
-// ClearPacks handles POST requests to clear all pack sizes.
+// PackingSlip handles requests for the packing slip of an order: a printable pick list
+// of its packs with the totals and a barcode of the order ID.
+// It expects an "orderId" and either the "order" size, whose packs are calculated, or a
+// calculation "result" to print. They are read from a JSON object, or from form or query
+// values with the result encoded as JSON.
+// Responds with a PDF when the client asks for "format=pdf" or accepts "application/pdf",
+// and with an HTML page otherwise.
+// Returns HTTP 400 with the invalid fields and HTTP 422 if the order cannot be calculated.
+func (ph *PackageHandler) PackingSlip(w http.ResponseWriter, r *http.Request) {
+	var req packingSlipRequest
+	var p *params
+	fallback := formatHTML
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		fallback = formatJSON
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxParamsBody)).Decode(&req); err != nil {
+			writeError(w, r, fallback, http.StatusBadRequest, "Invalid request")
+			return
+		}
+		req.OrderID = strings.TrimSpace(req.OrderID)
+		p = &params{values: r.URL.Query()}
+	} else {
+		p = readParams(r)
+		req.OrderID = p.String("orderId")
+		req.Order = p.NonNegativeInt("order")
+		if value := p.String("result"); value != "" {
+			req.Result = &services.CalculationResult{}
+			if err := json.Unmarshal([]byte(value), req.Result); err != nil {
+				p.fail("result", "must be a calculation result as JSON")
+			}
+		}
+	}
+
+	if slips.CheckOrderID(req.OrderID) != nil {
+		p.fail("orderId", fmt.Sprintf("must be 1 to %d printable ASCII characters", slips.MaxOrderIDLength))
+	}
+	switch {
+	case req.Order < 0:
+		p.fail("order", "must not be negative")
+	case req.Order > 0 && req.Result != nil:
+		p.fail("order", "cannot be combined with result")
+	case req.Order == 0 && req.Result == nil:
+		p.fail("order", "is required without a result")
+	}
+	pdf := false
+	switch p.String("format") {
+	case "pdf":
+		pdf = true
+	case "", "html":
+		pdf = negotiate(r, formatHTML) == formatPDF
+	default:
+		p.fail("format", `must be "html" or "pdf"`)
+	}
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, fallback, err)
+		return
+	}
+
+	var result services.CalculationResult
+	if req.Result != nil {
+		result = *req.Result
+	} else {
+		var err error
+		if result, err = ph.service.Calculate(req.Order); err != nil {
+			writeError(w, r, fallback, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+	}
+	slip, err := slips.New(req.OrderID, result)
+	if err != nil {
+		writeError(w, r, fallback, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !pdf {
+		setCatalogueVersion(w, result.CatalogueVersion)
+		templ.Handler(web.PackingSlipPage(slip)).ServeHTTP(w, r)
+		return
+	}
+	// The PDF is rendered before any of it is sent, so a failure can still be reported
+	var pdfBody bytes.Buffer
+	if err := slip.WritePDF(&pdfBody); err != nil {
+		writeError(w, r, fallback, http.StatusInternalServerError, "An error occurred while printing the packing slip")
+		return
+	}
+	setCatalogueVersion(w, result.CatalogueVersion)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", slip.FileName()))
+	w.Header().Set("Content-Length", strconv.Itoa(pdfBody.Len()))
+	pdfBody.WriteTo(w)
+}
+
+// RemovePack handles requests to remove a pack size.
+// It expects the pack size to remove as the path value "size", or as a value "size"
+// given as a JSON object, form or query values.
+// With an "effectiveFrom" date the size stays available until that date instead of being removed now.
+// Responds with the pack sizes in the format the client accepts, HTML by default.
+// Returns HTTP 400 with the invalid fields, HTTP 404 if the pack size does not exist
+// and HTTP 409 if it is a packaging level contained in another level.
 // Triggers "packSizesChanged" event on success.
-func (ph *PackageHandler) ClearPacks(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+func (ph *PackageHandler) RemovePack(w http.ResponseWriter, r *http.Request) {
+	p := readParams(r, "size")
+	size := p.PositiveInt("size")
+	effectiveFrom := p.Date("effectiveFrom")
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, formatHTML, err)
+		return
+	}
+
+	var err error
+	if effectiveFrom.IsZero() {
+		err = ph.service.RemovePack(size)
+	} else {
+		err = ph.service.SchedulePackRemoval(size, effectiveFrom)
+	}
+
+	switch err {
+	case nil:
+	case repositories.ErrSizeNotFound:
+		writeError(w, r, formatHTML, http.StatusNotFound, "Pack size not found", FieldError{Field: "size", Message: "does not exist"})
+		return
+	case repositories.ErrLevelInUse:
+		writeError(w, r, formatHTML, http.StatusConflict, "The pack size is a packaging level contained in another level",
+			FieldError{Field: "size", Message: "is contained in another packaging level"})
+		return
+	case services.ErrInvalidSchedule:
+		writeError(w, r, formatHTML, http.StatusBadRequest, "The pack size cannot be withdrawn before it becomes available",
+			FieldError{Field: "effectiveFrom", Message: "must be after the size becomes available"})
+		return
+	default:
+		writeError(w, r, formatHTML, http.StatusInternalServerError, "An error occurred while removing the pack size")
 		return
 	}
 
+	w.Header().Set("HX-Trigger", "packSizesChanged")
+	writeSizes(w, r, formatHTML, ph.service.GetPackSizes())
+}
+
+// ClearPacks handles requests to clear all pack sizes.
+// Responds with the now empty pack sizes in the format the client accepts, HTML by default.
+// Triggers "packSizesChanged" event on success.
+func (ph *PackageHandler) ClearPacks(w http.ResponseWriter, r *http.Request) {
 	ph.service.ClearPacks()
 
 	w.Header().Set("HX-Trigger", "packSizesChanged")
-	templ.Handler(web.PackSizesList(ph.service.GetPackSizes())).ServeHTTP(w, r)
+	writeSizes(w, r, formatHTML, ph.service.GetPackSizes())
 }
 
-// PackSizes handles requests to retrieve all pack sizes.
-// Returns an HTML component with the list of pack sizes.
+// PackSizes handles requests to retrieve the pack sizes available now,
+// or at the date given by the value "at".
+// Responds in the format the client accepts: a JSON array, CSV or plain text with one
+// size per line, and otherwise an HTML component with the list of pack sizes.
 func (ph *PackageHandler) PackSizes(w http.ResponseWriter, r *http.Request) {
-	templ.Handler(web.PackSizesList(ph.service.GetPackSizes())).ServeHTTP(w, r)
+	p := readParams(r)
+	at := p.Date("at")
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, formatHTML, err)
+		return
+	}
+
+	var sizes []int
+	if at.IsZero() {
+		sizes = ph.service.GetPackSizes()
+	} else {
+		sizes = ph.service.GetPackSizesAt(at)
+	}
+	writeSizes(w, r, formatHTML, sizes)
+}
+
+// writeSizes writes pack sizes in the negotiated format.
+func writeSizes(w http.ResponseWriter, r *http.Request, fallback format, sizes []int) {
+	switch negotiate(r, fallback) {
+	case formatJSON:
+		writeJSON(w, sizes)
+	case formatCSV:
+		writeCSV(w, http.StatusOK, sizeRecords(sizes))
+	case formatText:
+		writeText(w, http.StatusOK, sizesText(sizes))
+	default:
+		templ.Handler(web.PackSizesList(sizes)).ServeHTTP(w, r)
+	}
+}
+
+// PackSchedules handles GET requests for the availability windows of scheduled pack sizes.
+// Returns an HTML list for htmx and browser requests, and a JSON object keyed by size for API clients.
+func (ph *PackageHandler) PackSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules := ph.service.GetPackSchedules()
+	if prefersHTML(r) {
+		templ.Handler(web.ScheduledSizes(schedules)).ServeHTTP(w, r)
+		return
+	}
+	writeJSON(w, schedules)
+}
+
+// PackRules handles GET requests for the per-size usage rules and returns them as JSON.
+func (ph *PackageHandler) PackRules(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, ph.service.GetPackRules())
+}
+
+// SetPackRule handles POST requests to set the usage rule of one pack size from the values
+// "size", "minCount", "maxCount" and "disabled", given as a JSON object, form or query values,
+// and returns the updated rules as JSON.
+// Returns HTTP 400 with the invalid fields and HTTP 404 if the pack size does not exist.
+// Triggers "packSizesChanged" event on success.
+func (ph *PackageHandler) SetPackRule(w http.ResponseWriter, r *http.Request) {
+	p := readParams(r)
+	size := p.PositiveInt("size")
+	rule := repositories.PackRule{
+		MinCount: p.NonNegativeInt("minCount"),
+		MaxCount: p.NonNegativeInt("maxCount"),
+		Disabled: p.Bool("disabled"),
+	}
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, formatJSON, err)
+		return
+	}
+
+	switch err := ph.service.SetPackRule(size, rule); err {
+	case nil:
+	case repositories.ErrSizeNotFound:
+		writeError(w, r, formatJSON, http.StatusNotFound, "Pack size not found", FieldError{Field: "size", Message: "does not exist"})
+		return
+	case services.ErrInvalidPackRule:
+		writeError(w, r, formatJSON, http.StatusBadRequest, "Invalid pack rule", FieldError{Field: "maxCount", Message: "must not be below minCount"})
+		return
+	default:
+		writeError(w, r, formatJSON, http.StatusInternalServerError, "An error occurred while setting the pack rule")
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "packSizesChanged")
+	writeJSON(w, ph.service.GetPackRules())
+}
+
+// PackagingLevels handles GET requests for the packaging levels and returns them as JSON, largest first.
+func (ph *PackageHandler) PackagingLevels(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, ph.service.GetPackagingLevels())
+}
+
+// AddPackagingLevel handles POST requests to define a packaging level from the values "name",
+// "quantity" and "unit", the level it contains or "item" by default, given as a JSON object,
+// form or query values. The level's pack size is added to the catalogue if it is missing.
+// Returns the updated levels as JSON.
+// Returns HTTP 400 with the invalid fields, HTTP 404 if the unit does not exist,
+// HTTP 409 if the name or pack size already belongs to a level and HTTP 422 if the
+// sizes would need too large a table to calculate with.
+// Triggers "packSizesChanged" event on success.
+func (ph *PackageHandler) AddPackagingLevel(w http.ResponseWriter, r *http.Request) {
+	p := readParams(r)
+	name := p.String("name")
+	if name == "" {
+		p.fail("name", "is required")
+	}
+	quantity := p.PositiveInt("quantity")
+	if quantity == 1 {
+		p.fail("quantity", "must be at least 2")
+	}
+	unit := p.String("unit")
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, formatJSON, err)
+		return
+	}
+
+	switch err := ph.service.AddPackagingLevel(name, quantity, unit); err {
+	case nil:
+	case repositories.ErrLevelExists:
+		writeError(w, r, formatJSON, http.StatusConflict, "Packaging level already exists", FieldError{Field: "name", Message: "already exists"})
+		return
+	case repositories.ErrLevelNotFound:
+		writeError(w, r, formatJSON, http.StatusNotFound, "Unit not found", FieldError{Field: "unit", Message: "does not exist"})
+		return
+	case services.ErrInvalidLevel:
+		writeError(w, r, formatJSON, http.StatusBadRequest, "Invalid packaging level")
+		return
+	case services.ErrTableTooLarge:
+		writeError(w, r, formatJSON, http.StatusUnprocessableEntity, "The pack sizes would be too large to calculate with",
+			FieldError{Field: "quantity", Message: "is too large or too close to the other sizes"})
+		return
+	default:
+		writeError(w, r, formatJSON, http.StatusInternalServerError, "An error occurred while adding the packaging level")
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "packSizesChanged")
+	writeJSON(w, ph.service.GetPackagingLevels())
+}
+
+// RemovePackagingLevel handles DELETE requests to remove a packaging level and its pack size.
+// The level is named by the "name" path value or value.
+// Returns the remaining levels as JSON.
+// Returns HTTP 404 if the level does not exist and HTTP 409 if another level contains it.
+// Triggers "packSizesChanged" event on success.
+func (ph *PackageHandler) RemovePackagingLevel(w http.ResponseWriter, r *http.Request) {
+	p := readParams(r, "name")
+	name := p.String("name")
+	if name == "" {
+		p.fail("name", "is required")
+	}
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, formatJSON, err)
+		return
+	}
+
+	switch err := ph.service.RemovePackagingLevel(name); err {
+	case nil:
+	case repositories.ErrLevelNotFound:
+		writeError(w, r, formatJSON, http.StatusNotFound, "Packaging level not found", FieldError{Field: "name", Message: "does not exist"})
+		return
+	case repositories.ErrLevelInUse:
+		writeError(w, r, formatJSON, http.StatusConflict, "The packaging level is contained in another level",
+			FieldError{Field: "name", Message: "is contained in another packaging level"})
+		return
+	default:
+		writeError(w, r, formatJSON, http.StatusInternalServerError, "An error occurred while removing the packaging level")
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "packSizesChanged")
+	writeJSON(w, ph.service.GetPackagingLevels())
+}
+
+// Versions handles GET requests for the catalogue history.
+// Returns an HTML table for htmx and browser requests, and a JSON list of versions, oldest first, for API clients.
+func (ph *PackageHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	versions := ph.service.ListVersions()
+	if prefersHTML(r) {
+		templ.Handler(web.VersionsList(versions)).ServeHTTP(w, r)
+		return
+	}
+	writeJSON(w, versions)
+}
+
+// VersionDiff handles GET requests comparing two catalogue versions.
+// It expects values "from" and "to" with the version IDs.
+// Returns HTTP 400 with the invalid fields and HTTP 404 if either version does not exist.
+func (ph *PackageHandler) VersionDiff(w http.ResponseWriter, r *http.Request) {
+	p := readParams(r)
+	from := p.PositiveInt("from")
+	to := p.PositiveInt("to")
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, formatJSON, err)
+		return
+	}
+
+	diff, err := ph.service.DiffVersions(from, to)
+	if err != nil {
+		writeError(w, r, formatJSON, http.StatusNotFound, "Catalogue version not found")
+		return
+	}
+
+	if prefersHTML(r) {
+		templ.Handler(web.VersionDiffView(diff)).ServeHTTP(w, r)
+		return
+	}
+	writeJSON(w, diff)
+}
+
+// Rollback handles POST requests to restore an earlier catalogue version.
+// It expects a value "version" with the version ID. The rollback is recorded as a new version.
+// Returns HTTP 400 with the invalid fields and HTTP 404 if the version does not exist.
+// Triggers "packSizesChanged" event on success.
+func (ph *PackageHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	p := readParams(r)
+	id := p.PositiveInt("version")
+	if err := p.Err(); err != nil {
+		writeValidationError(w, r, formatJSON, err)
+		return
+	}
+
+	switch err := ph.service.RollbackCatalogue(id); err {
+	case nil:
+	case repositories.ErrVersionNotFound:
+		writeError(w, r, formatJSON, http.StatusNotFound, "Catalogue version not found", FieldError{Field: "version", Message: "does not exist"})
+		return
+	default:
+		writeError(w, r, formatJSON, http.StatusInternalServerError, "An error occurred while rolling back the catalogue")
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "packSizesChanged")
+	versions := ph.service.ListVersions()
+	if prefersHTML(r) {
+		templ.Handler(web.VersionsList(versions)).ServeHTTP(w, r)
+		return
+	}
+	writeJSON(w, versions[len(versions)-1])
+}
+
+// Events handles GET requests for the Server-Sent Events stream.
+// Every catalogue change is pushed to all connected clients until they disconnect.
+func (ph *PackageHandler) Events(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	events, unsubscribe := ph.service.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Name, event.Data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
 }
 
 // CalculatorIndex handles requests for the main calculator page.
@@ -120,3 +901,77 @@ func (ph *PackageHandler) PackSizes(w http.ResponseWriter, r *http.Request) {
 func (ph *PackageHandler) CalculatorIndex(w http.ResponseWriter, r *http.Request) {
 	templ.Handler(web.IndexPage(ph.service.GetPackSizes())).ServeHTTP(w, r)
 }
+
+// writeJSON encodes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, v any) {
+	writeJSONStatus(w, http.StatusOK, v)
+}
+
+// writeJSONStatus encodes v as the JSON response body with the given status code.
+func writeJSONStatus(w http.ResponseWriter, statusCode int, v any) {
+	jsonResult, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "Error encoding result", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(jsonResult)
+}
+
+// parseDate reads an optional date given as "2006-01-02" (midnight UTC) or in RFC 3339 format.
+// An empty value gives the zero time.
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// setCatalogueVersion reports the catalogue version a calculation used.
+func setCatalogueVersion(w http.ResponseWriter, version int) {
+	if version > 0 {
+		w.Header().Set("X-Catalogue-Version", strconv.Itoa(version))
+	}
+}
+
+// shippingOptions reads the optional destination zone and weights, or returns nil when
+// no shipping costs are asked for.
+func shippingOptions(p *params) *services.ShippingOptions {
+	options := services.ShippingOptions{
+		Zone:       p.String("zone"),
+		ItemWeight: p.NonNegativeFloat("itemWeight"),
+		PackWeight: p.NonNegativeFloat("packWeight"),
+		PackCost:   p.NonNegativeFloat("packCost"),
+	}
+	switch objective := p.String("objective"); objective {
+	case "", "items":
+	case "landedCost":
+		options.MinimiseLandedCost = true
+	default:
+		p.fail("objective", `must be "items" or "landedCost"`)
+	}
+
+	if options == (services.ShippingOptions{}) {
+		return nil
+	}
+	if options.Zone == "" {
+		p.fail("zone", "is required for shipping costs")
+	}
+	if options.ItemWeight == 0 {
+		p.fail("itemWeight", "must be positive for shipping costs")
+	}
+	return &options
+}
+
+// shipmentLimits reads the optional per-shipment limits.
+func shipmentLimits(p *params) services.ShipmentLimits {
+	return services.ShipmentLimits{
+		MaxItems: p.NonNegativeInt("maxItemsPerShipment"),
+		MaxPacks: p.NonNegativeInt("maxPacksPerShipment"),
+	}
+}
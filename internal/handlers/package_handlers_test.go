@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"Ship_Manager/internal/events"
+	"Ship_Manager/internal/rates"
+	"Ship_Manager/internal/repositories"
+	"Ship_Manager/internal/services"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -22,6 +29,11 @@ func (m *MockPackageService) AddPack(size int) error {
 	return args.Error(0)
 }
 
+func (m *MockPackageService) RemovePack(size int) error {
+	args := m.Called(size)
+	return args.Error(0)
+}
+
 func (m *MockPackageService) ClearPacks() {
 	m.Called()
 }
@@ -31,9 +43,140 @@ func (m *MockPackageService) GetPackSizes() []int {
 	return args.Get(0).([]int)
 }
 
-func (m *MockPackageService) CalculatePacks(order int) map[int]int {
+func (m *MockPackageService) CalculatePacks(order int) (map[int]int, error) {
+	args := m.Called(order)
+	return args.Get(0).(map[int]int), args.Error(1)
+}
+
+func (m *MockPackageService) ExplainPacks(order int) (services.Explanation, error) {
+	args := m.Called(order)
+	return args.Get(0).(services.Explanation), args.Error(1)
+}
+
+func (m *MockPackageService) Subscribe() (<-chan events.Event, func()) {
+	args := m.Called()
+	return args.Get(0).(<-chan events.Event), args.Get(1).(func())
+}
+
+func (m *MockPackageService) HandleEvents(handle func(events.Event)) func() {
+	args := m.Called(handle)
+	return args.Get(0).(func())
+}
+
+func (m *MockPackageService) Close() {
+	m.Called()
+}
+
+func (m *MockPackageService) CalculateShipments(order int, limits services.ShipmentLimits) (services.CalculationResult, error) {
+	args := m.Called(order, limits)
+	return args.Get(0).(services.CalculationResult), args.Error(1)
+}
+
+func (m *MockPackageService) CalculateOrder(lines []services.OrderLine) (services.OrderResult, error) {
+	args := m.Called(lines)
+	return args.Get(0).(services.OrderResult), args.Error(1)
+}
+
+func (m *MockPackageService) SetPackRule(size int, rule repositories.PackRule) error {
+	args := m.Called(size, rule)
+	return args.Error(0)
+}
+
+func (m *MockPackageService) GetPackRules() map[int]repositories.PackRule {
+	args := m.Called()
+	return args.Get(0).(map[int]repositories.PackRule)
+}
+
+func (m *MockPackageService) AddPackagingLevel(name string, quantity int, unit string) error {
+	args := m.Called(name, quantity, unit)
+	return args.Error(0)
+}
+
+func (m *MockPackageService) RemovePackagingLevel(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *MockPackageService) GetPackagingLevels() []repositories.PackagingLevel {
+	args := m.Called()
+	return args.Get(0).([]repositories.PackagingLevel)
+}
+
+func (m *MockPackageService) LoadContainers(req services.ContainerRequest) (services.ContainerResult, error) {
+	args := m.Called(req)
+	return args.Get(0).(services.ContainerResult), args.Error(1)
+}
+
+func (m *MockPackageService) CalculateShipping(order int, options services.ShippingOptions) (services.CalculationResult, error) {
+	args := m.Called(order, options)
+	return args.Get(0).(services.CalculationResult), args.Error(1)
+}
+
+func (m *MockPackageService) CheckRules(order int) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockPackageService) CalculateExact(order int) (map[int]int, error) {
+	args := m.Called(order)
+	packs, _ := args.Get(0).(map[int]int)
+	return packs, args.Error(1)
+}
+
+func (m *MockPackageService) CompareCatalogue(proposed []int, orders []int, costs map[int]float64) (services.WhatIfResult, error) {
+	args := m.Called(proposed, orders, costs)
+	return args.Get(0).(services.WhatIfResult), args.Error(1)
+}
+
+func (m *MockPackageService) Calculate(order int) (services.CalculationResult, error) {
 	args := m.Called(order)
-	return args.Get(0).(map[int]int)
+	return args.Get(0).(services.CalculationResult), args.Error(1)
+}
+
+func (m *MockPackageService) GetPackSizesAt(at time.Time) []int {
+	args := m.Called(at)
+	return args.Get(0).([]int)
+}
+
+func (m *MockPackageService) AddScheduledPack(size int, schedule repositories.Schedule) error {
+	args := m.Called(size, schedule)
+	return args.Error(0)
+}
+
+func (m *MockPackageService) SchedulePackRemoval(size int, at time.Time) error {
+	args := m.Called(size, at)
+	return args.Error(0)
+}
+
+func (m *MockPackageService) GetPackSchedules() map[int]repositories.Schedule {
+	args := m.Called()
+	return args.Get(0).(map[int]repositories.Schedule)
+}
+
+func (m *MockPackageService) CalculateAt(order int, shipDate time.Time) (services.CalculationResult, error) {
+	args := m.Called(order, shipDate)
+	return args.Get(0).(services.CalculationResult), args.Error(1)
+}
+
+func (m *MockPackageService) ListVersions() []repositories.CatalogueVersion {
+	args := m.Called()
+	return args.Get(0).([]repositories.CatalogueVersion)
+}
+
+func (m *MockPackageService) DiffVersions(from, to int) (services.VersionDiff, error) {
+	args := m.Called(from, to)
+	return args.Get(0).(services.VersionDiff), args.Error(1)
+}
+
+func (m *MockPackageService) RollbackCatalogue(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockPackageService) OptimizeCatalogue(req services.OptimizeRequest) ([]services.Recommendation, error) {
+	args := m.Called(req)
+	recommendations, _ := args.Get(0).([]services.Recommendation)
+	return recommendations, args.Error(1)
 }
 
 func TestAddPack(t *testing.T) {
@@ -69,12 +212,65 @@ func TestAddPack(t *testing.T) {
 	})
 }
 
+func TestRemovePack(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	t.Run("Successful remove", func(t *testing.T) {
+		mockService.On("RemovePack", 100).Return(nil).Once()
+		mockService.On("GetPackSizes").Return([]int{}).Once()
+
+		form := url.Values{}
+		form.Add("size", "100")
+		req, _ := http.NewRequest("POST", "/remove-pack", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.RemovePack(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("HX-Trigger"), "packSizesChanged")
+	})
+
+	t.Run("Size in the path", func(t *testing.T) {
+		mockService.On("RemovePack", 250).Return(nil).Once()
+		mockService.On("GetPackSizes").Return([]int{500}).Once()
+
+		req, _ := http.NewRequest("DELETE", "/pack-sizes/250", nil)
+		req.SetPathValue("size", "250")
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.RemovePack(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[500]`, rr.Body.String())
+	})
+
+	t.Run("Unknown size", func(t *testing.T) {
+		mockService.On("RemovePack", 300).Return(repositories.ErrSizeNotFound).Once()
+
+		form := url.Values{}
+		form.Add("size", "300")
+		req, _ := http.NewRequest("POST", "/remove-pack", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.RemovePack(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Header().Get("HX-Trigger"), "errorMessage")
+	})
+}
+
 func TestCalculate(t *testing.T) {
 	mockService := new(MockPackageService)
 	handler := NewPackageHandler(mockService)
 
 	t.Run("Successful calculation", func(t *testing.T) {
-		mockService.On("CalculatePacks", 250).Return(map[int]int{250: 1}).Once()
+		result := services.NewCalculationResult(250, map[int]int{250: 1})
+		result.CatalogueVersion = 3
+		mockService.On("Calculate", 250).Return(result, nil).Once()
 
 		form := url.Values{}
 		form.Add("order", "250")
@@ -85,55 +281,1128 @@ func TestCalculate(t *testing.T) {
 		handler.Calculate(rr, req)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "3", rr.Header().Get("X-Catalogue-Version"))
+		var packs map[int]int
+		json.NewDecoder(rr.Body).Decode(&packs)
+		assert.Equal(t, map[int]int{250: 1}, packs)
+	})
+
+	t.Run("Packaging levels", func(t *testing.T) {
+		result := services.NewCalculationResult(500, map[int]int{480: 1, 12: 2})
+		result.Levels = []services.LevelCount{
+			{Level: "pallet", Size: 480, Count: 1, Quantity: 40, Unit: "pack"},
+			{Level: "pack", Size: 12, Count: 2, Quantity: 12, Unit: repositories.ItemUnit},
+		}
+		mockService.On("Calculate", 500).Return(result, nil).Times(3)
+
+		// The packs alone unless the levels are asked for
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(`{"order":500}`))
+		req.Header.Add("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var packs map[int]int
+		json.NewDecoder(rr.Body).Decode(&packs)
+		assert.Equal(t, result.Packs, packs)
+
+		req, _ = http.NewRequest("POST", "/calculate", strings.NewReader(`{"order":500,"levels":true}`))
+		req.Header.Add("Content-Type", "application/json")
+		rr = httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got services.CalculationResult
+		json.NewDecoder(rr.Body).Decode(&got)
+		assert.Equal(t, result, got)
+
+		req, _ = http.NewRequest("POST", "/calculate", strings.NewReader("order=500"))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "text/plain")
+		rr = httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Contains(t, rr.Body.String(), "1 pallet + 2 packs\n")
+	})
+
+	t.Run("Shipping quotes", func(t *testing.T) {
+		result := services.NewCalculationResult(501, map[int]int{1000: 1})
+		result.Shipping = &services.ShippingEstimate{
+			Zone:          "EU",
+			Weight:        500,
+			PackagingCost: 10,
+			Quotes:        []rates.Quote{{Carrier: "Swift", Zone: "EU", MaxWeight: 600, Price: 20}},
+			LandedCost:    30,
+		}
+		options := services.ShippingOptions{Zone: "EU", ItemWeight: 0.5, PackCost: 10, MinimiseLandedCost: true}
+		mockService.On("CalculateShipping", 501, options).Return(result, nil).Twice()
+
+		body := `{"order":501,"zone":"EU","itemWeight":0.5,"packCost":10,"objective":"landedCost"}`
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got services.CalculationResult
+		json.NewDecoder(rr.Body).Decode(&got)
+		assert.Equal(t, result, got)
+
+		req, _ = http.NewRequest("POST", "/calculate", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Set("Accept", "text/plain")
+		rr = httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Contains(t, rr.Body.String(), "Shipping 500 to EU: Swift 20.00\nLanded cost 30.00 (packaging 10.00)\n")
+	})
+
+	t.Run("Invalid shipping options", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(`{"order":501,"itemWeight":-1,"objective":"cheapest","explain":true}`))
+		req.Header.Add("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error":"invalid request","fields":[
+			{"field":"itemWeight","message":"must not be negative"},
+			{"field":"objective","message":"must be \"items\" or \"landedCost\""},
+			{"field":"zone","message":"is required for shipping costs"}]}`, rr.Body.String())
+	})
+
+	t.Run("No rate tables", func(t *testing.T) {
+		options := services.ShippingOptions{Zone: "EU", ItemWeight: 1}
+		mockService.On("CalculateShipping", 501, options).Return(services.CalculationResult{}, services.ErrNoRateTables).Once()
+
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader("order=501&zone=EU&itemWeight=1"))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+
+	t.Run("No version header without a version", func(t *testing.T) {
+		mockService.On("Calculate", 250).Return(services.NewCalculationResult(250, map[int]int{250: 1}), nil).Once()
+
+		form := url.Values{}
+		form.Add("order", "250")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Empty(t, rr.Header().Get("X-Catalogue-Version"))
 		var result map[int]int
 		json.NewDecoder(rr.Body).Decode(&result)
 		assert.Equal(t, map[int]int{250: 1}, result)
 	})
 
-	t.Run("Invalid order size", func(t *testing.T) {
+	t.Run("HTML for htmx requests", func(t *testing.T) {
+		mockService.On("Calculate", 501).Return(services.NewCalculationResult(501, map[int]int{500: 1, 250: 1}), nil).Once()
+
 		form := url.Values{}
-		form.Add("order", "invalid")
+		form.Add("order", "501")
 		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
 		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Add("HX-Request", "true")
 		rr := httptest.NewRecorder()
 
 		handler.Calculate(rr, req)
 
-		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rr.Body.String(), "750")
 	})
-}
 
-func TestClearPacks(t *testing.T) {
-	mockService := new(MockPackageService)
-	handler := NewPackageHandler(mockService)
+	t.Run("JSON when both are accepted", func(t *testing.T) {
+		mockService.On("Calculate", 250).Return(services.NewCalculationResult(250, map[int]int{250: 1}), nil).Once()
 
-	mockService.On("ClearPacks").Once()
-	mockService.On("GetPackSizes").Return([]int{}).Once()
+		form := url.Values{}
+		form.Add("order", "250")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Add("Accept", "text/html, application/json")
+		rr := httptest.NewRecorder()
 
-	req, _ := http.NewRequest("POST", "/clear-packs", nil)
-	rr := httptest.NewRecorder()
+		handler.Calculate(rr, req)
 
-	handler.ClearPacks(rr, req)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	})
 
-	assert.Equal(t, http.StatusOK, rr.Code)
-	assert.Contains(t, rr.Header().Get("HX-Trigger"), "packSizesChanged")
-}
+	t.Run("Explain", func(t *testing.T) {
+		explanation := services.Explanation{
+			Chosen: services.NewCalculationResult(501, map[int]int{500: 1, 250: 1}),
+			Candidates: []services.Candidate{{
+				CalculationResult: services.NewCalculationResult(501, map[int]int{1000: 1}),
+				Rule:              services.RuleLeastExcess,
+			}},
+			Rule: services.RuleLeastExcess,
+		}
+		mockService.On("ExplainPacks", 501).Return(explanation, nil).Once()
 
-func TestPackSizes(t *testing.T) {
-	mockService := new(MockPackageService)
-	handler := NewPackageHandler(mockService)
+		form := url.Values{}
+		form.Add("order", "501")
+		req, _ := http.NewRequest("POST", "/calculate?explain=true", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
 
-	mockService.On("GetPackSizes").Return([]int{100, 250, 500}).Once()
+		handler.Calculate(rr, req)
 
-	req, _ := http.NewRequest("GET", "/pack-sizes", nil)
-	rr := httptest.NewRecorder()
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var result services.Explanation
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, explanation, result)
+	})
 
-	handler.PackSizes(rr, req)
+	t.Run("Explain an order that cannot be calculated", func(t *testing.T) {
+		err := &services.RuleError{Reasons: []string{"at most 1 pack of 1000"}}
+		mockService.On("ExplainPacks", 5000).Return(services.Explanation{}, err).Once()
 
-	assert.Equal(t, http.StatusOK, rr.Code)
-	assert.Contains(t, rr.Body.String(), "100")
-	assert.Contains(t, rr.Body.String(), "250")
-	assert.Contains(t, rr.Body.String(), "500")
+		req, _ := http.NewRequest("GET", "/calculate?explain=true&order=5000", nil)
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "pack rules cannot be met")
+	})
+
+	t.Run("Shipments", func(t *testing.T) {
+		limits := services.ShipmentLimits{MaxItems: 1000}
+		expected := services.NewCalculationResult(1750, map[int]int{1000: 1, 500: 1, 250: 1})
+		expected.Shipments = []services.Shipment{
+			{Packs: map[int]int{1000: 1}, Total: 1000, PacksCount: 1},
+			{Packs: map[int]int{500: 1, 250: 1}, Total: 750, PacksCount: 2},
+		}
+		mockService.On("CalculateShipments", 1750, limits).Return(expected, nil).Once()
+
+		form := url.Values{}
+		form.Add("order", "1750")
+		form.Add("maxItemsPerShipment", "1000")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var result services.CalculationResult
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("Shipment limit too small", func(t *testing.T) {
+		limits := services.ShipmentLimits{MaxItems: 10}
+		mockService.On("CalculateShipments", 1750, limits).Return(services.CalculationResult{}, services.ErrShipmentLimitTooSmall).Once()
+
+		form := url.Values{}
+		form.Add("order", "1750")
+		form.Add("maxItemsPerShipment", "10")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("Shipments for an order too large", func(t *testing.T) {
+		limits := services.ShipmentLimits{MaxPacks: 1}
+		mockService.On("CalculateShipments", 1750, limits).Return(services.CalculationResult{}, services.ErrOrderTooLarge).Once()
+
+		form := url.Values{}
+		form.Add("order", "1750")
+		form.Add("maxPacksPerShipment", "1")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), services.ErrOrderTooLarge.Error())
+	})
+
+	t.Run("Exact mode", func(t *testing.T) {
+		mockService.On("CalculateExact", 29).Return(map[int]int{12: 2, 5: 1}, nil).Once()
+
+		form := url.Values{}
+		form.Add("order", "29")
+		form.Add("mode", "exact")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var result map[int]int
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, map[int]int{12: 2, 5: 1}, result)
+	})
+
+	t.Run("Exact mode without a fit", func(t *testing.T) {
+		mockService.On("CalculateExact", 18).Return(nil, &services.NoExactFitError{Order: 18, Below: 17, Above: 20}).Once()
+
+		form := url.Values{}
+		form.Add("order", "18")
+		form.Add("mode", "exact")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var body struct {
+			Error string `json:"error"`
+			Below int    `json:"below"`
+			Above int    `json:"above"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+		assert.Equal(t, 17, body.Below)
+		assert.Equal(t, 20, body.Above)
+		assert.Contains(t, body.Error, "17 and 20")
+	})
+
+	t.Run("Rules cannot be met", func(t *testing.T) {
+		mockService.On("Calculate", 2000).Return(services.CalculationResult{}, &services.RuleError{Reasons: []string{"every pack size is disabled"}}).Once()
+
+		form := url.Values{}
+		form.Add("order", "2000")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "every pack size is disabled")
+	})
+
+	t.Run("Invalid order size", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("order", "invalid")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestCalculateOrder(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	t.Run("Successful calculation", func(t *testing.T) {
+		lines := []services.OrderLine{
+			{SKU: "BOLT", Quantity: 501},
+			{SKU: "WATER", Quantity: 30, Sizes: []int{12, 24}},
+		}
+		expected := services.OrderResult{
+			Lines: []services.LineResult{
+				{SKU: "BOLT", CalculationResult: services.NewCalculationResult(501, map[int]int{500: 1, 250: 1})},
+				{SKU: "WATER", CalculationResult: services.NewCalculationResult(30, map[int]int{24: 1, 12: 1})},
+			},
+			TotalItems:  786,
+			TotalExcess: 255,
+			TotalPacks:  4,
+		}
+		mockService.On("CalculateOrder", lines).Return(expected, nil).Once()
+
+		body := `{"lines":[{"sku":"BOLT","quantity":501},{"sku":"WATER","quantity":30,"sizes":[12,24]}]}`
+		req, _ := http.NewRequest("POST", "/calculate-order", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.CalculateOrder(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var result services.OrderResult
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("Invalid line", func(t *testing.T) {
+		lines := []services.OrderLine{{SKU: "BOLT", Quantity: 0}}
+		err := &services.LineError{Line: 1, SKU: "BOLT", Err: services.ErrInvalidQuantity}
+		mockService.On("CalculateOrder", lines).Return(services.OrderResult{}, err).Once()
+
+		req, _ := http.NewRequest("POST", "/calculate-order", strings.NewReader(`{"lines":[{"sku":"BOLT","quantity":0}]}`))
+		req.Header.Add("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.CalculateOrder(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var body errorResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		assert.Equal(t, []FieldError{{Field: "lines[0].quantity", Message: "must be positive"}}, body.Fields)
+	})
+
+	t.Run("Line too large to calculate", func(t *testing.T) {
+		lines := []services.OrderLine{{SKU: "BOLT", Quantity: 10, Sizes: []int{1 << 30, 1<<30 - 1}}}
+		err := &services.LineError{Line: 1, SKU: "BOLT", Err: services.ErrTableTooLarge}
+		mockService.On("CalculateOrder", lines).Return(services.OrderResult{}, err).Once()
+
+		req, _ := http.NewRequest("POST", "/calculate-order", strings.NewReader(`{"lines":[{"sku":"BOLT","quantity":10,"sizes":[1073741824,1073741823]}]}`))
+		rr := httptest.NewRecorder()
+
+		handler.CalculateOrder(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"lines[0].sizes"`)
+	})
+
+	t.Run("Malformed body", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/calculate-order", strings.NewReader(`{"lines":`))
+		rr := httptest.NewRecorder()
+
+		handler.CalculateOrder(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		body := `{"lines":[` + strings.Repeat(`{"sku":"BOLT","quantity":10},`, maxParamsBody/25) + `{"sku":"BOLT","quantity":10}]}`
+		req, _ := http.NewRequest("POST", "/calculate-order", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		handler.CalculateOrder(rr, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+}
+
+func TestPackRules(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	t.Run("Set rule", func(t *testing.T) {
+		rule := repositories.PackRule{MinCount: 1, MaxCount: 3}
+		mockService.On("SetPackRule", 500, rule).Return(nil).Once()
+		mockService.On("GetPackRules").Return(map[int]repositories.PackRule{500: rule}).Once()
+
+		form := url.Values{}
+		form.Add("size", "500")
+		form.Add("minCount", "1")
+		form.Add("maxCount", "3")
+		req, _ := http.NewRequest("POST", "/pack-rules", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.SetPackRule(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("HX-Trigger"), "packSizesChanged")
+		var rules map[int]repositories.PackRule
+		json.NewDecoder(rr.Body).Decode(&rules)
+		assert.Equal(t, map[int]repositories.PackRule{500: rule}, rules)
+	})
+
+	t.Run("Invalid rule", func(t *testing.T) {
+		rule := repositories.PackRule{MinCount: 3, MaxCount: 1}
+		mockService.On("SetPackRule", 500, rule).Return(services.ErrInvalidPackRule).Once()
+
+		form := url.Values{}
+		form.Add("size", "500")
+		form.Add("minCount", "3")
+		form.Add("maxCount", "1")
+		req, _ := http.NewRequest("POST", "/pack-rules", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.SetPackRule(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestPackagingLevels(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+	levels := []repositories.PackagingLevel{
+		{Name: "case", Quantity: 4, Unit: "pack", Size: 48},
+		{Name: "pack", Quantity: 12, Unit: repositories.ItemUnit, Size: 12},
+	}
+
+	t.Run("List", func(t *testing.T) {
+		mockService.On("GetPackagingLevels").Return(levels).Once()
+
+		req, _ := http.NewRequest("GET", "/packaging-levels", nil)
+		rr := httptest.NewRecorder()
+
+		handler.PackagingLevels(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got []repositories.PackagingLevel
+		json.NewDecoder(rr.Body).Decode(&got)
+		assert.Equal(t, levels, got)
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		mockService.On("AddPackagingLevel", "case", 4, "pack").Return(nil).Once()
+		mockService.On("GetPackagingLevels").Return(levels).Once()
+
+		req, _ := http.NewRequest("POST", "/packaging-levels", strings.NewReader(`{"name":"case","quantity":4,"unit":"pack"}`))
+		req.Header.Add("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.AddPackagingLevel(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("HX-Trigger"), "packSizesChanged")
+	})
+
+	t.Run("Invalid level", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("quantity", "1")
+		req, _ := http.NewRequest("POST", "/packaging-levels", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.AddPackagingLevel(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error":"invalid request","fields":[{"field":"name","message":"is required"},{"field":"quantity","message":"must be at least 2"}]}`, rr.Body.String())
+	})
+
+	t.Run("Unknown unit", func(t *testing.T) {
+		mockService.On("AddPackagingLevel", "pallet", 40, "crate").Return(repositories.ErrLevelNotFound).Once()
+
+		req, _ := http.NewRequest("POST", "/packaging-levels", strings.NewReader("name=pallet&quantity=40&unit=crate"))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.AddPackagingLevel(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		mockService.On("RemovePackagingLevel", "case").Return(nil).Once()
+		mockService.On("GetPackagingLevels").Return(levels[1:]).Once()
+
+		req, _ := http.NewRequest("DELETE", "/packaging-levels/case", nil)
+		req.SetPathValue("name", "case")
+		rr := httptest.NewRecorder()
+
+		handler.RemovePackagingLevel(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"pack","quantity":12,"unit":"item","size":12}]`, rr.Body.String())
+	})
+
+	t.Run("Remove a contained level", func(t *testing.T) {
+		mockService.On("RemovePackagingLevel", "pack").Return(repositories.ErrLevelInUse).Once()
+
+		req, _ := http.NewRequest("DELETE", "/packaging-levels/pack", nil)
+		req.SetPathValue("name", "pack")
+		rr := httptest.NewRecorder()
+
+		handler.RemovePackagingLevel(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
+
+func TestLoadContainers(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	t.Run("Successful load", func(t *testing.T) {
+		req := services.ContainerRequest{
+			Order:      750,
+			Dimensions: map[int]services.PackDimensions{250: {Volume: 1, Weight: 2}},
+			Containers: []services.Container{{Name: "box", Volume: 4}},
+		}
+		expected := services.ContainerResult{
+			Packs:             map[int]int{250: 3},
+			Containers:        []services.ContainerLoad{{Container: "box", Packs: map[int]int{250: 3}, Volume: 3, Weight: 6, VolumeUtilisation: 75}},
+			VolumeUtilisation: 75,
+			CatalogueVersion:  2,
+		}
+		mockService.On("LoadContainers", req).Return(expected, nil).Once()
+
+		body := `{"order":750,"dimensions":{"250":{"volume":1,"weight":2}},"containers":[{"name":"box","volume":4}]}`
+		r, _ := http.NewRequest("POST", "/load-containers", strings.NewReader(body))
+		r.Header.Add("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.LoadContainers(rr, r)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("X-Catalogue-Version"))
+		var result services.ContainerResult
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("Pack too large", func(t *testing.T) {
+		err := &services.PackSizeError{Size: 250, Err: services.ErrPackTooLarge}
+		mockService.On("LoadContainers", mock.Anything).Return(services.ContainerResult{}, err).Once()
+
+		r, _ := http.NewRequest("POST", "/load-containers", strings.NewReader(`{"packs":{"250":1}}`))
+		rr := httptest.NewRecorder()
+
+		handler.LoadContainers(rr, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.JSONEq(t, `{"error":"pack size 250: pack does not fit in any container"}`, rr.Body.String())
+	})
+
+	t.Run("No containers", func(t *testing.T) {
+		mockService.On("LoadContainers", mock.Anything).Return(services.ContainerResult{}, services.ErrNoContainers).Once()
+
+		r, _ := http.NewRequest("POST", "/load-containers", strings.NewReader(`{"order":10}`))
+		rr := httptest.NewRecorder()
+
+		handler.LoadContainers(rr, r)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Malformed body", func(t *testing.T) {
+		r, _ := http.NewRequest("POST", "/load-containers", strings.NewReader(`{`))
+		rr := httptest.NewRecorder()
+
+		handler.LoadContainers(rr, r)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Too many packs", func(t *testing.T) {
+		mockService.On("LoadContainers", mock.Anything).Return(services.ContainerResult{}, services.ErrTooManyPacks).Once()
+
+		r, _ := http.NewRequest("POST", "/load-containers", strings.NewReader(`{"packs":{"250":100000}}`))
+		rr := httptest.NewRecorder()
+
+		handler.LoadContainers(rr, r)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"field":"packs"`)
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		body := `{"containers":[` + strings.Repeat(`{"name":"box","volume":4},`, maxParamsBody/20) + `]}`
+		r, _ := http.NewRequest("POST", "/load-containers", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		handler.LoadContainers(rr, r)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+}
+
+func TestPackingSlip(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	t.Run("Printable page of a calculation", func(t *testing.T) {
+		result := services.NewCalculationResult(750, map[int]int{500: 1, 250: 1})
+		result.CatalogueVersion = 3
+		mockService.On("Calculate", 750).Return(result, nil).Once()
+
+		r, _ := http.NewRequest("GET", "/packing-slip?orderId=SO-1042&order=750", nil)
+		rr := httptest.NewRecorder()
+
+		handler.PackingSlip(rr, r)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "3", rr.Header().Get("X-Catalogue-Version"))
+		assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rr.Body.String(), "SO-1042")
+	})
+
+	t.Run("PDF of a posted result", func(t *testing.T) {
+		body := `{"orderId":"SO-1042","result":{"packs":{"500":2},"total":1000,"orderSize":990,"excessItems":10,"packsCount":2}}`
+		r, _ := http.NewRequest("POST", "/packing-slip", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept", "application/pdf")
+		rr := httptest.NewRecorder()
+
+		handler.PackingSlip(rr, r)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `inline; filename="packing-slip-SO-1042.pdf"`, rr.Header().Get("Content-Disposition"))
+		assert.Equal(t, strconv.Itoa(rr.Body.Len()), rr.Header().Get("Content-Length"))
+		assert.True(t, strings.HasPrefix(rr.Body.String(), "%PDF-"))
+		assert.Contains(t, rr.Body.String(), "(Order SO-1042)")
+	})
+
+	t.Run("PDF from the calculator form", func(t *testing.T) {
+		form := url.Values{"orderId": {"SO-7"}, "format": {"pdf"}, "result": {`{"packs":{"250":1},"total":250,"orderSize":1,"excessItems":249,"packsCount":1}`}}
+		r, _ := http.NewRequest("POST", "/packing-slip", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.PackingSlip(rr, r)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), "(249)")
+	})
+
+	t.Run("Invalid fields", func(t *testing.T) {
+		r, _ := http.NewRequest("GET", "/packing-slip?orderId=&result=nope&format=doc", nil)
+		r.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.PackingSlip(rr, r)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error":"invalid request","fields":[
+			{"field":"result","message":"must be a calculation result as JSON"},
+			{"field":"orderId","message":"must be 1 to 40 printable ASCII characters"},
+			{"field":"format","message":"must be \"html\" or \"pdf\""}]}`, rr.Body.String())
+	})
+
+	t.Run("Order that cannot be calculated", func(t *testing.T) {
+		mockService.On("Calculate", 10).Return(services.CalculationResult{}, &services.RuleError{Reasons: []string{"size 250 is disabled"}}).Once()
+
+		body := `{"orderId":"SO-1","order":10}`
+		r, _ := http.NewRequest("POST", "/packing-slip", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.PackingSlip(rr, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+}
+
+func TestWhatIf(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	t.Run("Successful comparison", func(t *testing.T) {
+		expected := services.WhatIfResult{
+			Current:     services.CatalogueMetrics{Sizes: []int{500, 250}, Orders: 2, TotalPacks: 4, AveragePacks: 2},
+			Proposed:    services.CatalogueMetrics{Sizes: []int{750, 500, 250}, Orders: 2, TotalPacks: 2, AveragePacks: 1},
+			PacksChange: -1,
+		}
+		mockService.On("CompareCatalogue", []int{250, 500, 750}, []int{700, 700}, map[int]float64{750: 2}).Return(expected, nil).Once()
+
+		body := `{"sizes":[250,500,750],"orders":[700,700],"costs":{"750":2}}`
+		req, _ := http.NewRequest("POST", "/what-if", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.WhatIf(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var result services.WhatIfResult
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("No orders", func(t *testing.T) {
+		mockService.On("CompareCatalogue", []int{250}, []int(nil), map[int]float64(nil)).Return(services.WhatIfResult{}, services.ErrNoOrders).Once()
+
+		req, _ := http.NewRequest("POST", "/what-if", strings.NewReader(`{"sizes":[250]}`))
+		rr := httptest.NewRecorder()
+
+		handler.WhatIf(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var body errorResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		assert.Equal(t, []FieldError{{Field: "orders", Message: "must not be empty"}}, body.Fields)
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		body := `{"sizes":[250],"orders":[` + strings.Repeat(`1000,`, maxParamsBody/5) + `1000]}`
+		req, _ := http.NewRequest("POST", "/what-if", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		handler.WhatIf(rr, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+}
+
+func TestOptimize(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	t.Run("Successful optimization", func(t *testing.T) {
+		request := services.OptimizeRequest{Orders: []int{700, 1400}, K: 1, Objective: services.ObjectivePacks}
+		expected := []services.Recommendation{
+			{Rank: 1, CatalogueMetrics: services.CatalogueMetrics{Sizes: []int{700}, Orders: 2, TotalPacks: 3, AveragePacks: 1.5}},
+		}
+		mockService.On("OptimizeCatalogue", request).Return(expected, nil).Once()
+
+		body := `{"orders":[700,1400],"k":1,"objective":"packs"}`
+		req, _ := http.NewRequest("POST", "/optimize", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.Optimize(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var result []services.Recommendation
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("Invalid objective", func(t *testing.T) {
+		request := services.OptimizeRequest{Orders: []int{700}, K: 1, Objective: "cost"}
+		mockService.On("OptimizeCatalogue", request).Return(nil, services.ErrInvalidObjective).Once()
+
+		body := `{"orders":[700],"k":1,"objective":"cost"}`
+		req, _ := http.NewRequest("POST", "/optimize", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		handler.Optimize(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var response errorResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		assert.Equal(t, "invalid request", response.Error)
+		assert.Equal(t, []FieldError{{Field: "objective", Message: `must be "excess" or "packs"`}}, response.Fields)
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		body := `{"k":1,"orders":[` + strings.Repeat(`1000,`, maxParamsBody/5) + `1000]}`
+		req, _ := http.NewRequest("POST", "/optimize", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		handler.Optimize(rr, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+}
+
+func TestVersions(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+	versions := []repositories.CatalogueVersion{
+		{ID: 1, Change: "created", Sizes: []int{}},
+		{ID: 2, Change: "added 250", Sizes: []int{250}},
+	}
+
+	t.Run("List as JSON", func(t *testing.T) {
+		mockService.On("ListVersions").Return(versions).Once()
+
+		req, _ := http.NewRequest("GET", "/versions", nil)
+		rr := httptest.NewRecorder()
+
+		handler.Versions(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var result []repositories.CatalogueVersion
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, versions, result)
+	})
+
+	t.Run("List as HTML", func(t *testing.T) {
+		mockService.On("ListVersions").Return(versions).Once()
+
+		req, _ := http.NewRequest("GET", "/versions", nil)
+		req.Header.Add("HX-Request", "true")
+		rr := httptest.NewRecorder()
+
+		handler.Versions(rr, req)
+
+		assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rr.Body.String(), "added 250")
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		diff := services.VersionDiff{From: versions[0], To: versions[1], Added: []int{250}, Removed: []int{}, RuleChanges: []services.RuleChange{}}
+		mockService.On("DiffVersions", 1, 2).Return(diff, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/versions/diff?from=1&to=2", nil)
+		rr := httptest.NewRecorder()
+
+		handler.VersionDiff(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var result services.VersionDiff
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, diff, result)
+	})
+
+	t.Run("Diff of unknown version", func(t *testing.T) {
+		mockService.On("DiffVersions", 1, 9).Return(services.VersionDiff{}, repositories.ErrVersionNotFound).Once()
+
+		req, _ := http.NewRequest("GET", "/versions/diff?from=1&to=9", nil)
+		rr := httptest.NewRecorder()
+
+		handler.VersionDiff(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		rolledBack := append(versions, repositories.CatalogueVersion{ID: 3, Change: "rolled back to version 1", Sizes: []int{}})
+		mockService.On("RollbackCatalogue", 1).Return(nil).Once()
+		mockService.On("ListVersions").Return(rolledBack).Once()
+
+		form := url.Values{}
+		form.Add("version", "1")
+		req, _ := http.NewRequest("POST", "/rollback", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Rollback(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "packSizesChanged", rr.Header().Get("HX-Trigger"))
+		var result repositories.CatalogueVersion
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.Equal(t, 3, result.ID)
+	})
+
+	t.Run("Rollback to unknown version", func(t *testing.T) {
+		mockService.On("RollbackCatalogue", 9).Return(repositories.ErrVersionNotFound).Once()
+
+		form := url.Values{}
+		form.Add("version", "9")
+		req, _ := http.NewRequest("POST", "/rollback", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Rollback(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestClearPacks(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	mockService.On("ClearPacks").Once()
+	mockService.On("GetPackSizes").Return([]int{}).Once()
+
+	req, _ := http.NewRequest("POST", "/clear-packs", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ClearPacks(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "packSizesChanged")
+}
+
+func TestPackSizes(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	mockService.On("GetPackSizes").Return([]int{100, 250, 500}).Once()
+
+	req, _ := http.NewRequest("GET", "/pack-sizes", nil)
+	rr := httptest.NewRecorder()
+
+	handler.PackSizes(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "100")
+	assert.Contains(t, rr.Body.String(), "250")
+	assert.Contains(t, rr.Body.String(), "500")
+}
+
+func TestPackSizesJSON(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	mockService.On("GetPackSizes").Return([]int{500, 250, 100}).Once()
+
+	req, _ := http.NewRequest("GET", "/pack-sizes", nil)
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.PackSizes(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	var sizes []int
+	json.NewDecoder(rr.Body).Decode(&sizes)
+	assert.Equal(t, []int{500, 250, 100}, sizes)
+}
+
+func TestScheduledCatalogue(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Add with effective dates", func(t *testing.T) {
+		schedule := repositories.Schedule{EffectiveFrom: monday, EffectiveUntil: monday.AddDate(0, 3, 0)}
+		mockService.On("AddScheduledPack", 750, schedule).Return(nil).Once()
+		mockService.On("GetPackSizes").Return([]int{500, 250}).Once()
+
+		form := url.Values{}
+		form.Add("size", "750")
+		form.Add("effectiveFrom", "2024-03-04")
+		form.Add("effectiveUntil", "2024-06-04T00:00:00Z")
+		req, _ := http.NewRequest("POST", "/add-pack", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.AddPack(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("HX-Trigger"), "packSizesChanged")
+	})
+
+	t.Run("Add with an invalid window", func(t *testing.T) {
+		schedule := repositories.Schedule{EffectiveFrom: monday, EffectiveUntil: monday}
+		mockService.On("AddScheduledPack", 750, schedule).Return(services.ErrInvalidSchedule).Once()
+
+		form := url.Values{}
+		form.Add("size", "750")
+		form.Add("effectiveFrom", "2024-03-04")
+		form.Add("effectiveUntil", "2024-03-04")
+		req, _ := http.NewRequest("POST", "/add-pack", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.AddPack(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Invalid date", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("size", "750")
+		form.Add("effectiveFrom", "next monday")
+		req, _ := http.NewRequest("POST", "/add-pack", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.AddPack(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Scheduled removal", func(t *testing.T) {
+		mockService.On("SchedulePackRemoval", 250, monday).Return(nil).Once()
+		mockService.On("GetPackSizes").Return([]int{500, 250}).Once()
+
+		form := url.Values{}
+		form.Add("size", "250")
+		form.Add("effectiveFrom", "2024-03-04")
+		req, _ := http.NewRequest("POST", "/remove-pack", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.RemovePack(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Sizes at a date", func(t *testing.T) {
+		mockService.On("GetPackSizesAt", monday).Return([]int{750, 500}).Once()
+
+		req, _ := http.NewRequest("GET", "/pack-sizes?at=2024-03-04", nil)
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.PackSizes(rr, req)
+
+		var sizes []int
+		json.NewDecoder(rr.Body).Decode(&sizes)
+		assert.Equal(t, []int{750, 500}, sizes)
+	})
+
+	t.Run("Calculate for a ship date", func(t *testing.T) {
+		result := services.NewCalculationResult(700, map[int]int{750: 1})
+		result.CatalogueVersion = 4
+		mockService.On("CalculateAt", 700, monday).Return(result, nil).Once()
+
+		form := url.Values{}
+		form.Add("order", "700")
+		form.Add("shipDate", "2024-03-04")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "4", rr.Header().Get("X-Catalogue-Version"))
+		var packs map[int]int
+		json.NewDecoder(rr.Body).Decode(&packs)
+		assert.Equal(t, map[int]int{750: 1}, packs)
+	})
+
+	t.Run("Ship date with exact mode", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("order", "700")
+		form.Add("shipDate", "2024-03-04")
+		form.Add("mode", "exact")
+		req, _ := http.NewRequest("POST", "/calculate", strings.NewReader(form.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		handler.Calculate(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("List schedules", func(t *testing.T) {
+		schedules := map[int]repositories.Schedule{750: {EffectiveFrom: monday}}
+		mockService.On("GetPackSchedules").Return(schedules).Once()
+
+		req, _ := http.NewRequest("GET", "/pack-schedules", nil)
+		rr := httptest.NewRecorder()
+
+		handler.PackSchedules(rr, req)
+
+		var result map[int]repositories.Schedule
+		json.NewDecoder(rr.Body).Decode(&result)
+		assert.True(t, result[750].EffectiveFrom.Equal(monday))
+	})
+}
+
+func TestEvents(t *testing.T) {
+	mockService := new(MockPackageService)
+	handler := NewPackageHandler(mockService)
+
+	t.Run("Streams events", func(t *testing.T) {
+		ch := make(chan events.Event, 1)
+		mockService.On("Subscribe").Return((<-chan events.Event)(ch), func() {}).Once()
+
+		ch <- events.Event{Name: "packSizesChanged", Data: "[250]"}
+		close(ch)
+
+		req, _ := http.NewRequest("GET", "/events", nil)
+		rr := httptest.NewRecorder()
+
+		handler.Events(rr, req)
+
+		assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), "event: packSizesChanged\ndata: [250]\n\n")
+	})
+
+	t.Run("Unsubscribes when the client disconnects", func(t *testing.T) {
+		ch := make(chan events.Event)
+		unsubscribed := false
+		mockService.On("Subscribe").Return((<-chan events.Event)(ch), func() { unsubscribed = true }).Once()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, _ := http.NewRequestWithContext(ctx, "GET", "/events", nil)
+		rr := httptest.NewRecorder()
+
+		handler.Events(rr, req)
+
+		assert.True(t, unsubscribed)
+	})
 }
 
 func TestCalculatorIndex(t *testing.T) {
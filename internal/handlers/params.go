@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxParamsBody limits the size of a JSON or form request body.
+const maxParamsBody = 1 << 20
+
+// decodeBody decodes a JSON request body of up to maxParamsBody bytes into v.
+// It reports a larger body with HTTP 413 and an invalid one with HTTP 400,
+// and returns whether v was decoded.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxParamsBody)).Decode(v)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &tooLarge):
+		writeError(w, r, formatJSON, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is larger than %d bytes", maxParamsBody))
+	default:
+		writeError(w, r, formatJSON, http.StatusBadRequest, "Invalid request body")
+	}
+	return false
+}
+
+// FieldError points at a request field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a request.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + ": " + field.Message
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// params are the input values of a request. They are read alike from a JSON object
+// body, a form body and the query string; body values take precedence over the query.
+// Parse errors are collected so that every invalid field is reported at once.
+type params struct {
+	values url.Values
+	errs   []FieldError
+}
+
+// readParams reads the input values of a request, together with the values of the
+// path wildcards named by path, which take precedence over the body and the query.
+// A body that cannot be parsed is reported as an error of the field "body".
+func readParams(r *http.Request, path ...string) *params {
+	p := &params{values: url.Values{}}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" && r.Body != nil {
+		if err := p.readJSON(io.LimitReader(r.Body, maxParamsBody)); err != nil {
+			p.fail("body", err.Error())
+		}
+	} else if err := r.ParseForm(); err != nil {
+		p.fail("body", "must be a valid form")
+	} else {
+		p.values = r.Form
+	}
+
+	for name, values := range r.URL.Query() {
+		if !p.values.Has(name) {
+			p.values[name] = values
+		}
+	}
+	for _, name := range path {
+		if value := r.PathValue(name); value != "" {
+			p.values.Set(name, value)
+		}
+	}
+	return p
+}
+
+// readJSON reads a JSON object of strings, numbers and booleans.
+func (p *params) readJSON(body io.Reader) error {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&fields); err != nil {
+		return errors.New("must be a JSON object")
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		raw := fields[name]
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			p.fail(name, "must be a string, number or boolean")
+			continue
+		}
+		switch value := value.(type) {
+		case nil:
+		case string:
+			p.values.Set(name, value)
+		case float64, bool:
+			p.values.Set(name, string(raw))
+		default:
+			p.fail(name, "must be a string, number or boolean")
+		}
+	}
+	return nil
+}
+
+// fail records an invalid field. Only the first error of each field is kept.
+func (p *params) fail(field, message string) {
+	for _, err := range p.errs {
+		if err.Field == field {
+			return
+		}
+	}
+	p.errs = append(p.errs, FieldError{Field: field, Message: message})
+}
+
+// String returns a value, or "" if it is missing.
+func (p *params) String(name string) string {
+	return strings.TrimSpace(p.values.Get(name))
+}
+
+// Int reads an optional whole number, 0 when missing.
+func (p *params) Int(name string) int {
+	value := p.String(name)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.fail(name, "must be a whole number")
+	}
+	return n
+}
+
+// NonNegativeInt reads an optional whole number that must not be negative.
+func (p *params) NonNegativeInt(name string) int {
+	n := p.Int(name)
+	if n < 0 {
+		p.fail(name, "must not be negative")
+	}
+	return n
+}
+
+// NonNegativeFloat reads an optional number that must not be negative, 0 when missing.
+func (p *params) NonNegativeFloat(name string) float64 {
+	value := p.String(name)
+	if value == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		p.fail(name, "must be a number")
+		return 0
+	}
+	if f < 0 {
+		p.fail(name, "must not be negative")
+	}
+	return f
+}
+
+// PositiveInt reads a required whole number greater than zero.
+func (p *params) PositiveInt(name string) int {
+	if p.String(name) == "" {
+		p.fail(name, "is required")
+		return 0
+	}
+	before := len(p.errs)
+	n := p.Int(name)
+	if len(p.errs) == before && n <= 0 {
+		p.fail(name, "must be positive")
+	}
+	return n
+}
+
+// Bool reads an optional boolean, false when missing.
+func (p *params) Bool(name string) bool {
+	value := p.String(name)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		p.fail(name, "must be true or false")
+	}
+	return b
+}
+
+// Date reads an optional date given as "2006-01-02" (midnight UTC) or in RFC 3339 format.
+// A missing value gives the zero time.
+func (p *params) Date(name string) time.Time {
+	t, err := parseDate(p.String(name))
+	if err != nil {
+		p.fail(name, "must be a date (2006-01-02) or an RFC 3339 time")
+	}
+	return t
+}
+
+// Err returns the invalid fields, or nil if every value was valid.
+func (p *params) Err() *ValidationError {
+	if len(p.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: p.errs}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"Ship_Manager/internal/webhooks"
+	"net/http"
+)
+
+// WebhookHandler is responsible for handling HTTP requests that manage webhook
+// subscriptions and inspect the delivery log.
+type WebhookHandler struct {
+	dispatcher *webhooks.Dispatcher
+}
+
+// NewWebhookHandler creates a new instance of WebhookHandler with the given Dispatcher.
+func NewWebhookHandler(dispatcher *webhooks.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{
+		dispatcher: dispatcher,
+	}
+}
+
+// Webhooks handles GET requests for the webhook subscriptions and returns them as JSON,
+// without their secrets.
+func (wh *WebhookHandler) Webhooks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, wh.dispatcher.Subscriptions())
+}
+
+// Subscribe handles POST requests to create a subscription from a JSON body with the
+// target "url", the "events" to deliver (every event when empty) and the "secret" used
+// to sign deliveries. Responds with HTTP 201 and the subscription, without its secret.
+// Returns HTTP 400 with the invalid field for an invalid or private URL or a missing secret,
+// and HTTP 413 if the body is larger than 1 MiB.
+func (wh *WebhookHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	var sub webhooks.Subscription
+	if !decodeBody(w, r, &sub) {
+		return
+	}
+	sub, err := wh.dispatcher.Subscribe(sub)
+	switch err {
+	case nil:
+	case webhooks.ErrInvalidURL:
+		writeValidationError(w, r, formatJSON, &ValidationError{Fields: []FieldError{{Field: "url", Message: "must be an absolute http or https URL"}}})
+		return
+	case webhooks.ErrPrivateURL:
+		writeValidationError(w, r, formatJSON, &ValidationError{Fields: []FieldError{{Field: "url", Message: "must not point at a loopback, link-local or private address"}}})
+		return
+	case webhooks.ErrMissingSecret:
+		writeValidationError(w, r, formatJSON, &ValidationError{Fields: []FieldError{{Field: "secret", Message: "is required"}}})
+		return
+	default:
+		writeError(w, r, formatJSON, http.StatusInternalServerError, "An error occurred while saving the subscription")
+		return
+	}
+	sub.Secret = ""
+	writeJSONStatus(w, http.StatusCreated, sub)
+}
+
+// Unsubscribe handles DELETE requests to remove the subscription given by the path
+// value "id", or else by the query value "id", together with its pending deliveries.
+// Returns HTTP 404 if the subscription does not exist.
+func (wh *WebhookHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		id = r.FormValue("id")
+	}
+
+	switch err := wh.dispatcher.Unsubscribe(id); err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case webhooks.ErrSubscriptionNotFound:
+		writeError(w, r, formatJSON, http.StatusNotFound, "Subscription not found")
+	default:
+		writeError(w, r, formatJSON, http.StatusInternalServerError, "An error occurred while removing the subscription")
+	}
+}
+
+// Deliveries handles GET requests for the webhook delivery log.
+// With the query value "status" only deliveries in that state are returned.
+func (wh *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries := wh.dispatcher.Deliveries()
+	if status := r.FormValue("status"); status != "" {
+		filtered := deliveries[:0]
+		for _, delivery := range deliveries {
+			if delivery.Status == status {
+				filtered = append(filtered, delivery)
+			}
+		}
+		deliveries = filtered
+	}
+	writeJSON(w, deliveries)
+}
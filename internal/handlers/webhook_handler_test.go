@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"Ship_Manager/internal/webhooks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWebhookHandler(t *testing.T) *WebhookHandler {
+	t.Helper()
+	outbox, err := webhooks.NewOutbox("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewWebhookHandler(webhooks.NewDispatcher(outbox, nil, "default"))
+}
+
+func TestWebhooks(t *testing.T) {
+	wh := newTestWebhookHandler(t)
+
+	t.Run("Create subscription", func(t *testing.T) {
+		body := `{"url":"https://example.com/hook","events":["largeCalculation"],"secret":"s3cret"}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		wh.Subscribe(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var sub webhooks.Subscription
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &sub))
+		assert.NotEmpty(t, sub.ID)
+		assert.Empty(t, sub.Secret)
+		assert.Equal(t, []string{"largeCalculation"}, sub.Events)
+	})
+
+	t.Run("Invalid subscription", func(t *testing.T) {
+		tests := []struct {
+			body  string
+			field string
+		}{
+			{body: `{"url":"example.com","secret":"s"}`, field: "url"},
+			{body: `{"url":"http://127.0.0.1/hook","secret":"s"}`, field: "url"},
+			{body: `{"url":"https://example.com"}`, field: "secret"},
+			{body: `not json`},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			wh.Subscribe(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, tt.body)
+
+			var response errorResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response), tt.body)
+			if tt.field != "" {
+				assert.Len(t, response.Fields, 1, tt.body)
+				assert.Equal(t, tt.field, response.Fields[0].Field, tt.body)
+			}
+		}
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		body := `{"url":"https://example.com/hook","secret":"` + strings.Repeat("s", maxParamsBody) + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		wh.Subscribe(rr, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+
+	t.Run("List and delete subscriptions", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		wh.Webhooks(rr, httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var subs []webhooks.Subscription
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &subs))
+		assert.Len(t, subs, 1)
+
+		req := httptest.NewRequest(http.MethodDelete, "/webhooks/"+subs[0].ID, nil)
+		req.SetPathValue("id", subs[0].ID)
+		rr = httptest.NewRecorder()
+		wh.Unsubscribe(rr, req)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		rr = httptest.NewRecorder()
+		wh.Unsubscribe(rr, httptest.NewRequest(http.MethodDelete, "/webhooks?id="+subs[0].ID, nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		var response errorResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "Subscription not found", response.Error)
+	})
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	wh := newTestWebhookHandler(t)
+	wh.dispatcher.Subscribe(webhooks.Subscription{URL: "https://example.com/hook", Secret: "s"})
+	wh.dispatcher.Publish("packSizesChanged", json.RawMessage(`[250]`))
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "", want: 1},
+		{query: "?status=pending", want: 1},
+		{query: "?status=delivered", want: 0},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		wh.Deliveries(rr, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries"+tt.query, nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var deliveries []webhooks.Delivery
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &deliveries))
+		assert.Len(t, deliveries, tt.want, tt.query)
+	}
+}
@@ -0,0 +1,164 @@
+// Package openapi serves the OpenAPI 3 document of the HTTP API.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Spec is the OpenAPI document describing every route of the HTTP API.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// methods lists the HTTP methods an OpenAPI path item may describe, in display order.
+var methods = []string{"get", "post", "put", "patch", "delete"}
+
+// Document is the part of the OpenAPI document the docs page shows.
+type Document struct {
+	Info struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+	} `json:"info"`
+	Tags  []Tag                           `json:"tags"`
+	Paths map[string]map[string]Operation `json:"paths"`
+}
+
+// Tag groups related operations.
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Operation is a single method of a path.
+type Operation struct {
+	Method      string              `json:"-"`
+	Path        string              `json:"-"`
+	ID          string              `json:"operationId"`
+	Tags        []string            `json:"tags"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description"`
+	Parameters  []Parameter         `json:"parameters"`
+	RequestBody *RequestBody        `json:"requestBody"`
+	Responses   map[string]Response `json:"responses"`
+	Deprecated  bool                `json:"deprecated"`
+}
+
+// Parameter is a path, query or header parameter.
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Required    bool   `json:"required"`
+	Description string `json:"description"`
+}
+
+// RequestBody lists the media types an operation accepts.
+type RequestBody struct {
+	Content map[string]MediaType `json:"content"`
+}
+
+// Response is one possible response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// MediaType describes the body of one content type.
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// Schema is the outline of a body schema: a reference to a named schema, or the fields of an inline object.
+type Schema struct {
+	Ref        string                     `json:"$ref"`
+	Type       string                     `json:"type"`
+	Properties map[string]json.RawMessage `json:"properties"`
+	Required   []string                   `json:"required"`
+}
+
+// Name returns the name of a referenced schema, or "" for an inline one.
+func (s Schema) Name() string {
+	_, name, _ := strings.Cut(s.Ref, "#/components/schemas/")
+	return name
+}
+
+// Fields returns the property names of an inline object schema in ascending order.
+func (s Schema) Fields() []string {
+	fields := make([]string, 0, len(s.Properties))
+	for field := range s.Properties {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Load parses the embedded document.
+func Load() (Document, error) {
+	var doc Document
+	err := json.Unmarshal(Spec, &doc)
+	return doc, err
+}
+
+// Operations returns the operations of a tag, ordered by path and method.
+func (d Document) Operations(tag string) []Operation {
+	var ops []Operation
+	for path, item := range d.Paths {
+		for _, method := range methods {
+			op, ok := item[method]
+			if !ok || (len(op.Tags) > 0 && op.Tags[0] != tag) {
+				continue
+			}
+			op.Method = strings.ToUpper(method)
+			op.Path = path
+			ops = append(ops, op)
+		}
+	}
+	sort.SliceStable(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return methodIndex(ops[i].Method) < methodIndex(ops[j].Method)
+	})
+	return ops
+}
+
+// StatusCodes returns the documented response codes in ascending order.
+func (op Operation) StatusCodes() []string {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// ContentTypes returns the media types of a body in ascending order.
+func ContentTypes(content map[string]MediaType) []string {
+	types := make([]string, 0, len(content))
+	for contentType := range content {
+		types = append(types, contentType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func methodIndex(method string) int {
+	for i, m := range methods {
+		if strings.EqualFold(m, method) {
+			return i
+		}
+	}
+	return len(methods)
+}
+
+// Handler serves the document as JSON.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write(Spec)
+	})
+}
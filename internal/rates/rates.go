@@ -0,0 +1,212 @@
+// Package rates estimates shipping costs from carrier rate tables, which price
+// a shipment by its destination zone and weight band.
+package rates
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidBand is returned when a rate table has a band without a carrier, zone or
+// positive weight limit, or with a negative price.
+var ErrInvalidBand = errors.New("rate band needs a carrier, a zone, a positive maxWeight and a price that is not negative")
+
+// Band is the price of shipments to a zone weighing up to MaxWeight, and more than
+// the next lighter band of the same zone.
+type Band struct {
+	Zone      string  `json:"zone"`
+	MaxWeight float64 `json:"maxWeight"`
+	Price     float64 `json:"price"`
+}
+
+// Table is the rates of one carrier.
+type Table struct {
+	Carrier string `json:"carrier"`
+	Bands   []Band `json:"bands"`
+}
+
+// Quote is the price a carrier asks for one shipment.
+type Quote struct {
+	Carrier   string  `json:"carrier"`
+	Zone      string  `json:"zone"`
+	MaxWeight float64 `json:"maxWeight"` // Upper limit of the weight band the shipment falls in
+	Price     float64 `json:"price"`
+}
+
+// Quote returns the price of a shipment of the given weight to a zone, from the lightest
+// band the weight fits in. It returns false if the carrier does not serve the zone or
+// the shipment is heavier than its heaviest band.
+func (t Table) Quote(zone string, weight float64) (Quote, bool) {
+	var best *Band
+	for i, band := range t.Bands {
+		if band.Zone != zone || band.MaxWeight < weight {
+			continue
+		}
+		if best == nil || band.MaxWeight < best.MaxWeight {
+			best = &t.Bands[i]
+		}
+	}
+	if best == nil {
+		return Quote{}, false
+	}
+	return Quote{Carrier: t.Carrier, Zone: zone, MaxWeight: best.MaxWeight, Price: best.Price}, true
+}
+
+// Tables is the rate tables of every carrier.
+type Tables []Table
+
+// Quote returns the quote of every carrier that can ship the weight to the zone,
+// cheapest first and by carrier name for equal prices.
+func (ts Tables) Quote(zone string, weight float64) []Quote {
+	quotes := []Quote{}
+	for _, table := range ts {
+		if quote, ok := table.Quote(zone, weight); ok {
+			quotes = append(quotes, quote)
+		}
+	}
+	sort.Slice(quotes, func(i, j int) bool {
+		if quotes[i].Price != quotes[j].Price {
+			return quotes[i].Price < quotes[j].Price
+		}
+		return quotes[i].Carrier < quotes[j].Carrier
+	})
+	return quotes
+}
+
+// LoadDir loads every .csv and .json rate table file in a directory, in name order.
+func LoadDir(dir string) (Tables, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables Tables
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".csv" && ext != ".json") {
+			continue
+		}
+		loaded, err := Load(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, loaded...)
+	}
+	return merge(tables), nil
+}
+
+// Load reads a rate table file. CSV files have a header row naming the columns
+// carrier, zone, maxWeight and price, in any order, and one band per row.
+// JSON files hold a list of tables, each with a carrier and its bands.
+func Load(path string) (Tables, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var tables Tables
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		tables, err = ReadJSON(file)
+	} else {
+		tables, err = ReadCSV(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tables, nil
+}
+
+// ReadJSON reads a list of rate tables.
+func ReadJSON(r io.Reader) (Tables, error) {
+	var tables Tables
+	if err := json.NewDecoder(r).Decode(&tables); err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		for _, band := range table.Bands {
+			if err := validate(table.Carrier, band); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return merge(tables), nil
+}
+
+// ReadCSV reads rate bands with a header row naming the columns carrier, zone, maxWeight and price.
+func ReadCSV(r io.Reader) (Tables, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"carrier", "zone", "maxWeight", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var tables Tables
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		carrier := strings.TrimSpace(record[columns["carrier"]])
+		band := Band{Zone: strings.TrimSpace(record[columns["zone"]])}
+		band.MaxWeight, err = strconv.ParseFloat(strings.TrimSpace(record[columns["maxWeight"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: maxWeight must be a number", line)
+		}
+		band.Price, err = strconv.ParseFloat(strings.TrimSpace(record[columns["price"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: price must be a number", line)
+		}
+		if err := validate(carrier, band); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		tables = append(tables, Table{Carrier: carrier, Bands: []Band{band}})
+	}
+	return merge(tables), nil
+}
+
+func validate(carrier string, band Band) error {
+	if carrier == "" || band.Zone == "" || band.MaxWeight <= 0 || band.Price < 0 {
+		return ErrInvalidBand
+	}
+	return nil
+}
+
+// merge combines the tables of the same carrier, keeping the carriers in the order they first appear.
+func merge(tables Tables) Tables {
+	merged := Tables{}
+	index := map[string]int{}
+	for _, table := range tables {
+		i, ok := index[table.Carrier]
+		if !ok {
+			i = len(merged)
+			index[table.Carrier] = i
+			merged = append(merged, Table{Carrier: table.Carrier})
+		}
+		merged[i].Bands = append(merged[i].Bands, table.Bands...)
+	}
+	return merged
+}
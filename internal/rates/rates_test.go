@@ -0,0 +1,105 @@
+package rates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tables := Tables{
+		{Carrier: "Swift", Bands: []Band{{Zone: "EU", MaxWeight: 5, Price: 8}, {Zone: "EU", MaxWeight: 20, Price: 15}, {Zone: "US", MaxWeight: 10, Price: 30}}},
+		{Carrier: "Cargo", Bands: []Band{{Zone: "EU", MaxWeight: 50, Price: 12}}},
+		{Carrier: "Budget", Bands: []Band{{Zone: "EU", MaxWeight: 10, Price: 12}}},
+	}
+
+	tests := []struct {
+		name   string
+		zone   string
+		weight float64
+		want   []Quote
+	}{
+		{"Lightest band", "EU", 3, []Quote{{"Swift", "EU", 5, 8}, {"Budget", "EU", 10, 12}, {"Cargo", "EU", 50, 12}}},
+		{"Band limit is inclusive", "EU", 10, []Quote{{"Budget", "EU", 10, 12}, {"Cargo", "EU", 50, 12}, {"Swift", "EU", 20, 15}}},
+		{"Heavier than some carriers take", "EU", 30, []Quote{{"Cargo", "EU", 50, 12}}},
+		{"Zone served by one carrier", "US", 1, []Quote{{"Swift", "US", 10, 30}}},
+		{"Unknown zone", "APAC", 1, []Quote{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tables.Quote(tt.zone, tt.weight); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Quote(%s, %v) = %v, want %v", tt.zone, tt.weight, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	csv := "zone,carrier,maxWeight,price\nEU,Swift,5,8\nEU, Swift, 20, 15.5\nUS,Cargo,10,30\n"
+	tables, err := ReadCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+
+	want := Tables{
+		{Carrier: "Swift", Bands: []Band{{Zone: "EU", MaxWeight: 5, Price: 8}, {Zone: "EU", MaxWeight: 20, Price: 15.5}}},
+		{Carrier: "Cargo", Bands: []Band{{Zone: "US", MaxWeight: 10, Price: 30}}},
+	}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("ReadCSV() = %+v, want %+v", tables, want)
+	}
+
+	invalid := []struct {
+		name string
+		csv  string
+		err  string
+	}{
+		{"Missing column", "carrier,zone,price\nSwift,EU,8\n", `missing column "maxWeight"`},
+		{"Not a number", "carrier,zone,maxWeight,price\nSwift,EU,heavy,8\n", "line 2: maxWeight must be a number"},
+		{"Invalid band", "carrier,zone,maxWeight,price\nSwift,EU,5,8\nSwift,,5,8\n", "line 3: " + ErrInvalidBand.Error()},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadCSV(strings.NewReader(tt.csv)); err == nil || err.Error() != tt.err {
+				t.Errorf("Expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a-swift.csv":  "carrier,zone,maxWeight,price\nSwift,EU,5,8\n",
+		"b-cargo.json": `[{"carrier":"Cargo","bands":[{"zone":"EU","maxWeight":50,"price":12}]},{"carrier":"Swift","bands":[{"zone":"US","maxWeight":10,"price":30}]}]`,
+		"notes.txt":    "not a rate table",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tables, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+	want := Tables{
+		{Carrier: "Swift", Bands: []Band{{Zone: "EU", MaxWeight: 5, Price: 8}, {Zone: "US", MaxWeight: 10, Price: 30}}},
+		{Carrier: "Cargo", Bands: []Band{{Zone: "EU", MaxWeight: 50, Price: 12}}},
+	}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("LoadDir() = %+v, want %+v", tables, want)
+	}
+
+	// Errors name the file
+	bad := filepath.Join(dir, "c-bad.json")
+	os.WriteFile(bad, []byte(`[{"carrier":"Bad","bands":[{"zone":"EU","maxWeight":0,"price":1}]}]`), 0o644)
+	if _, err := LoadDir(dir); !errors.Is(err, ErrInvalidBand) || !strings.HasPrefix(err.Error(), bad) {
+		t.Errorf("Expected ErrInvalidBand for %s, got %v", bad, err)
+	}
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// ItemUnit is the unit of the smallest packaging level: a single item.
+const ItemUnit = "item"
+
+// ErrLevelExists is returned when a packaging level name or its pack size already belongs to a level.
+var ErrLevelExists = fmt.Errorf("packaging level already exists")
+
+// ErrLevelNotFound is returned when a packaging level, or the unit of a new one, does not exist.
+var ErrLevelNotFound = fmt.Errorf("packaging level not found")
+
+// ErrLevelInUse is returned when removing a packaging level that another level contains.
+var ErrLevelInUse = fmt.Errorf("packaging level is contained in another level")
+
+// PackagingLevel names a pack size as a number of units of a smaller level,
+// such as a case of 12 bottles or a pallet of 40 cases.
+type PackagingLevel struct {
+	Name     string `json:"name"`     // Name of the level, e.g. "pallet"
+	Quantity int    `json:"quantity"` // Number of units in one of this level
+	Unit     string `json:"unit"`     // Level contained, or ItemUnit for single items
+	Size     int    `json:"size"`     // Number of items in one of this level, which is its pack size
+}
+
+// level returns the packaging level with the given name. The caller must hold the lock.
+func (pc *packCache) level(name string) (PackagingLevel, bool) {
+	for _, level := range pc.levels {
+		if level.Name == name {
+			return level, true
+		}
+	}
+	return PackagingLevel{}, false
+}
+
+// levelOfSize returns the packaging level of a pack size. The caller must hold the lock.
+func (pc *packCache) levelOfSize(size int) (PackagingLevel, bool) {
+	for _, level := range pc.levels {
+		if level.Size == size {
+			return level, true
+		}
+	}
+	return PackagingLevel{}, false
+}
+
+// contained reports whether another level is made of the named level. The caller must hold the lock.
+func (pc *packCache) contained(name string) bool {
+	return slices.ContainsFunc(pc.levels, func(level PackagingLevel) bool {
+		return level.Unit == name
+	})
+}
+
+// dropLevel forgets the level of a pack size, if it has one. The caller must hold the lock.
+func (pc *packCache) dropLevel(size int) {
+	pc.levels = slices.DeleteFunc(pc.levels, func(level PackagingLevel) bool {
+		return level.Size == size
+	})
+}
+
+// AddLevel defines a packaging level of quantity units. Its pack size is added to the
+// catalogue unless it is there already, in which case the existing size gets the name.
+// It returns ErrLevelExists if the name or the size already belongs to a level,
+// and ErrLevelNotFound if the unit is neither ItemUnit nor an existing level.
+func (pr *packageRepository) AddLevel(name string, quantity int, unit string) error {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+
+	if _, ok := pr.cache.level(name); ok || name == ItemUnit {
+		return ErrLevelExists
+	}
+	unitSize := 1
+	if unit != ItemUnit {
+		contained, ok := pr.cache.level(unit)
+		if !ok {
+			return ErrLevelNotFound
+		}
+		unitSize = contained.Size
+	}
+
+	level := PackagingLevel{Name: name, Quantity: quantity, Unit: unit, Size: quantity * unitSize}
+	if _, ok := pr.cache.levelOfSize(level.Size); ok {
+		return ErrLevelExists
+	}
+
+	change := fmt.Sprintf("named %d %s (%d x %s)", level.Size, name, quantity, unit)
+	if err := pr.cache.insert(level.Size); err == nil {
+		change = fmt.Sprintf("added %s (%d x %s, %d items)", name, quantity, unit, level.Size)
+	}
+	pr.cache.levels = append(pr.cache.levels, level)
+	sort.Slice(pr.cache.levels, func(i, j int) bool {
+		return pr.cache.levels[i].Size > pr.cache.levels[j].Size
+	})
+	pr.cache.record(change)
+	return nil
+}
+
+// RemoveLevel removes a packaging level together with its pack size.
+// It returns ErrLevelNotFound if there is no such level and ErrLevelInUse if another level contains it.
+func (pr *packageRepository) RemoveLevel(name string) error {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+
+	level, ok := pr.cache.level(name)
+	if !ok {
+		return ErrLevelNotFound
+	}
+	if pr.cache.contained(name) {
+		return ErrLevelInUse
+	}
+
+	pr.cache.remove(level.Size)
+	pr.cache.record(fmt.Sprintf("removed %s (%d)", name, level.Size))
+	return nil
+}
+
+// GetLevels returns a copy of the packaging levels, largest first.
+func (pr *packageRepository) GetLevels() []PackagingLevel {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+	return slices.Clone(pr.cache.levels)
+}
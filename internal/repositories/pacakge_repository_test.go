@@ -3,6 +3,7 @@ package repositories
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestPackageRepository(t *testing.T) {
@@ -42,6 +43,37 @@ func TestPackageRepository(t *testing.T) {
 		}
 	})
 
+	t.Run("Remove", func(t *testing.T) {
+		repo := NewPackageRepository()
+
+		// Add some sizes
+		sizes := []int{500, 250, 1000}
+		for _, size := range sizes {
+			err := repo.Add(size)
+			if err != nil {
+				t.Errorf("Failed to add size %d: %v", size, err)
+			}
+		}
+
+		// Remove one of them
+		err := repo.Remove(500)
+		if err != nil {
+			t.Errorf("Failed to remove size 500: %v", err)
+		}
+
+		expected := []int{1000, 250}
+		actual := repo.GetSizes()
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("GetSizes() = %v, want %v", actual, expected)
+		}
+
+		// Try to remove it again
+		err = repo.Remove(500)
+		if err != ErrSizeNotFound {
+			t.Errorf("Expected ErrSizeNotFound, got %v", err)
+		}
+	})
+
 	t.Run("DeleteAll", func(t *testing.T) {
 		repo := NewPackageRepository()
 
@@ -63,4 +95,215 @@ func TestPackageRepository(t *testing.T) {
 			t.Errorf("Expected empty repository after DeleteAll, got %v", actual)
 		}
 	})
+
+	t.Run("Rules", func(t *testing.T) {
+		repo := NewPackageRepository()
+		repo.Add(250)
+		repo.Add(500)
+
+		// Rules can only be set on existing sizes
+		err := repo.SetRule(1000, PackRule{MaxCount: 1})
+		if err != ErrSizeNotFound {
+			t.Errorf("Expected ErrSizeNotFound, got %v", err)
+		}
+
+		repo.SetRule(250, PackRule{MaxCount: 2})
+		repo.SetRule(500, PackRule{MinCount: 1})
+
+		expected := map[int]PackRule{250: {MaxCount: 2}, 500: {MinCount: 1}}
+		if actual := repo.GetRules(); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("GetRules() = %v, want %v", actual, expected)
+		}
+
+		// A zero rule clears the restriction, and removing a size drops its rule
+		repo.SetRule(250, PackRule{})
+		repo.Remove(500)
+		if actual := repo.GetRules(); len(actual) != 0 {
+			t.Errorf("Expected no rules, got %v", actual)
+		}
+	})
+	t.Run("Versions and Restore", func(t *testing.T) {
+		repo := NewPackageRepository().(*packageRepository)
+		start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+		tick := 0
+		repo.cache.now = func() time.Time {
+			tick++
+			return start.Add(time.Duration(tick) * time.Minute)
+		}
+
+		repo.Add(250)
+		repo.Add(500)
+		repo.SetRule(500, PackRule{MaxCount: 1})
+		repo.DeleteAll()
+
+		versions := repo.Versions()
+		var changes []string
+		for i, v := range versions {
+			if v.ID != i+1 {
+				t.Errorf("Expected version %d, got %d", i+1, v.ID)
+			}
+			changes = append(changes, v.Change)
+		}
+		expected := []string{"created", "added 250", "added 500", "set rule for 500", "cleared"}
+		if !reflect.DeepEqual(changes, expected) {
+			t.Errorf("Changes = %v, want %v", changes, expected)
+		}
+		if !versions[4].CreatedAt.Equal(start.Add(4 * time.Minute)) {
+			t.Errorf("Unexpected timestamp %v", versions[4].CreatedAt)
+		}
+
+		// Versions are immutable copies
+		versions[3].Sizes[0] = 1
+		if v, _ := repo.Version(4); !reflect.DeepEqual(v.Sizes, []int{500, 250}) || v.Rules[500].MaxCount != 1 {
+			t.Errorf("Unexpected version 4: %+v", v)
+		}
+
+		if err := repo.Restore(4); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if sizes := repo.GetSizes(); !reflect.DeepEqual(sizes, []int{500, 250}) {
+			t.Errorf("GetSizes() = %v after restore", sizes)
+		}
+		if rules := repo.GetRules(); rules[500].MaxCount != 1 {
+			t.Errorf("GetRules() = %v after restore", rules)
+		}
+		if current := repo.CurrentVersion(); current.ID != 6 || current.Change != "rolled back to version 4" {
+			t.Errorf("Unexpected current version %+v", current)
+		}
+
+		if err := repo.Restore(7); err != ErrVersionNotFound {
+			t.Errorf("Expected ErrVersionNotFound, got %v", err)
+		}
+		if _, err := repo.Version(0); err != ErrVersionNotFound {
+			t.Errorf("Expected ErrVersionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Version limit", func(t *testing.T) {
+		repo := NewPackageRepository(WithMaxVersions(3))
+		for size := 1; size <= 5; size++ {
+			repo.Add(size)
+		}
+
+		// The oldest versions are dropped and the IDs keep counting up
+		var ids []int
+		for _, v := range repo.Versions() {
+			ids = append(ids, v.ID)
+		}
+		if !reflect.DeepEqual(ids, []int{4, 5, 6}) {
+			t.Errorf("Expected versions [4 5 6], got %v", ids)
+		}
+		if _, err := repo.Version(3); err != ErrVersionNotFound {
+			t.Errorf("Expected ErrVersionNotFound for a dropped version, got %v", err)
+		}
+		if err := repo.Restore(4); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if current := repo.CurrentVersion(); current.ID != 7 || !reflect.DeepEqual(current.Sizes, []int{3, 2, 1}) {
+			t.Errorf("Unexpected current version %+v", current)
+		}
+	})
+	t.Run("Schedules", func(t *testing.T) {
+		repo := NewPackageRepository()
+		monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+		repo.Add(250)
+		repo.Add(500)
+		if err := repo.AddScheduled(750, Schedule{EffectiveFrom: monday}); err != nil {
+			t.Fatalf("AddScheduled failed: %v", err)
+		}
+		if err := repo.SetSchedule(250, Schedule{EffectiveUntil: monday}); err != nil {
+			t.Fatalf("SetSchedule failed: %v", err)
+		}
+
+		if sizes := repo.GetSizes(); !reflect.DeepEqual(sizes, []int{750, 500, 250}) {
+			t.Errorf("GetSizes() = %v", sizes)
+		}
+		if sizes := repo.GetSizesAt(monday.Add(-time.Hour)); !reflect.DeepEqual(sizes, []int{500, 250}) {
+			t.Errorf("GetSizesAt(before) = %v", sizes)
+		}
+		if sizes := repo.GetSizesAt(monday); !reflect.DeepEqual(sizes, []int{750, 500}) {
+			t.Errorf("GetSizesAt(monday) = %v", sizes)
+		}
+		if current := repo.CurrentVersion(); current.Change != "scheduled 250 until 2024-03-04" {
+			t.Errorf("Unexpected change %q", current.Change)
+		}
+
+		if err := repo.AddScheduled(750, Schedule{}); err != ErrSizeAlreadyExists {
+			t.Errorf("Expected ErrSizeAlreadyExists, got %v", err)
+		}
+		if err := repo.SetSchedule(1000, Schedule{EffectiveFrom: monday}); err != ErrSizeNotFound {
+			t.Errorf("Expected ErrSizeNotFound, got %v", err)
+		}
+
+		// A zero schedule and removing a size both drop the window
+		repo.SetSchedule(250, Schedule{})
+		repo.Remove(750)
+		if schedules := repo.GetSchedules(); len(schedules) != 0 {
+			t.Errorf("Expected no schedules, got %v", schedules)
+		}
+	})
+
+	t.Run("Packaging levels", func(t *testing.T) {
+		repo := NewPackageRepository()
+		repo.Add(12)
+
+		// 12 is already a size, so naming it as a pack does not add a size
+		if err := repo.AddLevel("pack", 12, ItemUnit); err != nil {
+			t.Fatalf("AddLevel failed: %v", err)
+		}
+		if err := repo.AddLevel("pallet", 40, "pack"); err != nil {
+			t.Fatalf("AddLevel failed: %v", err)
+		}
+		if sizes := repo.GetSizes(); !reflect.DeepEqual(sizes, []int{480, 12}) {
+			t.Errorf("GetSizes() = %v", sizes)
+		}
+		expected := []PackagingLevel{
+			{Name: "pallet", Quantity: 40, Unit: "pack", Size: 480},
+			{Name: "pack", Quantity: 12, Unit: ItemUnit, Size: 12},
+		}
+		if levels := repo.GetLevels(); !reflect.DeepEqual(levels, expected) {
+			t.Errorf("GetLevels() = %+v", levels)
+		}
+		if current := repo.CurrentVersion(); current.Change != "added pallet (40 x pack, 480 items)" || !reflect.DeepEqual(current.Levels, expected) {
+			t.Errorf("Unexpected version %+v", current)
+		}
+
+		if err := repo.AddLevel("pack", 6, ItemUnit); err != ErrLevelExists {
+			t.Errorf("Expected ErrLevelExists, got %v", err)
+		}
+		if err := repo.AddLevel("dozen", 12, ItemUnit); err != ErrLevelExists {
+			t.Errorf("Expected ErrLevelExists for a named size, got %v", err)
+		}
+		if err := repo.AddLevel("crate", 4, "case"); err != ErrLevelNotFound {
+			t.Errorf("Expected ErrLevelNotFound, got %v", err)
+		}
+
+		// The pack is part of the pallet, so it cannot go first
+		if err := repo.RemoveLevel("pack"); err != ErrLevelInUse {
+			t.Errorf("Expected ErrLevelInUse, got %v", err)
+		}
+		if err := repo.Remove(12); err != ErrLevelInUse {
+			t.Errorf("Expected ErrLevelInUse, got %v", err)
+		}
+		if err := repo.RemoveLevel("pallet"); err != nil {
+			t.Fatalf("RemoveLevel failed: %v", err)
+		}
+		if err := repo.Remove(12); err != nil {
+			t.Fatalf("Remove failed: %v", err)
+		}
+		if levels := repo.GetLevels(); len(levels) != 0 {
+			t.Errorf("Expected no levels, got %+v", levels)
+		}
+		if err := repo.RemoveLevel("pack"); err != ErrLevelNotFound {
+			t.Errorf("Expected ErrLevelNotFound, got %v", err)
+		}
+
+		if err := repo.Restore(4); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if levels := repo.GetLevels(); !reflect.DeepEqual(levels, expected) {
+			t.Errorf("GetLevels() after restore = %+v", levels)
+		}
+	})
 }
@@ -3,17 +3,38 @@ package repositories
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"sync"
+	"time"
 )
 
 // ErrSizeAlreadyExists is returned when attempting to add a package size that already exists.
 var ErrSizeAlreadyExists = fmt.Errorf("pack size already exists")
 
+// ErrSizeNotFound is returned when attempting to remove a package size that does not exist.
+var ErrSizeNotFound = fmt.Errorf("pack size not found")
+
+// PackRule restricts how often a pack size may be used in a single order.
+// The zero value places no restriction.
+type PackRule struct {
+	MinCount int  `json:"minCount"` // Minimum number of packs of this size, 0 for none
+	MaxCount int  `json:"maxCount"` // Maximum number of packs of this size, 0 for unlimited
+	Disabled bool `json:"disabled"` // Whether the size is excluded from calculations
+}
+
 // packCache represents the in-memory storage for pack sizes.
 type packCache struct {
 	packSizes []int
+	rules     map[int]PackRule
+	schedules map[int]Schedule
+	levels    []PackagingLevel
+	versions  []CatalogueVersion
+	now       func() time.Time
 	mu        sync.Mutex
+
+	maxVersions int // Versions kept in the history, or every version when below 1
 }
 
 // PackageRepository defines the interface for managing package sizes.
@@ -22,11 +43,64 @@ type PackageRepository interface {
 	// It returns an error if the size already exists.
 	Add(size int) error
 
+	// Remove deletes a pack size from the repository.
+	// It returns an error if the size does not exist.
+	Remove(size int) error
+
 	// DeleteAll removes all pack sizes from the repository.
 	DeleteAll()
 
-	// GetSizes returns a slice of all pack sizes in descending order.
+	// GetSizes returns a slice of all pack sizes in descending order,
+	// including those that are scheduled but not available yet or any more.
 	GetSizes() []int
+
+	// GetSizesAt returns the pack sizes available at the given time, in descending order.
+	GetSizesAt(t time.Time) []int
+
+	// AddScheduled inserts a new pack size that is only available within the schedule.
+	// It returns an error if the size already exists.
+	AddScheduled(size int, schedule Schedule) error
+
+	// SetSchedule replaces the availability window of an existing pack size.
+	// It returns an error if the size does not exist.
+	SetSchedule(size int, schedule Schedule) error
+
+	// GetSchedules returns the schedules of all pack sizes that have one.
+	GetSchedules() map[int]Schedule
+
+	// SetRule stores the usage rule for an existing pack size.
+	// It returns an error if the size does not exist.
+	SetRule(size int, rule PackRule) error
+
+	// GetRules returns the rules of all pack sizes that have one.
+	GetRules() map[int]PackRule
+
+	// AddLevel defines a packaging level of quantity units of another level or of single items,
+	// adding its pack size if the catalogue does not have it yet.
+	// It returns an error if the name or size already belongs to a level or the unit does not exist.
+	AddLevel(name string, quantity int, unit string) error
+
+	// RemoveLevel removes a packaging level together with its pack size.
+	// It returns an error if the level does not exist or another level contains it.
+	RemoveLevel(name string) error
+
+	// GetLevels returns the packaging levels, largest first.
+	GetLevels() []PackagingLevel
+
+	// Versions returns the catalogue versions kept in the history, oldest first.
+	// Each change to the sizes or rules creates a new version.
+	Versions() []CatalogueVersion
+
+	// Version returns a single catalogue version.
+	// It returns an error if the version does not exist or is no longer kept.
+	Version(id int) (CatalogueVersion, error)
+
+	// CurrentVersion returns the latest catalogue version.
+	CurrentVersion() CatalogueVersion
+
+	// Restore replaces the catalogue with an earlier version, recording a new version.
+	// It returns an error if the version does not exist or is no longer kept.
+	Restore(id int) error
 }
 
 // packageRepository implements the PackageRepository interface.
@@ -34,11 +108,34 @@ type packageRepository struct {
 	cache *packCache
 }
 
+// DefaultMaxVersions is the number of catalogue versions a repository keeps unless
+// configured with WithMaxVersions.
+const DefaultMaxVersions = 1000
+
+// Option configures a PackageRepository.
+type Option func(*packCache)
+
+// WithMaxVersions keeps at most n catalogue versions, dropping the oldest once a change
+// records more. A limit below 1 keeps every version, so the history grows with every change.
+func WithMaxVersions(n int) Option {
+	return func(pc *packCache) {
+		pc.maxVersions = n
+	}
+}
+
 // NewPackageRepository creates and returns a new instance of PackageRepository.
-func NewPackageRepository() PackageRepository {
+func NewPackageRepository(opts ...Option) PackageRepository {
 	pc := packCache{
-		packSizes: []int{},
+		packSizes:   []int{},
+		rules:       map[int]PackRule{},
+		schedules:   map[int]Schedule{},
+		now:         time.Now,
+		maxVersions: DefaultMaxVersions,
 	}
+	for _, opt := range opts {
+		opt(&pc)
+	}
+	pc.record("created")
 	return &packageRepository{
 		cache: &pc,
 	}
@@ -51,29 +148,75 @@ func (pr *packageRepository) Add(size int) error {
 	pr.cache.mu.Lock()
 	defer pr.cache.mu.Unlock()
 
+	if err := pr.cache.insert(size); err != nil {
+		return err
+	}
+	pr.cache.record(fmt.Sprintf("added %d", size))
+
+	return nil
+}
+
+// insert places a new size in descending order. The caller must hold the lock.
+// It returns ErrSizeAlreadyExists if the size is already in the repository.
+func (pc *packCache) insert(size int) error {
 	// Find the correct position for the new size
-	index := sort.Search(len(pr.cache.packSizes), func(i int) bool {
-		return pr.cache.packSizes[i] <= size
+	index := sort.Search(len(pc.packSizes), func(i int) bool {
+		return pc.packSizes[i] <= size
 	})
 
 	// Check if size already exists
-	if index < len(pr.cache.packSizes) && pr.cache.packSizes[index] == size {
+	if index < len(pc.packSizes) && pc.packSizes[index] == size {
 		return ErrSizeAlreadyExists
 	}
 
 	// Insert the new size at the correct position
-	pr.cache.packSizes = append(pr.cache.packSizes, 0)
-	copy(pr.cache.packSizes[index+1:], pr.cache.packSizes[index:])
-	pr.cache.packSizes[index] = size
+	pc.packSizes = append(pc.packSizes, 0)
+	copy(pc.packSizes[index+1:], pc.packSizes[index:])
+	pc.packSizes[index] = size
 
 	return nil
 }
 
+// Remove deletes a pack size from the repository, together with its packaging level.
+// It returns ErrSizeNotFound if the size is not in the repository and
+// ErrLevelInUse if the size is a packaging level that another level contains.
+func (pr *packageRepository) Remove(size int) error {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+
+	if !slices.Contains(pr.cache.packSizes, size) {
+		return ErrSizeNotFound
+	}
+	if level, ok := pr.cache.levelOfSize(size); ok && pr.cache.contained(level.Name) {
+		return ErrLevelInUse
+	}
+
+	pr.cache.remove(size)
+	pr.cache.record(fmt.Sprintf("removed %d", size))
+
+	return nil
+}
+
+// remove deletes an existing size with its rule, schedule and level. The caller must hold the lock.
+func (pc *packCache) remove(size int) {
+	index := sort.Search(len(pc.packSizes), func(i int) bool {
+		return pc.packSizes[i] <= size
+	})
+	pc.packSizes = append(pc.packSizes[:index], pc.packSizes[index+1:]...)
+	delete(pc.rules, size)
+	delete(pc.schedules, size)
+	pc.dropLevel(size)
+}
+
 // DeleteAll removes all pack sizes from the repository.
 func (pr *packageRepository) DeleteAll() {
 	pr.cache.mu.Lock()
 	defer pr.cache.mu.Unlock()
 	pr.cache.packSizes = []int{}
+	pr.cache.rules = map[int]PackRule{}
+	pr.cache.schedules = map[int]Schedule{}
+	pr.cache.levels = nil
+	pr.cache.record("cleared")
 }
 
 // GetSizes returns a copy of all pack sizes in descending order.
@@ -82,3 +225,30 @@ func (pr *packageRepository) GetSizes() []int {
 	defer pr.cache.mu.Unlock()
 	return append([]int{}, pr.cache.packSizes...)
 }
+
+// SetRule stores the usage rule for a pack size. A zero rule removes any restriction.
+// It returns ErrSizeNotFound if the size is not in the repository.
+func (pr *packageRepository) SetRule(size int, rule PackRule) error {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+
+	if !slices.Contains(pr.cache.packSizes, size) {
+		return ErrSizeNotFound
+	}
+
+	if rule == (PackRule{}) {
+		delete(pr.cache.rules, size)
+	} else {
+		pr.cache.rules[size] = rule
+	}
+	pr.cache.record(fmt.Sprintf("set rule for %d", size))
+
+	return nil
+}
+
+// GetRules returns a copy of the rules of all pack sizes that have one.
+func (pr *packageRepository) GetRules() map[int]PackRule {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+	return maps.Clone(pr.cache.rules)
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule limits when a pack size is available. A zero time leaves that end of the window open.
+type Schedule struct {
+	EffectiveFrom  time.Time `json:"effectiveFrom"`  // First moment the size may be used
+	EffectiveUntil time.Time `json:"effectiveUntil"` // Moment the size stops being available
+}
+
+// IsZero reports whether the schedule places no restriction.
+func (s Schedule) IsZero() bool {
+	return s.EffectiveFrom.IsZero() && s.EffectiveUntil.IsZero()
+}
+
+// Equal reports whether two schedules describe the same window.
+func (s Schedule) Equal(other Schedule) bool {
+	return s.EffectiveFrom.Equal(other.EffectiveFrom) && s.EffectiveUntil.Equal(other.EffectiveUntil)
+}
+
+// ActiveAt reports whether the window contains t.
+func (s Schedule) ActiveAt(t time.Time) bool {
+	return (s.EffectiveFrom.IsZero() || !t.Before(s.EffectiveFrom)) &&
+		(s.EffectiveUntil.IsZero() || t.Before(s.EffectiveUntil))
+}
+
+// SizesAt returns the sizes of the version that are available at t, in descending order.
+func (v CatalogueVersion) SizesAt(t time.Time) []int {
+	sizes := make([]int, 0, len(v.Sizes))
+	for _, size := range v.Sizes {
+		if schedule, ok := v.Schedules[size]; !ok || schedule.ActiveAt(t) {
+			sizes = append(sizes, size)
+		}
+	}
+	return sizes
+}
+
+// RulesAt returns the rules of the sizes that are available at t.
+func (v CatalogueVersion) RulesAt(t time.Time) map[int]PackRule {
+	rules := maps.Clone(v.Rules)
+	maps.DeleteFunc(rules, func(size int, _ PackRule) bool {
+		schedule, ok := v.Schedules[size]
+		return ok && !schedule.ActiveAt(t)
+	})
+	return rules
+}
+
+// AddScheduled inserts a new pack size that is only available within the schedule.
+// It returns ErrSizeAlreadyExists if the size is already in the repository.
+func (pr *packageRepository) AddScheduled(size int, schedule Schedule) error {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+
+	if err := pr.cache.insert(size); err != nil {
+		return err
+	}
+	if !schedule.IsZero() {
+		pr.cache.schedules[size] = schedule
+	}
+	pr.cache.record(describeAdd(size, schedule))
+	return nil
+}
+
+// SetSchedule replaces the availability window of a pack size. A zero schedule makes it always available.
+// It returns ErrSizeNotFound if the size is not in the repository.
+func (pr *packageRepository) SetSchedule(size int, schedule Schedule) error {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+
+	if !slices.Contains(pr.cache.packSizes, size) {
+		return ErrSizeNotFound
+	}
+
+	if schedule.IsZero() {
+		delete(pr.cache.schedules, size)
+	} else {
+		pr.cache.schedules[size] = schedule
+	}
+	pr.cache.record(describeSchedule(size, schedule))
+	return nil
+}
+
+// GetSchedules returns a copy of the schedules of all pack sizes that have one.
+func (pr *packageRepository) GetSchedules() map[int]Schedule {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+	return maps.Clone(pr.cache.schedules)
+}
+
+// GetSizesAt returns the pack sizes available at t, in descending order.
+func (pr *packageRepository) GetSizesAt(t time.Time) []int {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+	return pr.cache.versions[len(pr.cache.versions)-1].SizesAt(t)
+}
+
+// describeAdd names the change that adds a size, including its window.
+func describeAdd(size int, schedule Schedule) string {
+	if schedule.IsZero() {
+		return "added " + strconv.Itoa(size)
+	}
+	return "added " + strconv.Itoa(size) + " " + describeWindow(schedule)
+}
+
+// describeSchedule names the change that reschedules a size.
+func describeSchedule(size int, schedule Schedule) string {
+	if schedule.IsZero() {
+		return "cleared schedule for " + strconv.Itoa(size)
+	}
+	return "scheduled " + strconv.Itoa(size) + " " + describeWindow(schedule)
+}
+
+// describeWindow formats a schedule as "from 2024-03-04 until 2024-06-01".
+func describeWindow(schedule Schedule) string {
+	var parts []string
+	if !schedule.EffectiveFrom.IsZero() {
+		parts = append(parts, "from "+schedule.EffectiveFrom.Format(time.DateOnly))
+	}
+	if !schedule.EffectiveUntil.IsZero() {
+		parts = append(parts, "until "+schedule.EffectiveUntil.Format(time.DateOnly))
+	}
+	return strings.Join(parts, " ")
+}
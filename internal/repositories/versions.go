@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// ErrVersionNotFound is returned when a catalogue version does not exist.
+var ErrVersionNotFound = fmt.Errorf("catalogue version not found")
+
+// CatalogueVersion is an immutable snapshot of the catalogue taken after every change.
+type CatalogueVersion struct {
+	ID        int              `json:"id"`        // Sequential version number, starting at 1 for the empty catalogue
+	CreatedAt time.Time        `json:"createdAt"` // When the change was made
+	Change    string           `json:"change"`    // Description of the change that created the version
+	Sizes     []int            `json:"sizes"`     // Pack sizes in descending order
+	Rules     map[int]PackRule `json:"rules"`     // Rules of the pack sizes that have one
+	Schedules map[int]Schedule `json:"schedules"` // Availability windows of the pack sizes that have one
+	Levels    []PackagingLevel `json:"levels"`    // Packaging levels, largest first
+}
+
+// record appends a version for the current state, dropping the oldest versions beyond
+// maxVersions. Version IDs keep counting up. The caller must hold the lock.
+func (pc *packCache) record(change string) {
+	id := 1
+	if len(pc.versions) > 0 {
+		id = pc.versions[len(pc.versions)-1].ID + 1
+	}
+	pc.versions = append(pc.versions, CatalogueVersion{
+		ID:        id,
+		CreatedAt: pc.now(),
+		Change:    change,
+		Sizes:     slices.Clone(pc.packSizes),
+		Rules:     maps.Clone(pc.rules),
+		Schedules: maps.Clone(pc.schedules),
+		Levels:    slices.Clone(pc.levels),
+	})
+	if pc.maxVersions > 0 && len(pc.versions) > pc.maxVersions {
+		pc.versions = slices.Delete(pc.versions, 0, len(pc.versions)-pc.maxVersions)
+	}
+}
+
+// version returns the version with the given ID, if the history still has it.
+// The caller must hold the lock.
+func (pc *packCache) version(id int) (CatalogueVersion, bool) {
+	i := id - pc.versions[0].ID
+	if i < 0 || i >= len(pc.versions) {
+		return CatalogueVersion{}, false
+	}
+	return pc.versions[i], true
+}
+
+// clone returns a copy of a version that shares no memory with the history.
+func (v CatalogueVersion) clone() CatalogueVersion {
+	v.Sizes = slices.Clone(v.Sizes)
+	v.Rules = maps.Clone(v.Rules)
+	v.Schedules = maps.Clone(v.Schedules)
+	v.Levels = slices.Clone(v.Levels)
+	return v
+}
+
+// Versions returns the catalogue versions kept in the history, oldest first.
+func (pr *packageRepository) Versions() []CatalogueVersion {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+
+	versions := make([]CatalogueVersion, len(pr.cache.versions))
+	for i, v := range pr.cache.versions {
+		versions[i] = v.clone()
+	}
+	return versions
+}
+
+// Version returns a single catalogue version.
+// It returns ErrVersionNotFound if there is no version with the given ID or it is no longer kept.
+func (pr *packageRepository) Version(id int) (CatalogueVersion, error) {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+
+	v, ok := pr.cache.version(id)
+	if !ok {
+		return CatalogueVersion{}, ErrVersionNotFound
+	}
+	return v.clone(), nil
+}
+
+// CurrentVersion returns the latest catalogue version, which matches the current sizes and rules.
+func (pr *packageRepository) CurrentVersion() CatalogueVersion {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+	return pr.cache.versions[len(pr.cache.versions)-1].clone()
+}
+
+// Restore replaces the sizes and rules with those of an earlier version.
+// The history is kept: restoring creates a new version.
+// It returns ErrVersionNotFound if there is no version with the given ID or it is no longer kept.
+func (pr *packageRepository) Restore(id int) error {
+	pr.cache.mu.Lock()
+	defer pr.cache.mu.Unlock()
+
+	v, ok := pr.cache.version(id)
+	if !ok {
+		return ErrVersionNotFound
+	}
+	pr.cache.packSizes = slices.Clone(v.Sizes)
+	pr.cache.rules = maps.Clone(v.Rules)
+	pr.cache.schedules = maps.Clone(v.Schedules)
+	pr.cache.levels = slices.Clone(v.Levels)
+	pr.cache.record(fmt.Sprintf("rolled back to version %d", id))
+	return nil
+}
@@ -4,34 +4,167 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"path/filepath"
 
-	// "Ship_Manager/cmd/web"
+	"Ship_Manager/cmd/web"
+	"Ship_Manager/internal/grpcapi"
 	"Ship_Manager/internal/handlers"
-	"Ship_Manager/internal/repositories"
+	"Ship_Manager/internal/openapi"
 	"Ship_Manager/internal/services"
+	"Ship_Manager/internal/tenants"
+	"Ship_Manager/internal/webhooks"
+
+	"google.golang.org/grpc"
 )
 
+// packageRoutes are the routes served by each tenant's PackageHandler. The patterns
+// name the method, so other methods get 405 Method Not Allowed with an Allow header.
+var packageRoutes = map[string]func(*handlers.PackageHandler, http.ResponseWriter, *http.Request){
+	"GET /calculator":                 (*handlers.PackageHandler).CalculatorIndex,
+	"GET /pack-sizes":                 (*handlers.PackageHandler).PackSizes,
+	"POST /pack-sizes":                (*handlers.PackageHandler).AddPack,
+	"DELETE /pack-sizes":              (*handlers.PackageHandler).ClearPacks,
+	"DELETE /pack-sizes/{size}":       (*handlers.PackageHandler).RemovePack,
+	"GET /pack-schedules":             (*handlers.PackageHandler).PackSchedules,
+	"GET /pack-rules":                 (*handlers.PackageHandler).PackRules,
+	"POST /pack-rules":                (*handlers.PackageHandler).SetPackRule,
+	"GET /packaging-levels":           (*handlers.PackageHandler).PackagingLevels,
+	"POST /packaging-levels":          (*handlers.PackageHandler).AddPackagingLevel,
+	"DELETE /packaging-levels/{name}": (*handlers.PackageHandler).RemovePackagingLevel,
+	"POST /calculate":                 (*handlers.PackageHandler).Calculate,
+	"POST /calculate-order":           (*handlers.PackageHandler).CalculateOrder,
+	"POST /what-if":                   (*handlers.PackageHandler).WhatIf,
+	"POST /optimize":                  (*handlers.PackageHandler).Optimize,
+	"POST /load-containers":           (*handlers.PackageHandler).LoadContainers,
+	"GET /packing-slip":               (*handlers.PackageHandler).PackingSlip,
+	"POST /packing-slip":              (*handlers.PackageHandler).PackingSlip,
+	"GET /versions":                   (*handlers.PackageHandler).Versions,
+	"GET /versions/diff":              (*handlers.PackageHandler).VersionDiff,
+	"POST /rollback":                  (*handlers.PackageHandler).Rollback,
+	"GET /events":                     (*handlers.PackageHandler).Events,
+
+	// Form endpoints kept for clients written before the pack-sizes routes
+	"POST /add-pack":    (*handlers.PackageHandler).AddPack,
+	"POST /remove-pack": (*handlers.PackageHandler).RemovePack,
+	"POST /clear-packs": (*handlers.PackageHandler).ClearPacks,
+}
+
+// webhookRoutes are the routes served by each tenant's WebhookHandler.
+var webhookRoutes = map[string]func(*handlers.WebhookHandler, http.ResponseWriter, *http.Request){
+	"GET /webhooks":            (*handlers.WebhookHandler).Webhooks,
+	"POST /webhooks":           (*handlers.WebhookHandler).Subscribe,
+	"DELETE /webhooks":         (*handlers.WebhookHandler).Unsubscribe,
+	"DELETE /webhooks/{id}":    (*handlers.WebhookHandler).Unsubscribe,
+	"GET /webhooks/deliveries": (*handlers.WebhookHandler).Deliveries,
+}
+
+// serverRoutes are the routes served by the server itself, the same for every tenant.
+// "GET /assets/" is a prefix, serving every path below it.
+func (s *Server) serverRoutes() map[string]http.Handler {
+	return map[string]http.Handler{
+		"GET /{$}":          http.HandlerFunc(s.HelloWorldHandler),
+		"GET /assets/":      web.AssetHandler(),
+		"GET /openapi.json": openapi.Handler(),
+		"GET /docs":         http.HandlerFunc(handlers.Docs),
+	}
+}
+
+// RegisterRoutes builds the HTTP routes, wrapped in the server's middleware.
 func (s *Server) RegisterRoutes() http.Handler {
-	repository := repositories.NewPackageRepository()
-	service := services.NewPackageService(repository)
-	ph := handlers.NewPackageHandler(service)
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", s.HelloWorldHandler)
+	for pattern, handler := range s.serverRoutes() {
+		mux.Handle(pattern, handler)
+	}
 
-	mux.HandleFunc("/calculator", ph.CalculatorIndex)
-	mux.HandleFunc("/add-pack", ph.AddPack)
-	mux.HandleFunc("/clear-packs", ph.ClearPacks)
-	mux.HandleFunc("/calculate", ph.Calculate)
-	mux.HandleFunc("/pack-sizes", ph.PackSizes)
+	// The tenant routes are registered here too, so that unknown paths and methods
+	// are answered before the tenant is resolved.
+	tenantRoutes := tenants.NewHandler(s.config.Tenants, s.tenantRoutes)
+	for pattern := range packageRoutes {
+		mux.Handle(pattern, tenantRoutes)
+	}
+	for pattern := range webhookRoutes {
+		mux.Handle(pattern, tenantRoutes)
+	}
+
+	return Chain(mux, s.middleware...)
+}
+
+// RegisterGRPC creates the gRPC server of the package API, sharing the tenants' catalogues with the HTTP routes.
+func (s *Server) RegisterGRPC() *grpc.Server {
+	return grpcapi.NewServer(s.config.Tenants, s.tenantService)
+}
+
+// tenantState is what each tenant owns: its catalogue and its webhooks.
+type tenantState struct {
+	service      services.PackageService
+	dispatcher   *webhooks.Dispatcher
+	stopWebhooks func() // Stops queueing the service's events for the webhooks
+}
 
-	// fileServer := http.FileServer(http.FS(web.Files))
-	// mux.Handle("/assets/", fileServer)
-	// mux.Handle("/web", templ.Handler(web.HelloForm()))
-	// mux.HandleFunc("/hello", web.HelloWebHandler)
+// tenant returns the state of a tenant, creating it on first use. Tenants are
+// resolved before, so only configured tenants and the default one get here.
+// The HTTP routes and the gRPC server share it.
+func (s *Server) tenant(tenant string) *tenantState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	if state, ok := s.tenants[tenant]; ok {
+		return state
+	}
+	if s.tenants == nil {
+		s.tenants = make(map[string]*tenantState)
+	}
+
+	service := s.newService(tenant, s.newRepository(tenant))
+	state := &tenantState{service: service}
+	state.dispatcher, state.stopWebhooks = s.webhookDispatcher(tenant, service)
+	s.tenants[tenant] = state
+	return state
+}
+
+// tenantService returns the package service of a tenant.
+func (s *Server) tenantService(tenant string) services.PackageService {
+	return s.tenant(tenant).service
+}
+
+// tenantRoutes builds the routes of one tenant, backed by a catalogue and webhooks of its own.
+func (s *Server) tenantRoutes(tenant string) http.Handler {
+	state := s.tenant(tenant)
+	ph := handlers.NewPackageHandler(state.service)
+	wh := handlers.NewWebhookHandler(state.dispatcher)
+
+	mux := http.NewServeMux()
+	for pattern, handle := range packageRoutes {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) { handle(ph, w, r) })
+	}
+	for pattern, handle := range webhookRoutes {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) { handle(wh, w, r) })
+	}
 	return mux
 }
 
+// webhookDispatcher starts delivering the service's events to the tenant's webhooks until
+// the server is closed, and returns a function that stops queueing them. Events are queued
+// in the outbox as the service publishes them. The outbox is kept in WebhookOutboxDir,
+// or in memory when it is not set.
+func (s *Server) webhookDispatcher(tenant string, service services.PackageService) (*webhooks.Dispatcher, func()) {
+	var path string
+	if s.config.WebhookOutboxDir != "" {
+		path = filepath.Join(s.config.WebhookOutboxDir, tenant+".json")
+	}
+	outbox, err := webhooks.NewOutbox(path)
+	if err != nil {
+		s.logger.Printf("cannot load webhook outbox %s, keeping it in memory: %v", path, err)
+		outbox, _ = webhooks.NewOutbox("")
+	}
+
+	dispatcher := webhooks.NewDispatcher(outbox, nil, tenant)
+	dispatcher.AllowPrivateTargets = s.config.WebhookAllowPrivateTargets
+	stop := service.HandleEvents(dispatcher.Enqueue)
+	go dispatcher.Run(s.ctx, webhooks.DefaultPollInterval)
+	return dispatcher, stop
+}
+
 func (s *Server) HelloWorldHandler(w http.ResponseWriter, r *http.Request) {
 	resp := make(map[string]string)
 	resp["message"] = "Hello World"
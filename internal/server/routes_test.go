@@ -1,14 +1,20 @@
 package server
 
 import (
+	"Ship_Manager/cmd/web"
+	"Ship_Manager/internal/tenants"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 )
 
 func TestHandler(t *testing.T) {
-	s := &Server{}
+	s := New(Config{})
 	server := httptest.NewServer(http.HandlerFunc(s.HelloWorldHandler))
 	defer server.Close()
 	resp, err := http.Get(server.URL)
@@ -29,3 +35,377 @@ func TestHandler(t *testing.T) {
 		t.Errorf("expected response body to be %v; got %v", expected, string(body))
 	}
 }
+
+func TestAssetsRoute(t *testing.T) {
+	s := New(Config{})
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + web.AssetPath("css/output.css"))
+	if err != nil {
+		t.Fatalf("error making request to server. Err: %v", err)
+	}
+	defer resp.Body.Close()
+	// Assertions
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status OK; got %v", resp.Status)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+		t.Errorf("expected long-lived Cache-Control; got %q", cc)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	s := New(Config{Tenants: tenants.Config{
+		BaseDomain: "ships.example.com",
+		APIKeys:    map[string]string{"acme-key": "acme", "globex-key": "globex"},
+	}})
+	handler := s.RegisterRoutes()
+
+	// send serves a request on behalf of the tenant identified by the host and headers
+	send := func(method, path, host string, header http.Header, form url.Values) *httptest.ResponseRecorder {
+		var body io.Reader
+		if form != nil {
+			body = strings.NewReader(form.Encode())
+		}
+		req := httptest.NewRequest(method, path, body)
+		req.Host = host
+		for name := range header {
+			req.Header.Set(name, header.Get(name))
+		}
+		req.Header.Set("Accept", "application/json")
+		if form != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	acme := http.Header{}
+	acme.Set(tenants.APIKeyHeader, "acme-key")
+	globex := http.Header{}
+	globex.Set(tenants.APIKeyHeader, "globex-key")
+
+	sizes := func(host string, header http.Header) []int {
+		rr := send("GET", "/pack-sizes", host, header, nil)
+		var sizes []int
+		json.NewDecoder(rr.Body).Decode(&sizes)
+		return sizes
+	}
+
+	send("POST", "/add-pack", "localhost", acme, url.Values{"size": {"250"}})
+	send("POST", "/add-pack", "localhost", acme, url.Values{"size": {"500"}})
+	send("POST", "/add-pack", "localhost", globex, url.Values{"size": {"1000"}})
+	send("POST", "/pack-rules", "localhost", acme, url.Values{"size": {"250"}, "maxCount": {"1"}})
+
+	t.Run("Catalogues", func(t *testing.T) {
+		if got := sizes("localhost", acme); !reflect.DeepEqual(got, []int{500, 250}) {
+			t.Errorf("acme sizes = %v", got)
+		}
+		if got := sizes("localhost", globex); !reflect.DeepEqual(got, []int{1000}) {
+			t.Errorf("globex sizes = %v", got)
+		}
+		// The same tenants reached through their subdomains, still with their API key
+		if got := sizes("acme.ships.example.com", acme); !reflect.DeepEqual(got, []int{500, 250}) {
+			t.Errorf("acme subdomain sizes = %v", got)
+		}
+		if rr := send("GET", "/pack-sizes", "acme.ships.example.com", nil, nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for the acme subdomain without its API key, got %d", rr.Code)
+		}
+		if got := sizes("localhost", nil); len(got) != 0 {
+			t.Errorf("default tenant sizes = %v", got)
+		}
+	})
+
+	t.Run("Rules and history", func(t *testing.T) {
+		var rules map[string]any
+		json.NewDecoder(send("GET", "/pack-rules", "localhost", globex, nil).Body).Decode(&rules)
+		if len(rules) != 0 {
+			t.Errorf("globex sees rules %v", rules)
+		}
+
+		var versions []map[string]any
+		json.NewDecoder(send("GET", "/versions", "localhost", globex, nil).Body).Decode(&versions)
+		if len(versions) != 2 {
+			t.Errorf("Expected globex to have 2 versions, got %d", len(versions))
+		}
+	})
+
+	t.Run("Calculations", func(t *testing.T) {
+		rr := send("POST", "/calculate", "localhost", globex, url.Values{"order": {"251"}})
+		var packs map[int]int
+		json.NewDecoder(rr.Body).Decode(&packs)
+		if !reflect.DeepEqual(packs, map[int]int{1000: 1}) {
+			t.Errorf("globex calculated %v", packs)
+		}
+	})
+
+	t.Run("Changes stay within a tenant", func(t *testing.T) {
+		send("POST", "/clear-packs", "localhost", globex, url.Values{})
+		send("POST", "/rollback", "localhost", globex, url.Values{"version": {"1"}})
+		if got := sizes("localhost", acme); !reflect.DeepEqual(got, []int{500, 250}) {
+			t.Errorf("acme sizes = %v after globex cleared its catalogue", got)
+		}
+		// acme cannot remove a size from globex, even when asking for it by name
+		rr := send("POST", "/remove-pack", "globex.ships.example.com", acme, url.Values{"size": {"1000"}})
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Expected 403 for a cross-tenant request, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Unknown API key", func(t *testing.T) {
+		rr := send("GET", "/pack-sizes", "localhost", http.Header{"Authorization": {"Bearer guessed"}}, nil)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rr.Code)
+		}
+	})
+}
+
+// TestOpenAPIRoutes checks that every operation in the OpenAPI document is routed
+// to a handler accepting its method.
+func TestOpenAPIRoutes(t *testing.T) {
+	s := New(Config{})
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/openapi.json")
+	if err != nil {
+		t.Fatalf("error making request to server. Err: %v", err)
+	}
+	defer resp.Body.Close()
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Content map[string]any `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			if _, ok := op.Responses["200"].Content["text/event-stream"]; ok {
+				continue
+			}
+			target := strings.NewReplacer("{path}", "css/output.css", "{size}", "250", "{id}", "unknown").Replace(path)
+			req, _ := http.NewRequest(strings.ToUpper(method), server.URL+target, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusMethodNotAllowed {
+				t.Errorf("%s %s: method not allowed", method, path)
+			}
+			if string(body) == notFound {
+				t.Errorf("%s %s: not routed", method, path)
+			}
+		}
+	}
+}
+
+// TestOpenAPIPaths checks that the OpenAPI document describes exactly the routes the
+// server registers. Prefix patterns like "GET /assets/" are documented as "/assets/{path}".
+func TestOpenAPIPaths(t *testing.T) {
+	s := New(Config{})
+	var patterns []string
+	for pattern := range s.serverRoutes() {
+		patterns = append(patterns, pattern)
+	}
+	for pattern := range packageRoutes {
+		patterns = append(patterns, pattern)
+	}
+	for pattern := range webhookRoutes {
+		patterns = append(patterns, pattern)
+	}
+
+	registered := make(map[string]bool)
+	for _, pattern := range patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		switch {
+		case strings.HasSuffix(path, "/{$}"):
+			path = strings.TrimSuffix(path, "{$}")
+		case path != "/" && strings.HasSuffix(path, "/"):
+			path += "{path}"
+		}
+		registered[method+" "+path] = true
+	}
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&doc); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method := range item {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	for route := range registered {
+		if !documented[route] {
+			t.Errorf("%s is routed but not documented", route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			t.Errorf("%s is documented but not routed", route)
+		}
+	}
+}
+
+// notFound is the body of the router's 404 response, as opposed to a handler's.
+const notFound = "404 page not found\n"
+
+func TestRoutes(t *testing.T) {
+	s := New(Config{})
+	handler := s.RegisterRoutes()
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		allow  string // Expected Allow header of a 405 response
+	}{
+		{method: "GET", path: "/", status: http.StatusOK},
+		{method: "HEAD", path: "/pack-sizes", status: http.StatusOK},
+		{method: "GET", path: "/pack-sizes", status: http.StatusOK},
+		{method: "GET", path: "/docs", status: http.StatusOK},
+		{method: "GET", path: "/nope", status: http.StatusNotFound},
+		{method: "GET", path: "/pack-sizes/250/extra", status: http.StatusNotFound},
+		{method: "POST", path: "/", status: http.StatusMethodNotAllowed, allow: "GET, HEAD"},
+		{method: "PUT", path: "/pack-sizes", status: http.StatusMethodNotAllowed, allow: "DELETE, GET, HEAD, POST"},
+		{method: "GET", path: "/pack-sizes/250", status: http.StatusMethodNotAllowed, allow: "DELETE"},
+		{method: "GET", path: "/calculate", status: http.StatusMethodNotAllowed, allow: "POST"},
+		{method: "GET", path: "/packaging-levels", status: http.StatusOK},
+		{method: "GET", path: "/load-containers", status: http.StatusMethodNotAllowed, allow: "POST"},
+		{method: "GET", path: "/packaging-levels/pallet", status: http.StatusMethodNotAllowed, allow: "DELETE"},
+		{method: "DELETE", path: "/packaging-levels/pallet", status: http.StatusNotFound},
+		{method: "GET", path: "/add-pack", status: http.StatusMethodNotAllowed, allow: "POST"},
+		{method: "POST", path: "/versions", status: http.StatusMethodNotAllowed, allow: "GET, HEAD"},
+		{method: "PUT", path: "/webhooks", status: http.StatusMethodNotAllowed, allow: "DELETE, GET, HEAD, POST"},
+		{method: "POST", path: "/docs", status: http.StatusMethodNotAllowed, allow: "GET, HEAD"},
+		{method: "DELETE", path: "/openapi.json", status: http.StatusMethodNotAllowed, allow: "GET, HEAD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if allow := rr.Header().Get("Allow"); allow != tt.allow {
+				t.Errorf("Expected Allow %q, got %q", tt.allow, allow)
+			}
+			if tt.status == http.StatusNotFound && strings.Contains(rr.Body.String(), "Hello World") {
+				t.Errorf("Unknown path answered by the index")
+			}
+		})
+	}
+}
+
+// TestTenantPatterns checks that every tenant route is registered on the outer router,
+// so that it reaches the tenant's own routes.
+func TestTenantPatterns(t *testing.T) {
+	s := New(Config{})
+	mux := s.RegisterRoutes().(*http.ServeMux)
+
+	var patterns []string
+	for pattern := range packageRoutes {
+		patterns = append(patterns, pattern)
+	}
+	for pattern := range webhookRoutes {
+		patterns = append(patterns, pattern)
+	}
+
+	for _, pattern := range patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		path = strings.NewReplacer("{size}", "250", "{id}", "abc").Replace(path)
+		_, got := mux.Handler(httptest.NewRequest(method, path, nil))
+		if got != pattern {
+			t.Errorf("%s %s routed to %q, want %q", method, path, got, pattern)
+		}
+	}
+}
+
+// TestRoutingBeforeTenants checks that unknown paths and methods are answered
+// without resolving a tenant, even when every request needs an API key.
+func TestRoutingBeforeTenants(t *testing.T) {
+	s := New(Config{Tenants: tenants.Config{RequireAPIKey: true}})
+	handler := s.RegisterRoutes()
+
+	for method, want := range map[string]int{"GET": http.StatusUnauthorized, "PATCH": http.StatusMethodNotAllowed} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, "/pack-sizes", nil))
+		if rr.Code != want {
+			t.Errorf("%s /pack-sizes: expected %d, got %d", method, want, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/nope", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown path, got %d", rr.Code)
+	}
+}
+
+func TestPackSizeRoutes(t *testing.T) {
+	s := New(Config{})
+	handler := s.RegisterRoutes()
+
+	send := func(method, path string, body io.Reader) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, body)
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	steps := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		sizes  string
+	}{
+		{method: "POST", path: "/pack-sizes", body: `{"size": 250}`, status: http.StatusOK, sizes: `[250]`},
+		{method: "POST", path: "/pack-sizes", body: `{"size": 500}`, status: http.StatusOK, sizes: `[500, 250]`},
+		{method: "DELETE", path: "/pack-sizes/250", status: http.StatusOK, sizes: `[500]`},
+		{method: "DELETE", path: "/pack-sizes/250", status: http.StatusNotFound},
+		{method: "DELETE", path: "/pack-sizes/abc", status: http.StatusBadRequest},
+		{method: "DELETE", path: "/pack-sizes", status: http.StatusOK, sizes: `[]`},
+	}
+
+	for _, step := range steps {
+		var body io.Reader
+		if step.body != "" {
+			body = strings.NewReader(step.body)
+		}
+		rr := send(step.method, step.path, body)
+		if rr.Code != step.status {
+			t.Fatalf("%s %s: expected %d, got %d: %s", step.method, step.path, step.status, rr.Code, rr.Body)
+		}
+		if step.sizes == "" {
+			continue
+		}
+		var got, want []int
+		json.Unmarshal(rr.Body.Bytes(), &got)
+		json.Unmarshal([]byte(step.sizes), &want)
+		if !reflect.DeepEqual(got, want) && !(len(got) == 0 && len(want) == 0) {
+			t.Errorf("%s %s: sizes %v, want %v", step.method, step.path, got, want)
+		}
+	}
+}
@@ -1,33 +1,214 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
-	"os"
 	"strconv"
+	"sync"
 	"time"
 
-	_ "github.com/joho/godotenv/autoload"
+	"Ship_Manager/internal/rates"
+	"Ship_Manager/internal/repositories"
+	"Ship_Manager/internal/services"
+	"Ship_Manager/internal/tenants"
+
+	"google.golang.org/grpc"
 )
 
+// Config is the configuration of a Server.
+type Config struct {
+	Port    int
+	Tenants tenants.Config
+
+	// WebhookOutboxDir is where each tenant's webhook outbox is stored; in memory when empty.
+	// The outbox files hold the subscriptions' signing secrets and are only readable by their owner.
+	WebhookOutboxDir string
+	// WebhookAllowPrivateTargets lets webhooks post to loopback, link-local and private addresses.
+	WebhookAllowPrivateTargets bool
+	// LargeOrderThreshold is the order size from which calculations trigger webhooks; 0 disables them.
+	// It configures the default package service only.
+	LargeOrderThreshold int
+	// GRPCPort is the port of the gRPC API; it is not served when 0.
+	GRPCPort int
+	// RateTablesDir holds the carrier rate tables, as CSV or JSON files, that the default
+	// package service estimates shipping costs from; shipping is not estimated when empty.
+	RateTablesDir string
+	// CatalogueMaxVersions is how many versions of each catalogue's history the default
+	// repository keeps; repositories.DefaultMaxVersions when 0, and every version when negative.
+	CatalogueMaxVersions int
+}
+
+// ConfigFromEnv reads the server configuration from environment variables:
+// PORT, GRPC_PORT, WEBHOOK_OUTBOX_DIR, WEBHOOK_ALLOW_PRIVATE_TARGETS ("true" to allow),
+// LARGE_ORDER_THRESHOLD, RATE_TABLES_DIR, CATALOGUE_MAX_VERSIONS and the tenant variables
+// read by tenants.ConfigFromEnv.
+func ConfigFromEnv(getenv func(string) string) Config {
+	port, _ := strconv.Atoi(getenv("PORT"))
+	grpcPort, _ := strconv.Atoi(getenv("GRPC_PORT"))
+	largeOrderThreshold, _ := strconv.Atoi(getenv("LARGE_ORDER_THRESHOLD"))
+	maxVersions, _ := strconv.Atoi(getenv("CATALOGUE_MAX_VERSIONS"))
+	return Config{
+		Port:                       port,
+		GRPCPort:                   grpcPort,
+		Tenants:                    tenants.ConfigFromEnv(getenv),
+		WebhookOutboxDir:           getenv("WEBHOOK_OUTBOX_DIR"),
+		WebhookAllowPrivateTargets: getenv("WEBHOOK_ALLOW_PRIVATE_TARGETS") == "true",
+		LargeOrderThreshold:        largeOrderThreshold,
+		RateTablesDir:              getenv("RATE_TABLES_DIR"),
+		CatalogueMaxVersions:       maxVersions,
+	}
+}
+
+// RepositoryFunc creates the catalogue storage of a tenant.
+type RepositoryFunc func(tenant string) repositories.PackageRepository
+
+// ServiceFunc creates the package service of a tenant, backed by the tenant's repository.
+type ServiceFunc func(tenant string, repository repositories.PackageRepository) services.PackageService
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithRepository sets how the catalogue storage of each tenant is created.
+func WithRepository(newRepository RepositoryFunc) Option {
+	return func(s *Server) {
+		s.newRepository = newRepository
+	}
+}
+
+// WithService sets how the package service of each tenant is created.
+func WithService(newService ServiceFunc) Option {
+	return func(s *Server) {
+		s.newService = newService
+	}
+}
+
+// WithLogger sets the logger of the server.
+func WithLogger(logger *log.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithMiddleware adds middleware around every HTTP route. The first one added is the outermost.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, middleware...)
+	}
+}
+
 type Server struct {
-	Port int
+	config        Config
+	newRepository RepositoryFunc
+	newService    ServiceFunc
+	logger        *log.Logger
+	middleware    []Middleware
+
+	// ctx ends when the server is closed, stopping the tenants' background work
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	tenants map[string]*tenantState
+}
+
+// New creates a Server. Unless configured otherwise every tenant gets an in-memory
+// repository and the default package service, which estimates shipping costs from the
+// rate tables in RateTablesDir, and messages go to the standard logger.
+func New(config Config, opts ...Option) *Server {
+	s := &Server{config: config}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.newRepository == nil {
+		var opts []repositories.Option
+		if config.CatalogueMaxVersions != 0 {
+			opts = append(opts, repositories.WithMaxVersions(config.CatalogueMaxVersions))
+		}
+		s.newRepository = func(string) repositories.PackageRepository {
+			return repositories.NewPackageRepository(opts...)
+		}
+	}
+	if s.logger == nil {
+		s.logger = log.Default()
+	}
+	if s.newService == nil {
+		tables := s.rateTables()
+		s.newService = func(_ string, repository repositories.PackageRepository) services.PackageService {
+			return services.NewPackageService(repository,
+				services.WithLargeOrderThreshold(config.LargeOrderThreshold),
+				services.WithRateTables(tables))
+		}
+	}
+	return s
+}
+
+// Close stops delivering the webhooks of every tenant, detaches them from their services
+// and stops the services watching their pack schedules. Pending deliveries stay in the outboxes.
+func (s *Server) Close() {
+	s.cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, state := range s.tenants {
+		state.stopWebhooks()
+		state.service.Close()
+	}
 }
 
-func NewServer() *http.Server {
-	port, _ := strconv.Atoi(os.Getenv("PORT"))
-	NewServer := &Server{
-		Port: port,
+// rateTables loads the carrier rate tables from RateTablesDir. A directory that cannot be
+// read is logged and leaves the service without rate tables.
+func (s *Server) rateTables() rates.Tables {
+	if s.config.RateTablesDir == "" {
+		return nil
+	}
+	tables, err := rates.LoadDir(s.config.RateTablesDir)
+	if err != nil {
+		s.logger.Printf("cannot load rate tables from %s, shipping costs are disabled: %v", s.config.RateTablesDir, err)
+		return nil
 	}
+	return tables
+}
 
-	// Declare Server config
-	server := &http.Server{
-		Addr:         fmt.Sprintf("0.0.0.0:%d", NewServer.Port),
-		Handler:      NewServer.RegisterRoutes(),
+// HTTPServer returns the HTTP server of the routes on the configured port.
+func (s *Server) HTTPServer() *http.Server {
+	return &http.Server{
+		Addr:         fmt.Sprintf("0.0.0.0:%d", s.config.Port),
+		Handler:      s.RegisterRoutes(),
+		ErrorLog:     s.logger,
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
+}
 
-	return server
+// GRPCServer returns the gRPC server sharing the catalogues of the HTTP routes,
+// or nil when no gRPC port is configured.
+func (s *Server) GRPCServer() *GRPCServer {
+	if s.config.GRPCPort == 0 {
+		return nil
+	}
+	return &GRPCServer{
+		Server: s.RegisterGRPC(),
+		Addr:   fmt.Sprintf("0.0.0.0:%d", s.config.GRPCPort),
+	}
+}
+
+// GRPCServer serves the gRPC API on its own address.
+type GRPCServer struct {
+	*grpc.Server
+	Addr string
+}
+
+// ListenAndServe listens on Addr and serves gRPC calls until the server stops.
+func (gs *GRPCServer) ListenAndServe() error {
+	listener, err := net.Listen("tcp", gs.Addr)
+	if err != nil {
+		return err
+	}
+	return gs.Serve(listener)
 }
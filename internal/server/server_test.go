@@ -0,0 +1,251 @@
+package server
+
+import (
+	"Ship_Manager/internal/repositories"
+	"Ship_Manager/internal/services"
+	"Ship_Manager/internal/tenants"
+	"Ship_Manager/internal/webhooks"
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"PORT":                          "8080",
+		"GRPC_PORT":                     "9090",
+		"WEBHOOK_OUTBOX_DIR":            "/var/lib/ship",
+		"WEBHOOK_ALLOW_PRIVATE_TARGETS": "true",
+		"LARGE_ORDER_THRESHOLD":         "10000",
+		"TENANT_API_KEYS":               "acme-key=acme",
+		"RATE_TABLES_DIR":               "/etc/ship/rates",
+		"CATALOGUE_MAX_VERSIONS":        "50",
+	}
+	config := ConfigFromEnv(func(name string) string { return env[name] })
+
+	want := Config{
+		Port:                       8080,
+		GRPCPort:                   9090,
+		WebhookOutboxDir:           "/var/lib/ship",
+		WebhookAllowPrivateTargets: true,
+		LargeOrderThreshold:        10000,
+		Tenants:                    tenants.Config{APIKeys: map[string]string{"acme-key": "acme"}},
+		RateTablesDir:              "/etc/ship/rates",
+		CatalogueMaxVersions:       50,
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("ConfigFromEnv() = %+v, want %+v", config, want)
+	}
+}
+
+func TestInjectedDependencies(t *testing.T) {
+	var mu sync.Mutex
+	var created []string
+
+	// Every tenant starts with its name's length as its only pack size
+	newRepository := func(tenant string) repositories.PackageRepository {
+		repository := repositories.NewPackageRepository()
+		repository.Add(len(tenant))
+		return repository
+	}
+	newService := func(tenant string, repository repositories.PackageRepository) services.PackageService {
+		mu.Lock()
+		created = append(created, tenant)
+		mu.Unlock()
+		return services.NewPackageService(repository)
+	}
+
+	var logs bytes.Buffer
+	s := New(Config{Tenants: tenants.Config{APIKeys: map[string]string{"acme-key": "acme"}}},
+		WithRepository(newRepository),
+		WithService(newService),
+		WithLogger(log.New(&logs, "", 0)),
+		WithMiddleware(Logging(log.New(&logs, "", 0))),
+	)
+	handler := s.RegisterRoutes()
+
+	sizes := func(apiKey string) []int {
+		req := httptest.NewRequest("GET", "/pack-sizes", nil)
+		req.Header.Set("Accept", "application/json")
+		if apiKey != "" {
+			req.Header.Set(tenants.APIKeyHeader, apiKey)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		var sizes []int
+		json.NewDecoder(rr.Body).Decode(&sizes)
+		return sizes
+	}
+
+	if got := sizes("acme-key"); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("acme sizes = %v, want [4]", got)
+	}
+	if got := sizes(""); !reflect.DeepEqual(got, []int{7}) {
+		t.Errorf("default sizes = %v, want [7]", got)
+	}
+	sizes("acme-key")
+
+	// The gRPC server shares the service created for the HTTP routes
+	if s.tenantService("acme") != s.tenantService("acme") {
+		t.Error("Expected one service per tenant")
+	}
+	if !reflect.DeepEqual(created, []string{"acme", "default"}) {
+		t.Errorf("services created for %v, want [acme default]", created)
+	}
+	if !strings.Contains(logs.String(), "GET /pack-sizes 200") {
+		t.Errorf("Expected the request to be logged, got %q", logs.String())
+	}
+}
+
+func TestTenantLifetime(t *testing.T) {
+	s := New(Config{Tenants: tenants.Config{Tenants: []string{"globex"}}})
+	handler := s.RegisterRoutes()
+
+	// Tenants that are not configured get no state
+	for _, tenant := range []string{"initech", "umbrella"} {
+		req := httptest.NewRequest("GET", "/pack-sizes", nil)
+		req.Header.Set(tenants.HeaderName, tenant)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", tenant, rr.Code)
+		}
+	}
+	if len(s.tenants) != 0 {
+		t.Errorf("Expected no tenant to be created, got %d", len(s.tenants))
+	}
+
+	// Every event is queued for the webhooks until the server is closed
+	state := s.tenant("globex")
+	if _, err := state.dispatcher.Subscribe(webhooks.Subscription{URL: "https://example.com/hook", Secret: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	for size := 1; size <= 50; size++ {
+		state.service.AddPack(size)
+	}
+	if deliveries := state.dispatcher.Deliveries(); len(deliveries) != 50 {
+		t.Errorf("Expected 50 deliveries, got %d", len(deliveries))
+	}
+
+	s.Close()
+	state.service.AddPack(100)
+	if deliveries := state.dispatcher.Deliveries(); len(deliveries) != 50 {
+		t.Errorf("Expected no delivery after closing, got %d", len(deliveries)-50)
+	}
+}
+
+func TestRateTables(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "swift.csv"), []byte("carrier,zone,maxWeight,price\nSwift,EU,10,7.5\n"), 0o644)
+
+	quote := func(handler http.Handler) *httptest.ResponseRecorder {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/pack-sizes?size=5", nil))
+		req := httptest.NewRequest("POST", "/calculate", strings.NewReader(`{"order":4,"zone":"EU","itemWeight":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := quote(New(Config{RateTablesDir: dir}).RegisterRoutes())
+	if !strings.Contains(rr.Body.String(), `"quotes":[{"carrier":"Swift","zone":"EU","maxWeight":10,"price":7.5}]`) {
+		t.Errorf("Expected a quote from the rate tables, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	var logs bytes.Buffer
+	rr = quote(New(Config{RateTablesDir: filepath.Join(dir, "missing")}, WithLogger(log.New(&logs, "", 0))).RegisterRoutes())
+	if rr.Code != http.StatusServiceUnavailable || !strings.Contains(logs.String(), "cannot load rate tables") {
+		t.Errorf("Expected shipping to be disabled, got %d and logs %q", rr.Code, logs.String())
+	}
+}
+
+func TestServers(t *testing.T) {
+	s := New(Config{Port: 8080})
+	if addr := s.HTTPServer().Addr; addr != "0.0.0.0:8080" {
+		t.Errorf("HTTP address = %q", addr)
+	}
+	if s.GRPCServer() != nil {
+		t.Error("Expected no gRPC server without a gRPC port")
+	}
+
+	s = New(Config{GRPCPort: 9090})
+	if grpcServer := s.GRPCServer(); grpcServer == nil || grpcServer.Addr != "0.0.0.0:9090" {
+		t.Errorf("gRPC server = %+v", grpcServer)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	// tag appends its name to a header, showing the order the middleware ran in
+	tag := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	t.Run("Chain order", func(t *testing.T) {
+		handler := Chain(http.NotFoundHandler(), tag("outer"), tag("inner"))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+		if got := rr.Header().Values("X-Middleware"); !reflect.DeepEqual(got, []string{"outer", "inner"}) {
+			t.Errorf("middleware ran as %v", got)
+		}
+	})
+
+	t.Run("Applied to every route", func(t *testing.T) {
+		handler := New(Config{}, WithMiddleware(tag("first")), WithMiddleware(tag("second"))).RegisterRoutes()
+		for _, path := range []string{"/", "/pack-sizes", "/docs", "/nope"} {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+			if got := rr.Header().Values("X-Middleware"); !reflect.DeepEqual(got, []string{"first", "second"}) {
+				t.Errorf("%s: middleware ran as %v", path, got)
+			}
+		}
+	})
+
+	t.Run("Logging", func(t *testing.T) {
+		var logs bytes.Buffer
+		handler := Logging(log.New(&logs, "", 0))(http.NotFoundHandler())
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nope?x=1", nil))
+
+		if !strings.HasPrefix(logs.String(), "GET /nope?x=1 404 ") {
+			t.Errorf("logged %q", logs.String())
+		}
+	})
+
+	t.Run("Recover", func(t *testing.T) {
+		var logs bytes.Buffer
+		handler := Recover(log.New(&logs, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("Expected 500, got %d", rr.Code)
+		}
+		if !strings.Contains(logs.String(), "panic serving GET /: boom") {
+			t.Errorf("logged %q", logs.String())
+		}
+	})
+
+	t.Run("Streaming through the logger", func(t *testing.T) {
+		handler := Logging(log.New(&bytes.Buffer{}, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := w.(http.Flusher); !ok {
+				t.Error("Expected the response to stay flushable")
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/events", nil))
+	})
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var (
+	// ErrNoContainers is returned when packs are to be loaded without any container types.
+	ErrNoContainers = errors.New("no containers given")
+	// ErrInvalidContainer is returned when a container has no volume or a negative weight limit.
+	ErrInvalidContainer = errors.New("container volume must be positive and its weight limit must not be negative")
+	// ErrMissingDimensions is returned when a pack size to load has no dimensions.
+	ErrMissingDimensions = errors.New("no dimensions given")
+	// ErrInvalidDimensions is returned when a pack has no volume or a negative weight.
+	ErrInvalidDimensions = errors.New("volume must be positive and weight must not be negative")
+	// ErrPackTooLarge is returned when a pack does not fit in any container, even an empty one.
+	ErrPackTooLarge = errors.New("pack does not fit in any container")
+	// ErrTooManyPacks is returned when more than MaxContainerPacks packs are to be loaded.
+	ErrTooManyPacks = fmt.Errorf("more than %d packs to load", MaxContainerPacks)
+)
+
+// MaxContainerPacks bounds the packs loaded at once, and so the containers opened for them.
+const MaxContainerPacks = 10000
+
+// capacityTolerance absorbs rounding when volumes and weights add up to exactly a container's capacity.
+const capacityTolerance = 1e-9
+
+// PackDimensions is the physical size of one pack. Volumes and weights may use any
+// units, as long as the containers use the same ones.
+type PackDimensions struct {
+	Volume float64 `json:"volume"` // Space one pack takes up
+	Weight float64 `json:"weight"` // Weight of one full pack
+}
+
+// Container is a type of carrier box or pallet that packs are loaded into.
+type Container struct {
+	Name      string  `json:"name"`
+	Volume    float64 `json:"volume"`    // Usable volume
+	MaxWeight float64 `json:"maxWeight"` // Heaviest load allowed, 0 for unlimited
+}
+
+// ContainerRequest asks for the packs of an order to be loaded into containers.
+type ContainerRequest struct {
+	Order      int                    `json:"order"`      // Items ordered; the packs are calculated for it when Packs is empty
+	Packs      map[int]int            `json:"packs"`      // Packs to load, e.g. from an earlier calculation
+	Dimensions map[int]PackDimensions `json:"dimensions"` // Dimensions of one pack, by pack size
+	Containers []Container            `json:"containers"` // Container types in order of preference
+}
+
+// ContainerLoad is one container and the packs loaded into it.
+type ContainerLoad struct {
+	Container         string      `json:"container"`         // Name of the container type
+	Packs             map[int]int `json:"packs"`             // Map of pack sizes to the number of packs in this container
+	Volume            float64     `json:"volume"`            // Volume taken up by the packs
+	Weight            float64     `json:"weight"`            // Weight of the packs
+	VolumeUtilisation float64     `json:"volumeUtilisation"` // Percentage of the container's volume used
+	WeightUtilisation float64     `json:"weightUtilisation"` // Percentage of the container's weight limit used, 0 when unlimited
+}
+
+// ContainerResult is the assignment of packs to containers.
+type ContainerResult struct {
+	Packs             map[int]int     `json:"packs"`             // All packs loaded
+	Containers        []ContainerLoad `json:"containers"`        // Containers used, in the order they were opened
+	VolumeUtilisation float64         `json:"volumeUtilisation"` // Percentage of the volume of all containers used
+	WeightUtilisation float64         `json:"weightUtilisation"` // Percentage of the weight limits of the limited containers used
+
+	CatalogueVersion int `json:"catalogueVersion,omitempty"` // Catalogue version the packs were calculated against
+}
+
+// PackSizeError reports which pack size could not be loaded.
+type PackSizeError struct {
+	Size int
+	Err  error
+}
+
+func (e *PackSizeError) Error() string {
+	return fmt.Sprintf("pack size %d: %v", e.Size, e.Err)
+}
+
+func (e *PackSizeError) Unwrap() error {
+	return e.Err
+}
+
+func (ps *packageService) LoadContainers(req ContainerRequest) (ContainerResult, error) {
+	packs := req.Packs
+	version := 0
+	if len(packs) == 0 {
+		if req.Order <= 0 {
+			return ContainerResult{}, ErrInvalidQuantity
+		}
+		result, err := ps.Calculate(req.Order)
+		if err != nil {
+			return ContainerResult{}, err
+		}
+		packs, version = result.Packs, result.CatalogueVersion
+	}
+
+	result, err := FirstFitDecreasing(packs, req.Dimensions, req.Containers)
+	if err != nil {
+		return ContainerResult{}, err
+	}
+	result.CatalogueVersion = version
+	return result, nil
+}
+
+// FirstFitDecreasing assigns packs to containers: the packs are placed largest volume
+// first, then heaviest first, each into the first open container with room for it.
+// When none has room, a container of the first type the pack fits in is opened.
+// The result only depends on its arguments, so the same packs always load the same way.
+// It returns ErrTooManyPacks for more than MaxContainerPacks packs.
+func FirstFitDecreasing(packs map[int]int, dimensions map[int]PackDimensions, containers []Container) (ContainerResult, error) {
+	if len(containers) == 0 {
+		return ContainerResult{}, ErrNoContainers
+	}
+	for _, container := range containers {
+		if container.Volume <= 0 || container.MaxWeight < 0 {
+			return ContainerResult{}, ErrInvalidContainer
+		}
+	}
+
+	sizes := sortedSizes(packs)
+	total := 0
+	for _, size := range sizes {
+		if packs[size] < 0 {
+			return ContainerResult{}, &PackSizeError{Size: size, Err: ErrInvalidQuantity}
+		}
+		if total += packs[size]; total > MaxContainerPacks {
+			return ContainerResult{}, ErrTooManyPacks
+		}
+		dims, ok := dimensions[size]
+		switch {
+		case !ok:
+			return ContainerResult{}, &PackSizeError{Size: size, Err: ErrMissingDimensions}
+		case dims.Volume <= 0 || dims.Weight < 0:
+			return ContainerResult{}, &PackSizeError{Size: size, Err: ErrInvalidDimensions}
+		}
+	}
+	sort.SliceStable(sizes, func(i, j int) bool {
+		a, b := dimensions[sizes[i]], dimensions[sizes[j]]
+		if a.Volume != b.Volume {
+			return a.Volume > b.Volume
+		}
+		return a.Weight > b.Weight
+	})
+
+	// The packs of one size are identical, so filling each open container with as many
+	// as fit before opening the next places them as first fit would one at a time.
+	var loads []ContainerLoad
+	var types []Container // Type of each load
+	for _, size := range sizes {
+		dims := dimensions[size]
+		remaining := packs[size]
+		for i := 0; i < len(loads) && remaining > 0; i++ {
+			n := min(remaining, room(types[i], loads[i], dims))
+			loads[i].add(size, dims, n)
+			remaining -= n
+		}
+
+		for remaining > 0 {
+			opened := false
+			for _, container := range containers {
+				load := ContainerLoad{Container: container.Name, Packs: make(map[int]int)}
+				if n := min(remaining, room(container, load, dims)); n > 0 {
+					load.add(size, dims, n)
+					loads = append(loads, load)
+					types = append(types, container)
+					remaining -= n
+					opened = true
+					break
+				}
+			}
+			if !opened {
+				return ContainerResult{}, &PackSizeError{Size: size, Err: ErrPackTooLarge}
+			}
+		}
+	}
+
+	result := ContainerResult{Packs: make(map[int]int), Containers: loads}
+	for size, count := range packs {
+		if count > 0 {
+			result.Packs[size] = count
+		}
+	}
+	var volume, capacity, weight, weightLimit float64
+	for i := range loads {
+		container := types[i]
+		loads[i].VolumeUtilisation = percent(loads[i].Volume, container.Volume)
+		volume += loads[i].Volume
+		capacity += container.Volume
+		if container.MaxWeight > 0 {
+			loads[i].WeightUtilisation = percent(loads[i].Weight, container.MaxWeight)
+			weight += loads[i].Weight
+			weightLimit += container.MaxWeight
+		}
+	}
+	result.VolumeUtilisation = percent(volume, capacity)
+	result.WeightUtilisation = percent(weight, weightLimit)
+	return result, nil
+}
+
+// room returns how many more packs of the given dimensions fit in a load.
+func room(container Container, load ContainerLoad, dims PackDimensions) int {
+	n := capacityLeft(container.Volume, load.Volume, dims.Volume)
+	if container.MaxWeight > 0 && dims.Weight > 0 {
+		n = min(n, capacityLeft(container.MaxWeight, load.Weight, dims.Weight))
+	}
+	return n
+}
+
+// capacityLeft returns how many more of each may be added to used without exceeding capacity.
+// The count is capped at MaxContainerPacks, which is more than will ever be loaded.
+func capacityLeft(capacity, used, each float64) int {
+	left := (capacity + capacityTolerance - used) / each
+	if left <= 0 {
+		return 0
+	}
+	n := int(min(left, MaxContainerPacks))
+	if n < MaxContainerPacks && used+float64(n+1)*each <= capacity+capacityTolerance {
+		n++
+	}
+	for n > 0 && used+float64(n)*each > capacity+capacityTolerance {
+		n--
+	}
+	return n
+}
+
+func (cl *ContainerLoad) add(size int, dims PackDimensions, count int) {
+	if count == 0 {
+		return
+	}
+	cl.Packs[size] += count
+	cl.Volume += float64(count) * dims.Volume
+	cl.Weight += float64(count) * dims.Weight
+}
+
+// percent returns used as a percentage of capacity rounded to two decimals, or 0 without capacity.
+func percent(used, capacity float64) float64 {
+	if capacity <= 0 {
+		return 0
+	}
+	return math.Round(used/capacity*10000) / 100
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"Ship_Manager/internal/repositories"
+	"errors"
+	"fmt"
+)
+
+// ErrNoExactFit is returned when no combination of packs adds up to exactly the order.
+var ErrNoExactFit = errors.New("no combination of packs matches the order exactly")
+
+// NoExactFitError suggests the nearest quantities that can be shipped exactly.
+// It wraps ErrNoExactFit.
+type NoExactFitError struct {
+	Order int `json:"order"`
+	Below int `json:"below"` // Largest quantity below the order that fits exactly, 0 if none
+	Above int `json:"above"` // Smallest quantity above the order that fits exactly, 0 if none
+}
+
+func (e *NoExactFitError) Error() string {
+	switch {
+	case e.Below > 0 && e.Above > 0:
+		return fmt.Sprintf("%v: nearest quantities are %d and %d", ErrNoExactFit, e.Below, e.Above)
+	case e.Above > 0:
+		return fmt.Sprintf("%v: nearest quantity is %d", ErrNoExactFit, e.Above)
+	case e.Below > 0:
+		return fmt.Sprintf("%v: nearest quantity is %d", ErrNoExactFit, e.Below)
+	default:
+		return ErrNoExactFit.Error()
+	}
+}
+
+func (e *NoExactFitError) Unwrap() error {
+	return ErrNoExactFit
+}
+
+func (ps *packageService) CalculateExact(orderSize int) (map[int]int, error) {
+	c := ps.activeCatalogue()
+	packSizes, rules := c.sizes, c.rules
+	if len(packSizes) == 0 {
+		return nil, ErrNoPackSizes
+	}
+
+	if len(rules) > 0 {
+		return exactWithRules(packSizes, rules, orderSize)
+	}
+
+	table, err := ps.packTable(packSizes).covering(orderSize)
+	if err != nil {
+		return nil, err
+	}
+	if orderSize > 0 && table.reachableTotal(orderSize) {
+		return table.combination(orderSize), nil
+	}
+	return nil, &NoExactFitError{
+		Order: orderSize,
+		Below: table.below(orderSize),
+		Above: table.above(orderSize),
+	}
+}
+
+// exactWithRules looks for an exact fit that honours the pack rules.
+func exactWithRules(packSizes []int, rules map[int]repositories.PackRule, orderSize int) (map[int]int, error) {
+	rt, err := newRuleTable(packSizes, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	target := orderSize - rt.baseTotal
+	rt.fill(max(target, 0) + rt.sizes[0])
+	if orderSize > 0 && rt.reachable(target) {
+		return rt.combination(target), nil
+	}
+
+	fit := &NoExactFitError{Order: orderSize}
+	for t := target - 1; t >= 0; t-- {
+		if rt.reachable(t) && t+rt.baseTotal > 0 {
+			fit.Below = t + rt.baseTotal
+			break
+		}
+	}
+	for t := max(target+1, 0); t < len(rt.best); t++ {
+		if rt.reachable(t) && t+rt.baseTotal > 0 {
+			fit.Above = t + rt.baseTotal
+			break
+		}
+	}
+	return nil, fit
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"maps"
+	"sort"
+)
+
+// Rules used to choose between pack combinations, in the order they are applied.
+const (
+	RuleLeastExcess = "least excess items"
+	RuleFewestPacks = "fewest packs"
+)
+
+const (
+	maxSameTotalCandidates = 3
+	maxLargerCandidates    = 2
+	// enumerationBudget bounds the search for alternative combinations of the same total.
+	enumerationBudget = 10000
+)
+
+// Candidate is a pack combination that was considered for an order but not chosen.
+type Candidate struct {
+	CalculationResult
+	Rule string `json:"rule"` // Rule that ranked the chosen solution above this candidate
+}
+
+// Explanation describes why a pack combination was chosen for an order.
+type Explanation struct {
+	Chosen     CalculationResult `json:"chosen"`
+	Candidates []Candidate       `json:"candidates"` // Runner-up combinations, best first
+	Rule       string            `json:"rule"`       // Rule that separated the chosen solution from the best runner-up
+}
+
+func (ps *packageService) ExplainPacks(orderSize int) (Explanation, error) {
+	c := ps.activeCatalogue()
+	packSizes, rules := c.sizes, c.rules
+
+	packs, err := ps.solveWith(packSizes, rules, orderSize)
+	if err != nil {
+		return Explanation{}, err
+	}
+	chosen := NewCalculationResult(orderSize, packs)
+	chosen.CatalogueVersion = c.version
+	explanation := Explanation{
+		Chosen:     chosen,
+		Candidates: []Candidate{},
+	}
+
+	if len(packSizes) == 0 {
+		return explanation, nil
+	}
+
+	// Same total, more packs
+	for _, packs := range combinationsOf(packSizes, chosen.Total, chosen.Packs) {
+		if !followsRules(packs, rules) {
+			continue
+		}
+		explanation.Candidates = append(explanation.Candidates, Candidate{
+			CalculationResult: NewCalculationResult(orderSize, packs),
+			Rule:              RuleFewestPacks,
+		})
+	}
+	sort.SliceStable(explanation.Candidates, func(i, j int) bool {
+		return explanation.Candidates[i].PacksCount < explanation.Candidates[j].PacksCount
+	})
+
+	// Larger totals, more excess. Each candidate is at most a largest pack above the last.
+	total := chosen.Total
+	table, err := ps.packTable(packSizes).covering(total + maxLargerCandidates*(packSizes[0]+1))
+	for i := 0; err == nil && i < maxLargerCandidates; i++ {
+		packs := table.solve(total + 1)
+		result := NewCalculationResult(orderSize, packs)
+		if result.Total <= total {
+			break
+		}
+		total = result.Total
+		if !followsRules(packs, rules) {
+			continue
+		}
+		explanation.Candidates = append(explanation.Candidates, Candidate{
+			CalculationResult: result,
+			Rule:              RuleLeastExcess,
+		})
+	}
+
+	if len(explanation.Candidates) > 0 {
+		explanation.Rule = explanation.Candidates[0].Rule
+	}
+	return explanation, nil
+}
+
+// combinationsOf returns up to maxSameTotalCandidates combinations of the given
+// sizes that add up to exactly total, other than skip.
+func combinationsOf(sizes []int, total int, skip map[int]int) []map[int]int {
+	var found []map[int]int
+	counts := make(map[int]int)
+	budget := enumerationBudget
+
+	var search func(index, remaining int)
+	search = func(index, remaining int) {
+		if len(found) == maxSameTotalCandidates || budget == 0 {
+			return
+		}
+		budget--
+
+		if remaining == 0 {
+			if !maps.Equal(counts, skip) {
+				found = append(found, maps.Clone(counts))
+			}
+			return
+		}
+		if index == len(sizes) {
+			return
+		}
+
+		// Every count tried is charged to the budget, so a large total with few sizes stops early too
+		size := sizes[index]
+		for count := remaining / size; count >= 0 && budget > 0 && len(found) < maxSameTotalCandidates; count-- {
+			if count > 0 {
+				counts[size] = count
+			} else {
+				delete(counts, size)
+			}
+			search(index+1, remaining-count*size)
+		}
+		delete(counts, size)
+	}
+	search(0, total)
+
+	return found
+}
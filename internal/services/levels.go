@@ -0,0 +1,112 @@
+package services
+
+import (
+	"Ship_Manager/internal/repositories"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidLevel is returned when a packaging level has no name or holds fewer than two units.
+var ErrInvalidLevel = errors.New("packaging level needs a name and a quantity of at least 2")
+
+// LevelCount is the number of packs of one packaging level in a result.
+// Pack sizes without a level are listed with an empty Level.
+type LevelCount struct {
+	Level    string `json:"level,omitempty"`    // Name of the packaging level
+	Size     int    `json:"size"`               // Number of items in one pack of this level
+	Count    int    `json:"count"`              // Number of packs of this level
+	Quantity int    `json:"quantity,omitempty"` // Number of units in one pack of this level
+	Unit     string `json:"unit,omitempty"`     // Level contained in one pack of this level
+}
+
+// String describes the count, e.g. "2 pallets" or "3 x 7" for a size without a level.
+func (lc LevelCount) String() string {
+	switch {
+	case lc.Level == "":
+		return fmt.Sprintf("%d x %d", lc.Count, lc.Size)
+	case lc.Count == 1:
+		return fmt.Sprintf("1 %s", lc.Level)
+	default:
+		return fmt.Sprintf("%d %ss", lc.Count, lc.Level)
+	}
+}
+
+// DescribeLevels joins a breakdown into a phrase such as "2 pallets + 3 cases + 1 pack".
+func DescribeLevels(levels []LevelCount) string {
+	parts := make([]string, len(levels))
+	for i, level := range levels {
+		parts[i] = level.String()
+	}
+	return strings.Join(parts, " + ")
+}
+
+// levelBreakdown lists the packs by packaging level, largest first.
+// It returns nil when the catalogue has no levels, so results stay flat.
+func levelBreakdown(packs map[int]int, levels []repositories.PackagingLevel) []LevelCount {
+	if len(levels) == 0 {
+		return nil
+	}
+
+	named := make(map[int]repositories.PackagingLevel, len(levels))
+	for _, level := range levels {
+		named[level.Size] = level
+	}
+
+	breakdown := make([]LevelCount, 0, len(packs))
+	for _, size := range sortedSizes(packs) {
+		level := named[size]
+		breakdown = append(breakdown, LevelCount{
+			Level:    level.Name,
+			Size:     size,
+			Count:    packs[size],
+			Quantity: level.Quantity,
+			Unit:     level.Unit,
+		})
+	}
+	return breakdown
+}
+
+func (ps *packageService) AddPackagingLevel(name string, quantity int, unit string) error {
+	if strings.TrimSpace(name) == "" || quantity < 2 {
+		return ErrInvalidLevel
+	}
+	if unit == "" {
+		unit = repositories.ItemUnit
+	}
+	if size := ps.levelSize(quantity, unit); size > 0 {
+		if err := ps.checkCatalogueSize(size); err != nil {
+			return err
+		}
+	}
+	if err := ps.repository.AddLevel(name, quantity, unit); err != nil {
+		return err
+	}
+	ps.catalogueChanged()
+	return nil
+}
+
+// levelSize returns the pack size of a level of quantity units, or 0 if the unit does not exist.
+func (ps *packageService) levelSize(quantity int, unit string) int {
+	if unit == repositories.ItemUnit {
+		return quantity
+	}
+	for _, level := range ps.repository.GetLevels() {
+		if level.Name == unit {
+			return level.Size * quantity
+		}
+	}
+	return 0
+}
+
+func (ps *packageService) RemovePackagingLevel(name string) error {
+	if err := ps.repository.RemoveLevel(name); err != nil {
+		return err
+	}
+	ps.catalogueChanged()
+	return nil
+}
+
+func (ps *packageService) GetPackagingLevels() []repositories.PackagingLevel {
+	return ps.repository.GetLevels()
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Objectives the catalogue optimizer can minimise.
+const (
+	ObjectiveExcess = "excess"
+	ObjectivePacks  = "packs"
+)
+
+// MaxCandidates bounds the candidate sizes of a search.
+const MaxCandidates = 100
+
+const (
+	defaultRecommendations = 5
+	// exhaustiveSearchLimit is the largest number of candidate catalogues tried one by one.
+	// Beyond it the optimizer switches to a greedy start followed by local search.
+	exhaustiveSearchLimit = 5000
+	// searchBudget bounds the work of a search, counted in table entries built and
+	// orders solved. The search stops with the catalogues evaluated so far once it is spent.
+	searchBudget = 1 << 30
+)
+
+var (
+	// ErrInvalidObjective is returned for an objective other than ObjectiveExcess or ObjectivePacks.
+	ErrInvalidObjective = errors.New("objective must be \"excess\" or \"packs\"")
+	// ErrInvalidCatalogueSize is returned when K is not between 1 and the number of candidate sizes.
+	ErrInvalidCatalogueSize = errors.New("number of pack sizes must be between 1 and the number of candidates")
+	// ErrTooManyCandidates is returned when more than MaxCandidates candidate sizes are given.
+	ErrTooManyCandidates = fmt.Errorf("more than %d candidate sizes given", MaxCandidates)
+)
+
+// OptimizeRequest describes a search for the best catalogue of K pack sizes.
+type OptimizeRequest struct {
+	Orders          []int  `json:"orders"`          // Sample of historical order sizes
+	K               int    `json:"k"`               // Number of pack sizes in a recommended catalogue
+	Candidates      []int  `json:"candidates"`      // Sizes to choose from; the current catalogue and the most frequent sample orders when empty
+	Objective       string `json:"objective"`       // ObjectiveExcess (default) or ObjectivePacks
+	Recommendations int    `json:"recommendations"` // Number of catalogues to return, 5 when zero
+}
+
+// Recommendation is a ranked catalogue suggested by the optimizer.
+type Recommendation struct {
+	Rank int `json:"rank"`
+	CatalogueMetrics
+}
+
+func (ps *packageService) OptimizeCatalogue(req OptimizeRequest) ([]Recommendation, error) {
+	if err := validateOrders(req.Orders); err != nil {
+		return nil, err
+	}
+
+	var better func(a, b CatalogueMetrics) bool
+	switch req.Objective {
+	case "", ObjectiveExcess:
+		better = func(a, b CatalogueMetrics) bool {
+			if a.TotalExcess != b.TotalExcess {
+				return a.TotalExcess < b.TotalExcess
+			}
+			return a.TotalPacks < b.TotalPacks
+		}
+	case ObjectivePacks:
+		better = func(a, b CatalogueMetrics) bool {
+			if a.TotalPacks != b.TotalPacks {
+				return a.TotalPacks < b.TotalPacks
+			}
+			return a.TotalExcess < b.TotalExcess
+		}
+	default:
+		return nil, ErrInvalidObjective
+	}
+
+	candidates := req.Candidates
+	if len(candidates) > MaxCandidates {
+		return nil, ErrTooManyCandidates
+	}
+	if len(candidates) == 0 {
+		current := ps.GetPackSizes()
+		candidates = append(current, frequentOrders(req.Orders, MaxCandidates-len(current))...)
+	}
+	candidates, err := normalizeSizes(candidates)
+	if err != nil {
+		return nil, err
+	}
+	if req.K < 1 || req.K > len(candidates) {
+		return nil, ErrInvalidCatalogueSize
+	}
+	if req.K > MaxPackSizes {
+		return nil, ErrTooManyPackSizes
+	}
+
+	o := &optimizer{
+		orders:    req.Orders,
+		evaluated: make(map[string]CatalogueMetrics),
+		tooLarge:  make(map[string]bool),
+		budget:    searchBudget,
+	}
+	if binomial(len(candidates), req.K) <= exhaustiveSearchLimit {
+		o.exhaustive(candidates, req.K)
+	} else {
+		o.localSearch(candidates, req.K, better)
+	}
+
+	ranked := make([]CatalogueMetrics, 0, len(o.evaluated))
+	for _, metrics := range o.evaluated {
+		if len(metrics.Sizes) == req.K {
+			ranked = append(ranked, metrics)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if better(ranked[i], ranked[j]) || better(ranked[j], ranked[i]) {
+			return better(ranked[i], ranked[j])
+		}
+		return slices.Compare(ranked[i].Sizes, ranked[j].Sizes) > 0
+	})
+
+	limit := req.Recommendations
+	if limit <= 0 {
+		limit = defaultRecommendations
+	}
+	recommendations := make([]Recommendation, 0, min(limit, len(ranked)))
+	for i, metrics := range ranked[:min(limit, len(ranked))] {
+		recommendations = append(recommendations, Recommendation{Rank: i + 1, CatalogueMetrics: metrics})
+	}
+	return recommendations, nil
+}
+
+// frequentOrders returns up to n distinct order sizes, the most frequent first.
+func frequentOrders(orders []int, n int) []int {
+	counts := make(map[int]int)
+	for _, order := range orders {
+		counts[order]++
+	}
+	sizes := make([]int, 0, len(counts))
+	for size := range counts {
+		sizes = append(sizes, size)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		if counts[sizes[i]] != counts[sizes[j]] {
+			return counts[sizes[i]] > counts[sizes[j]]
+		}
+		return sizes[i] < sizes[j]
+	})
+	return sizes[:min(max(n, 0), len(sizes))]
+}
+
+// optimizer evaluates candidate catalogues against a sample of orders, remembering every result.
+type optimizer struct {
+	orders    []int
+	evaluated map[string]CatalogueMetrics
+	tooLarge  map[string]bool // Catalogues skipped because their table would exceed maxTableSize
+	budget    int             // Work left before the search stops, see searchBudget
+}
+
+// evaluate returns the metrics of a catalogue, which must be sorted in descending order.
+// It reports false for catalogues too large to calculate with and, once the budget is
+// spent, for every catalogue not evaluated yet.
+func (o *optimizer) evaluate(sizes []int) (CatalogueMetrics, bool) {
+	key := fmt.Sprint(sizes)
+	if metrics, ok := o.evaluated[key]; ok {
+		return metrics, true
+	}
+	if o.tooLarge[key] || o.spent() {
+		return CatalogueMetrics{}, false
+	}
+
+	sizes = slices.Clone(sizes)
+	table := newPackTable(sizes)
+	o.budget -= (table.bound + 2*table.largest) * len(sizes)
+	if table.partial {
+		o.tooLarge[key] = true
+		return CatalogueMetrics{}, false
+	}
+	o.budget -= len(o.orders)
+	metrics := evaluateCatalogue(sizes, o.orders, nil, tableSolver(table))
+	o.evaluated[key] = metrics
+	return metrics, true
+}
+
+// spent reports whether the search has used up its budget.
+func (o *optimizer) spent() bool {
+	return o.budget <= 0
+}
+
+// exhaustive evaluates every catalogue of k sizes.
+func (o *optimizer) exhaustive(candidates []int, k int) {
+	chosen := make([]int, 0, k)
+	var choose func(start int)
+	choose = func(start int) {
+		if len(chosen) == k {
+			o.evaluate(chosen)
+			return
+		}
+		for i := start; i <= len(candidates)-(k-len(chosen)) && !o.spent(); i++ {
+			chosen = append(chosen, candidates[i])
+			choose(i + 1)
+			chosen = chosen[:len(chosen)-1]
+		}
+	}
+	choose(0)
+}
+
+// localSearch builds a catalogue greedily, adding the size that helps most at each
+// step, then swaps single sizes in and out while that keeps improving the result.
+func (o *optimizer) localSearch(candidates []int, k int, better func(a, b CatalogueMetrics) bool) {
+	var current []int
+	for len(current) < k {
+		var best []int
+		var bestMetrics CatalogueMetrics
+		for _, size := range candidates {
+			if slices.Contains(current, size) {
+				continue
+			}
+			next := withSize(current, size)
+			if metrics, ok := o.evaluate(next); ok && (best == nil || better(metrics, bestMetrics)) {
+				best, bestMetrics = next, metrics
+			}
+		}
+		if best == nil {
+			return
+		}
+		current = best
+	}
+
+	currentMetrics, _ := o.evaluate(current)
+	for improved := true; improved && !o.spent(); {
+		improved = false
+		for i := range current {
+			for _, size := range candidates {
+				if slices.Contains(current, size) {
+					continue
+				}
+				next := withSize(slices.Delete(slices.Clone(current), i, i+1), size)
+				if metrics, ok := o.evaluate(next); ok && better(metrics, currentMetrics) {
+					current, currentMetrics = next, metrics
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+// withSize returns a copy of sizes with size added, kept in descending order.
+func withSize(sizes []int, size int) []int {
+	next := append(slices.Clone(sizes), size)
+	sort.Sort(sort.Reverse(sort.IntSlice(next)))
+	return next
+}
+
+// binomial returns n choose k, capped just above exhaustiveSearchLimit.
+func binomial(n, k int) int {
+	result := 1
+	for i := 1; i <= k; i++ {
+		result = result * (n - k + i) / i
+		if result > exhaustiveSearchLimit {
+			return exhaustiveSearchLimit + 1
+		}
+	}
+	return result
+}
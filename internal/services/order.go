@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+var (
+	// ErrNoOrderLines is returned when an order has no lines.
+	ErrNoOrderLines = errors.New("order has no lines")
+	// ErrInvalidQuantity is returned when an order line quantity is not positive.
+	ErrInvalidQuantity = errors.New("quantity must be positive")
+	// ErrInvalidPackSize is returned when an order line catalogue contains a size that is not positive.
+	ErrInvalidPackSize = errors.New("pack size must be positive")
+	// ErrNoPackSizes is returned when an order line has no catalogue and no pack sizes are configured.
+	ErrNoPackSizes = errors.New("no pack sizes available")
+	// ErrTooManyOrderLines is returned when an order has more than MaxOrderLines lines.
+	ErrTooManyOrderLines = fmt.Errorf("order has more than %d lines", MaxOrderLines)
+	// ErrTooManyPackSizes is returned when more than MaxPackSizes pack sizes are given
+	// for an order line or an analysis.
+	ErrTooManyPackSizes = fmt.Errorf("more than %d pack sizes given", MaxPackSizes)
+)
+
+const (
+	// MaxOrderLines bounds the lines of a multi-line order.
+	MaxOrderLines = 100
+	// MaxPackSizes bounds the pack sizes given for an order line or an analysis.
+	MaxPackSizes = 20
+)
+
+// OrderLine is one item of a multi-line order.
+type OrderLine struct {
+	SKU      string          `json:"sku"`
+	Quantity int             `json:"quantity"`
+	Sizes    []int           `json:"sizes,omitempty"` // Pack sizes for this line; the configured catalogue when empty
+	Costs    map[int]float64 `json:"costs,omitempty"` // Cost of one pack, by pack size
+}
+
+// LineResult is the calculation for a single order line.
+type LineResult struct {
+	SKU string `json:"sku"`
+	CalculationResult
+	Cost float64 `json:"cost"` // Cost of the packs for this line
+}
+
+// OrderResult is the calculation for a multi-line order.
+type OrderResult struct {
+	Lines       []LineResult `json:"lines"`
+	TotalItems  int          `json:"totalItems"`  // Items shipped across all lines
+	TotalExcess int          `json:"totalExcess"` // Items shipped in excess across all lines
+	TotalPacks  int          `json:"totalPacks"`  // Packs used across all lines
+	TotalCost   float64      `json:"totalCost"`   // Cost of the packs across all lines
+}
+
+// LineError reports which order line could not be calculated.
+type LineError struct {
+	Line int // 1-based position of the line in the order
+	SKU  string
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d (%s): %v", e.Line, e.SKU, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+func (ps *packageService) CalculateOrder(lines []OrderLine) (OrderResult, error) {
+	if len(lines) == 0 {
+		return OrderResult{}, ErrNoOrderLines
+	}
+	if len(lines) > MaxOrderLines {
+		return OrderResult{}, ErrTooManyOrderLines
+	}
+
+	// Check every line before building any table
+	c := ps.activeCatalogue()
+	sizes := make([][]int, len(lines))
+	for i, line := range lines {
+		lineSizes, err := checkLine(line, c)
+		if err != nil {
+			return OrderResult{}, &LineError{Line: i + 1, SKU: line.SKU, Err: err}
+		}
+		sizes[i] = lineSizes
+	}
+
+	// Solve the lines catalogue by catalogue, so that a single table is held at a time
+	keys := make([]string, len(lines))
+	byCatalogue := make([]int, len(lines))
+	for i := range lines {
+		keys[i] = fmt.Sprint(sizes[i])
+		byCatalogue[i] = i
+	}
+	sort.SliceStable(byCatalogue, func(a, b int) bool { return keys[byCatalogue[a]] < keys[byCatalogue[b]] })
+
+	packs := make([]map[int]int, len(lines))
+	var table *packTable
+	for _, i := range byCatalogue {
+		if sizes[i] == nil {
+			linePacks, err := ps.solveWith(c.sizes, c.rules, lines[i].Quantity)
+			if err != nil {
+				return OrderResult{}, &LineError{Line: i + 1, SKU: lines[i].SKU, Err: err}
+			}
+			packs[i] = linePacks
+			continue
+		}
+		if table == nil || !table.matches(sizes[i]) {
+			table = newPackTable(sizes[i])
+		}
+		packs[i] = table.solve(lines[i].Quantity)
+	}
+
+	result := OrderResult{Lines: make([]LineResult, 0, len(lines))}
+	for i, line := range lines {
+		lineResult := LineResult{
+			SKU:               line.SKU,
+			CalculationResult: NewCalculationResult(line.Quantity, packs[i]),
+		}
+		for size, count := range packs[i] {
+			lineResult.Cost += float64(count) * line.Costs[size]
+		}
+
+		result.Lines = append(result.Lines, lineResult)
+		result.TotalItems += lineResult.Total
+		result.TotalExcess += lineResult.ExcessItems
+		result.TotalPacks += lineResult.PacksCount
+		result.TotalCost += lineResult.Cost
+	}
+
+	return result, nil
+}
+
+// checkLine validates a single line and returns its own pack sizes, sorted in descending
+// order, or nil when it uses the configured catalogue.
+func checkLine(line OrderLine, c catalogueSnapshot) ([]int, error) {
+	if line.Quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if len(line.Sizes) == 0 {
+		if len(c.sizes) == 0 {
+			return nil, ErrNoPackSizes
+		}
+		return nil, nil
+	}
+	if len(line.Sizes) > MaxPackSizes {
+		return nil, ErrTooManyPackSizes
+	}
+
+	sizes, err := normalizeSizes(line.Sizes)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTableSize(sizes); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+// normalizeSizes sorts a caller-supplied catalogue in descending order and drops duplicates.
+func normalizeSizes(packSizes []int) ([]int, error) {
+	if len(packSizes) == 0 {
+		return nil, ErrNoPackSizes
+	}
+	sizes := slices.Clone(packSizes)
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	sizes = slices.Compact(sizes)
+	if sizes[len(sizes)-1] <= 0 {
+		return nil, ErrInvalidPackSize
+	}
+	return sizes, nil
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"container/heap"
+	"errors"
+	"slices"
+)
+
+// maxTableSize bounds the entries of a pack table, which grow with the largest pack
+// times the number of sizes and with the bound, about the square of the largest pack
+// for sizes close to each other. Catalogues over it are rejected, and tables for other
+// sizes over it fall back to a table covering just the order.
+const maxTableSize = 1 << 20
+
+// maxCachedTables bounds the tables a service keeps: the one for the sizes available now
+// and a few for the sizes of other ship dates.
+const maxCachedTables = 4
+
+var (
+	// ErrTableTooLarge is returned when pack sizes would need a table larger than maxTableSize.
+	ErrTableTooLarge = errors.New("pack sizes are too large or too close to each other to calculate with")
+	// ErrOrderTooLarge is returned when an order is too large for pack sizes without a full table.
+	ErrOrderTooLarge = errors.New("order is too large for these pack sizes")
+)
+
+// packTable is a precomputed solution table for a fixed set of pack sizes.
+//
+// Every total can be written as some number of largest packs plus a combination
+// of the smaller sizes. For each residue modulo the largest pack we keep the
+// combination of smaller packs that minimises the overall pack count. Once an
+// order reaches bound, the optimal solution is that combination topped up with
+// largest packs, so lookups no longer depend on the order size. Orders below
+// bound are answered from a dense table covering [0, bound+largest).
+//
+// When that would exceed maxTableSize the table is partial: it holds no entries and
+// covering builds a dense table for each order instead.
+type packTable struct {
+	sizes   []int // Pack sizes in descending order
+	largest int
+	bound   int
+	partial bool // Whether the table needs covering before use
+
+	// Dense table for small totals
+	packs []int // Minimum number of packs to reach a total exactly, -1 if unreachable
+	last  []int // Pack size used last to reach a total
+	next  []int // Smallest reachable total greater than or equal to the index, -1 if none
+	prev  []int // Largest reachable total less than or equal to the index
+
+	// Residue table for large totals
+	reachable  []bool  // Whether a residue can be reached with the smaller sizes
+	sums       [][]int // Counts of each smaller size for the best combination per residue
+	sumTotal   []int   // Item total of the best combination per residue
+	nextOffset []int   // Distance from a residue to the nearest reachable residue above
+	prevOffset []int   // Distance from a residue to the nearest reachable residue below
+}
+
+// newPackTable builds the table for the given pack sizes, which must be sorted in descending order.
+// Tables that would exceed maxTableSize are partial.
+func newPackTable(sizes []int) *packTable {
+	t := residueTable(sizes)
+	if len(t.sizes) > 0 && !t.partial {
+		t.buildDense()
+	}
+	return t
+}
+
+// residueTable builds the residue table alone, which is enough to tell whether the
+// dense table fits within maxTableSize.
+func residueTable(sizes []int) *packTable {
+	t := &packTable{sizes: slices.Clone(sizes)}
+	if len(sizes) == 0 {
+		return t
+	}
+	t.largest = sizes[0]
+	if t.largest > maxTableSize/len(sizes) {
+		t.partial = true
+		return t
+	}
+	t.buildResidues()
+	if t.bound+t.largest > maxTableSize {
+		*t = packTable{sizes: t.sizes, largest: t.largest, partial: true}
+	}
+	return t
+}
+
+// checkTableSize returns ErrTableTooLarge if the pack sizes, sorted in descending order,
+// cannot have a full table. It does not build the dense table.
+func checkTableSize(sizes []int) error {
+	if residueTable(sizes).partial {
+		return ErrTableTooLarge
+	}
+	return nil
+}
+
+// covering returns a table that answers every lookup for totals up to total: the table
+// itself, or for a partial one a dense table built for those totals alone, as long as
+// it stays within maxTableSize.
+func (t *packTable) covering(total int) (*packTable, error) {
+	if !t.partial {
+		return t, nil
+	}
+	// above needs the next reachable total after total, at most a largest pack further
+	if total > maxTableSize || t.largest > maxTableSize || max(total, 0)+2*t.largest+2 > maxTableSize {
+		return nil, ErrOrderTooLarge
+	}
+	bound := max(total, 0) + t.largest + 2
+	dense := &packTable{sizes: t.sizes, largest: t.largest, bound: bound}
+	dense.buildDense()
+	return dense, nil
+}
+
+// matches reports whether the table was built for the given pack sizes.
+func (t *packTable) matches(sizes []int) bool {
+	return slices.Equal(t.sizes, sizes)
+}
+
+// buildResidues runs Dijkstra over the residues modulo the largest pack.
+// Adding a smaller pack of size a costs largest-a, which is the number of items
+// "lost" compared to using a largest pack, so the cheapest path to a residue is the
+// combination that needs the fewest packs for any large enough total.
+func (t *packTable) buildResidues() {
+	smaller := t.sizes[1:]
+	l := t.largest
+
+	cost := make([]int, l)
+	t.reachable = make([]bool, l)
+	t.sums = make([][]int, l)
+	t.sumTotal = make([]int, l)
+	done := make([]bool, l)
+
+	t.reachable[0] = true
+	t.sums[0] = make([]int, len(smaller))
+	pq := &residueQueue{{residue: 0, cost: 0}}
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(residueItem)
+		r := item.residue
+		if done[r] {
+			continue
+		}
+		done[r] = true
+
+		for i, size := range smaller {
+			nr := (r + size) % l
+			nc := cost[r] + l - size
+			if done[nr] || (t.reachable[nr] && nc >= cost[nr]) {
+				continue
+			}
+			t.reachable[nr] = true
+			cost[nr] = nc
+			t.sums[nr] = slices.Clone(t.sums[r])
+			t.sums[nr][i]++
+			t.sumTotal[nr] = t.sumTotal[r] + size
+			heap.Push(pq, residueItem{residue: nr, cost: nc})
+		}
+	}
+
+	for r := range l {
+		if t.reachable[r] && t.sumTotal[r] > t.bound {
+			t.bound = t.sumTotal[r]
+		}
+	}
+
+	t.nextOffset = make([]int, l)
+	offset := 0
+	// Walk the residues backwards twice so every residue sees its successor, wrapping around.
+	for i := 2*l - 1; i >= 0; i-- {
+		r := i % l
+		if t.reachable[r] {
+			offset = 0
+		} else {
+			offset++
+		}
+		t.nextOffset[r] = offset
+	}
+
+	t.prevOffset = make([]int, l)
+	offset = 0
+	for i := range 2 * l {
+		r := i % l
+		if t.reachable[r] {
+			offset = 0
+		} else {
+			offset++
+		}
+		t.prevOffset[r] = offset
+	}
+}
+
+// buildDense fills the exact-total table for totals below bound+largest.
+func (t *packTable) buildDense() {
+	n := t.bound + t.largest
+	t.packs = make([]int, n)
+	t.last = make([]int, n)
+	t.next = make([]int, n)
+	t.prev = make([]int, n)
+
+	for i := 1; i < n; i++ {
+		t.packs[i] = -1
+		for _, size := range t.sizes {
+			if i < size || t.packs[i-size] < 0 {
+				continue
+			}
+			if t.packs[i] < 0 || t.packs[i-size]+1 < t.packs[i] {
+				t.packs[i] = t.packs[i-size] + 1
+				t.last[i] = size
+			}
+		}
+	}
+
+	nextReachable := -1
+	for i := n - 1; i >= 0; i-- {
+		if t.packs[i] >= 0 {
+			nextReachable = i
+		}
+		t.next[i] = nextReachable
+	}
+
+	prevReachable := 0
+	for i := range n {
+		if t.packs[i] >= 0 {
+			prevReachable = i
+		}
+		t.prev[i] = prevReachable
+	}
+}
+
+// solve returns the combination of packs with the smallest total covering the order,
+// using as few packs as possible for that total. Orders below the smallest pack
+// always get one smallest pack.
+func (t *packTable) solve(order int) map[int]int {
+	if len(t.sizes) == 0 {
+		return map[int]int{}
+	}
+	// Handle case where order is smaller than the smallest pack
+	if smallest := t.sizes[len(t.sizes)-1]; order < smallest {
+		return map[int]int{smallest: 1}
+	}
+
+	if order < t.bound {
+		return t.combination(t.next[order])
+	}
+	return t.combination(order + t.nextOffset[order%t.largest])
+}
+
+// reachableTotal reports whether some combination of packs adds up to exactly total.
+func (t *packTable) reachableTotal(total int) bool {
+	switch {
+	case len(t.sizes) == 0 || total < 0:
+		return false
+	case total < len(t.packs):
+		return t.packs[total] >= 0
+	default:
+		return t.reachable[total%t.largest]
+	}
+}
+
+// combination returns the fewest packs adding up to exactly total, which must be reachable.
+func (t *packTable) combination(total int) map[int]int {
+	result := make(map[int]int)
+	if total < t.bound {
+		for total > 0 {
+			size := t.last[total]
+			result[size]++
+			total -= size
+		}
+		return result
+	}
+
+	r := total % t.largest
+	for i, count := range t.sums[r] {
+		if count > 0 {
+			result[t.sizes[i+1]] = count
+		}
+	}
+	if largestCount := (total - t.sumTotal[r]) / t.largest; largestCount > 0 {
+		result[t.largest] = largestCount
+	}
+	return result
+}
+
+// above returns the smallest reachable total greater than order.
+func (t *packTable) above(order int) int {
+	o := max(order+1, 0)
+	if o < len(t.next) && t.next[o] >= 0 {
+		return t.next[o]
+	}
+	return o + t.nextOffset[o%t.largest]
+}
+
+// below returns the largest reachable total less than order, or 0 if there is none.
+func (t *packTable) below(order int) int {
+	o := order - 1
+	if o >= t.bound {
+		if total := o - t.prevOffset[o%t.largest]; total >= t.bound {
+			return total
+		}
+		o = t.bound - 1
+	}
+	if o <= 0 {
+		return 0
+	}
+	return t.prev[o]
+}
+
+// residueItem is an entry in the Dijkstra priority queue.
+type residueItem struct {
+	residue int
+	cost    int
+}
+
+// residueQueue implements heap.Interface ordered by cost, then residue.
+type residueQueue []residueItem
+
+func (q residueQueue) Len() int { return len(q) }
+func (q residueQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].residue < q[j].residue
+}
+func (q residueQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *residueQueue) Push(x any)   { *q = append(*q, x.(residueItem)) }
+func (q *residueQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
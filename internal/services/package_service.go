@@ -1,18 +1,44 @@
 package services
 
 import (
+	"Ship_Manager/internal/events"
+	"Ship_Manager/internal/rates"
 	"Ship_Manager/internal/repositories"
+	"encoding/json"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 )
 
 type PackSize int
 
 // CalculationResult represents the result of a pack calculation
 type CalculationResult struct {
-	Packs       map[int]int `json:"packs"`       // Map of pack sizes to the number of packs of that size
-	Total       int         `json:"total"`       // Total number of items that will be shipped
-	OrderSize   int         `json:"orderSize"`   // Original order size
-	ExcessItems int         `json:"excessItems"` // Number of items shipped in excess of the order
-	PacksCount  int         `json:"packsCount"`  // Total number of packs used
+	Packs       map[int]int       `json:"packs"`               // Map of pack sizes to the number of packs of that size
+	Total       int               `json:"total"`               // Total number of items that will be shipped
+	OrderSize   int               `json:"orderSize"`           // Original order size
+	ExcessItems int               `json:"excessItems"`         // Number of items shipped in excess of the order
+	PacksCount  int               `json:"packsCount"`          // Total number of packs used
+	Shipments   []Shipment        `json:"shipments,omitempty"` // Split of the packs into shipments, when limits apply
+	Levels      []LevelCount      `json:"levels,omitempty"`    // Packs by packaging level, largest first, when the catalogue has levels
+	Shipping    *ShippingEstimate `json:"shipping,omitempty"`  // Cost of shipping the packs, when requested
+
+	CatalogueVersion int `json:"catalogueVersion,omitempty"` // Catalogue version the result was calculated against
+}
+
+// NewCalculationResult summarises the packs chosen for an order.
+func NewCalculationResult(orderSize int, packs map[int]int) CalculationResult {
+	result := CalculationResult{
+		Packs:     packs,
+		OrderSize: orderSize,
+	}
+	for size, count := range packs {
+		result.Total += size * count
+		result.PacksCount += count
+	}
+	result.ExcessItems = max(result.Total-orderSize, 0)
+	return result
 }
 
 type PackCalculator struct {
@@ -25,100 +51,357 @@ type PackageService interface {
 	// It returns an error if the pack size already exists.
 	AddPack(size int) error
 
+	// RemovePack removes a pack size from the available pack sizes.
+	// It returns an error if the pack size does not exist.
+	RemovePack(size int) error
+
 	// ClearPacks removes all pack sizes from the service.
 	ClearPacks()
 
-	// GetPackSizes returns a slice of all available pack sizes, sorted in descending order.
+	// GetPackSizes returns a slice of the pack sizes available now, sorted in descending order.
 	GetPackSizes() []int
 
+	// GetPackSizesAt returns the pack sizes available at the given time, sorted in descending order.
+	GetPackSizesAt(t time.Time) []int
+
+	// AddScheduledPack adds a pack size that is only available between the schedule's
+	// effective dates. It returns an error if the pack size already exists or the
+	// schedule ends before it starts.
+	AddScheduledPack(size int, schedule repositories.Schedule) error
+
+	// SchedulePackRemoval makes a pack size unavailable from the given time on.
+	// It returns an error if the pack size does not exist or the time is not after
+	// the size becomes available.
+	SchedulePackRemoval(size int, at time.Time) error
+
+	// GetPackSchedules returns the availability windows of all pack sizes that have one.
+	GetPackSchedules() map[int]repositories.Schedule
+
 	// CalculatePacks determines the optimal combination of packs for a given order size.
 	// It returns a map where the keys are pack sizes and the values are the number of packs needed.
-	CalculatePacks(order int) map[int]int
+	// Results are looked up in a table that is rebuilt whenever the pack sizes change.
+	// It returns a *RuleError if the pack rules cannot be met.
+	CalculatePacks(order int) (map[int]int, error)
+
+	// Calculate determines the optimal packs for an order like CalculatePacks and records
+	// the catalogue version it used. It returns a *RuleError if the pack rules cannot be met.
+	Calculate(order int) (CalculationResult, error)
+
+	// CalculateAt is like Calculate but uses the pack sizes and rules in effect on the ship date.
+	CalculateAt(order int, shipDate time.Time) (CalculationResult, error)
+
+	// CalculateExact returns the fewest packs adding up to exactly the order.
+	// If there is none it returns a *NoExactFitError wrapping ErrNoExactFit
+	// with the nearest quantities that fit exactly.
+	CalculateExact(order int) (map[int]int, error)
+
+	// CompareCatalogue evaluates a sample of orders against the current catalogue and
+	// a proposed set of pack sizes, without changing the current catalogue.
+	// Costs give the price of one pack by size and may be empty. At most MaxPackSizes
+	// sizes, which must fit a table, and MaxSampleOrders orders are accepted.
+	// Both catalogues are measured by their sizes alone, without pack rules, and
+	// orders a catalogue cannot calculate are counted as unsolvable.
+	CompareCatalogue(proposed []int, orders []int, costs map[int]float64) (WhatIfResult, error)
+
+	// OptimizeCatalogue searches for the catalogues of K pack sizes that minimise
+	// the excess or pack count over a sample of orders, best first. Catalogues too large
+	// to calculate with are skipped, and large searches stop early with the best found.
+	OptimizeCatalogue(req OptimizeRequest) ([]Recommendation, error)
+
+	// ListVersions returns the versions of the catalogue kept in its history, oldest first.
+	ListVersions() []repositories.CatalogueVersion
+
+	// DiffVersions compares two catalogue versions.
+	// It returns an error if either version does not exist.
+	DiffVersions(from, to int) (VersionDiff, error)
+
+	// RollbackCatalogue restores the sizes and rules of an earlier version as a new version.
+	// It returns an error if the version does not exist.
+	RollbackCatalogue(id int) error
+
+	// SetPackRule sets the minimum and maximum usage of a pack size, or disables it.
+	// It returns an error if the pack size does not exist or the rule is invalid.
+	SetPackRule(size int, rule repositories.PackRule) error
+
+	// GetPackRules returns the rules of all pack sizes that have one.
+	GetPackRules() map[int]repositories.PackRule
+
+	// AddPackagingLevel names a pack size as quantity units of another level, or of
+	// single items when unit is empty, adding the size if the catalogue does not have it.
+	// It returns an error if the level is invalid, already exists or its unit does not exist.
+	AddPackagingLevel(name string, quantity int, unit string) error
+
+	// RemovePackagingLevel removes a packaging level together with its pack size.
+	// It returns an error if the level does not exist or another level contains it.
+	RemovePackagingLevel(name string) error
+
+	// GetPackagingLevels returns the packaging levels, largest first.
+	GetPackagingLevels() []repositories.PackagingLevel
+
+	// CheckRules returns a *RuleError explaining why the pack rules cannot be met
+	// for an order, or nil if they can.
+	CheckRules(order int) error
+
+	// ExplainPacks calculates the packs for an order together with the runner-up
+	// combinations and the rule that ranked the chosen one above them.
+	// It returns an error like Calculate if the order cannot be calculated.
+	ExplainPacks(order int) (Explanation, error)
+
+	// CalculateShipments calculates the packs for an order using only sizes that fit in a
+	// shipment, and splits them into shipments that respect the given limits.
+	// It returns ErrTooManyShipments if that takes more than MaxShipments shipments.
+	CalculateShipments(order int, limits ShipmentLimits) (CalculationResult, error)
+
+	// LoadContainers assigns the given packs, or the packs calculated for the order,
+	// to containers by volume and weight using FirstFitDecreasing, at most MaxContainerPacks of them.
+	LoadContainers(req ContainerRequest) (ContainerResult, error)
+
+	// CalculateShipping calculates the packs for an order like Calculate and quotes the cost of
+	// shipping them with every carrier. With MinimiseLandedCost it picks the packs with the
+	// lowest packaging and shipping cost instead. It returns an error if no rate tables are
+	// loaded, the options are invalid or, when minimising, no carrier ships to the zone.
+	CalculateShipping(order int, options ShippingOptions) (CalculationResult, error)
+
+	// CalculateOrder calculates the packs for every line of a multi-line order,
+	// each against its own catalogue, and consolidates the totals. Every line is checked
+	// before any is calculated: orders have at most MaxOrderLines lines and lines at most
+	// MaxPackSizes sizes, which must fit a table of the size the catalogue is held to.
+	CalculateOrder(lines []OrderLine) (OrderResult, error)
+
+	// Subscribe registers for catalogue change and large calculation events.
+	// It returns the event channel and a function that ends the subscription.
+	Subscribe() (<-chan events.Event, func())
+
+	// HandleEvents calls handle with every event as it is published. Unlike subscribers,
+	// which miss events while their buffer is full, the handler gets every one.
+	// It returns a function that removes the handler.
+	HandleEvents(handle func(events.Event)) func()
+
+	// Close stops watching the pack schedules, so PackSizesChangedEvent is no longer
+	// published when a scheduled size becomes available or is withdrawn.
+	Close()
 }
 
+// PackSizesChangedEvent is published with the new pack sizes whenever the catalogue changes.
+const PackSizesChangedEvent = "packSizesChanged"
+
+// LargeCalculationEvent is published with the result of a calculation for a large order.
+const LargeCalculationEvent = "largeCalculation"
+
 type packageService struct {
 	repository repositories.PackageRepository
+
+	mu         sync.RWMutex
+	tables     []*packTable  // Tables of the size sets calculated with, most recently used first
+	building   []*tableBuild // Tables being built, which other calculations wait for
+	rebuilding bool          // A background rebuild is running
+	stale      bool          // The catalogue changed while the background rebuild was running
+	boundary   *time.Timer   // Fires at the next schedule boundary
+	closed     bool
+
+	events         *events.Hub
+	largeOrderSize int
+	rates          rates.Tables
+}
+
+// Option configures a PackageService.
+type Option func(*packageService)
+
+// WithLargeOrderThreshold publishes LargeCalculationEvent for every calculation
+// of an order with at least the given number of items.
+func WithLargeOrderThreshold(items int) Option {
+	return func(ps *packageService) {
+		ps.largeOrderSize = items
+	}
 }
 
 // NewPackageService creates a new instance of PackageService with the given repository.
-func NewPackageService(repository repositories.PackageRepository) PackageService {
-	return &packageService{
+func NewPackageService(repository repositories.PackageRepository, opts ...Option) PackageService {
+	ps := &packageService{
 		repository: repository,
+		events:     events.NewHub(),
 	}
+	for _, opt := range opts {
+		opt(ps)
+	}
+	ps.watchSchedules()
+	return ps
 }
 
 func (ps *packageService) AddPack(size int) error {
-	return ps.repository.Add(size)
+	if err := ps.checkCatalogueSize(size); err != nil {
+		return err
+	}
+	if err := ps.repository.Add(size); err != nil {
+		return err
+	}
+	ps.catalogueChanged()
+	return nil
+}
+
+func (ps *packageService) RemovePack(size int) error {
+	if err := ps.repository.Remove(size); err != nil {
+		return err
+	}
+	ps.catalogueChanged()
+	return nil
 }
 
 func (ps *packageService) ClearPacks() {
 	ps.repository.DeleteAll()
+	ps.catalogueChanged()
+}
+
+func (ps *packageService) Subscribe() (<-chan events.Event, func()) {
+	return ps.events.Subscribe()
+}
+
+func (ps *packageService) HandleEvents(handle func(events.Event)) func() {
+	return ps.events.Handle(handle)
+}
+
+func (ps *packageService) Close() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.closed = true
+	if ps.boundary != nil {
+		ps.boundary.Stop()
+	}
+}
+
+// checkCatalogueSize returns ErrTableTooLarge if adding the size to every size of the
+// catalogue, scheduled ones included, would need a table over maxTableSize.
+func (ps *packageService) checkCatalogueSize(size int) error {
+	sizes := append(ps.repository.GetSizes(), size)
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	return checkTableSize(slices.Compact(sizes))
+}
+
+// catalogueChanged rebuilds the pack table and notifies subscribers of the new sizes.
+// It also runs when the sizes available change at a schedule boundary.
+func (ps *packageService) catalogueChanged() {
+	ps.rebuildTable()
+	ps.watchSchedules()
+
+	data, _ := json.Marshal(ps.GetPackSizes())
+	ps.events.Publish(events.Event{Name: PackSizesChangedEvent, Data: string(data)})
+}
+
+// calculated announces the result of a calculation for a large order.
+func (ps *packageService) calculated(result CalculationResult) {
+	if ps.largeOrderSize <= 0 || result.OrderSize < ps.largeOrderSize {
+		return
+	}
+	data, _ := json.Marshal(result)
+	ps.events.Publish(events.Event{Name: LargeCalculationEvent, Data: string(data)})
 }
 
 func (ps *packageService) GetPackSizes() []int {
-	return ps.repository.GetSizes()
+	return ps.repository.GetSizesAt(time.Now())
 }
 
-func (ps *packageService) CalculatePacks(orderSize int) map[int]int {
-	packSizes := ps.repository.GetSizes()
-	largestPack := packSizes[0]
-	switch {
-	case len(packSizes) == 0:
-		{
-			return map[int]int{}
-		}
-		// Handle case where order is smaller than the smallest pack
-	case orderSize < packSizes[len(packSizes)-1]:
-		{
-			return map[int]int{packSizes[len(packSizes)-1]: 1}
+func (ps *packageService) CalculatePacks(orderSize int) (map[int]int, error) {
+	c := ps.activeCatalogue()
+	return ps.solveWith(c.sizes, c.rules, orderSize)
+}
 
-		}
+func (ps *packageService) Calculate(orderSize int) (CalculationResult, error) {
+	return ps.CalculateAt(orderSize, time.Now())
+}
+
+// tableBuild is a table under construction. done is closed once table is set.
+type tableBuild struct {
+	sizes []int
+	table *packTable
+	done  chan struct{}
+}
+
+// packTable returns the precomputed table for the given sizes.
+// If no table is cached for them, as before the background rebuild has caught up
+// or for a ship date with other sizes, the table is built and cached. The lock is not
+// held while building, and calculations that need a table being built wait for it.
+func (ps *packageService) packTable(packSizes []int) *packTable {
+	ps.mu.RLock()
+	i := slices.IndexFunc(ps.tables, func(table *packTable) bool { return table.matches(packSizes) })
+	if i == 0 {
+		table := ps.tables[0]
+		ps.mu.RUnlock()
+		return table
 	}
+	ps.mu.RUnlock()
 
-	// Initialize dp array
-	dp := make([]map[int]int, orderSize+largestPack+1)
-	for i := range dp {
-		dp[i] = make(map[int]int)
-	}
-
-	// Fill dp array
-	for i := 1; i <= orderSize+largestPack; i++ {
-		for _, size := range packSizes {
-			switch {
-			case i < size:
-				continue
-			case i == size:
-				dp[i] = map[int]int{size: 1}
-			default:
-				remainder := i - size
-				if len(dp[remainder]) > 0 {
-					newSolution, _ := copyMap(dp[remainder])
-					newSolution[size]++
-
-					if len(dp[i]) == 0 || len(newSolution) < len(dp[i]) {
-						dp[i] = newSolution
-					}
-				}
-			}
+	ps.mu.Lock()
+	if table := ps.cachedTable(packSizes); table != nil {
+		ps.mu.Unlock()
+		return table
+	}
+	for _, build := range ps.building {
+		if slices.Equal(build.sizes, packSizes) {
+			ps.mu.Unlock()
+			<-build.done
+			return build.table
 		}
 	}
+	build := &tableBuild{sizes: slices.Clone(packSizes), done: make(chan struct{})}
+	ps.building = append(ps.building, build)
+	ps.mu.Unlock()
 
-	// Find the smallest valid solution
-	for i := orderSize; i <= orderSize+largestPack; i++ {
-		if len(dp[i]) > 0 {
-			return dp[i]
+	build.table = newPackTable(build.sizes)
+
+	ps.mu.Lock()
+	ps.building = slices.DeleteFunc(ps.building, func(b *tableBuild) bool { return b == build })
+	ps.storeTable(build.table)
+	ps.mu.Unlock()
+	close(build.done)
+	return build.table
+}
+
+// cachedTable returns the cached table for the sizes, moving it to the front,
+// or nil if there is none. The caller must hold the write lock.
+func (ps *packageService) cachedTable(packSizes []int) *packTable {
+	for i, table := range ps.tables {
+		if table.matches(packSizes) {
+			copy(ps.tables[1:i+1], ps.tables[:i])
+			ps.tables[0] = table
+			return table
 		}
 	}
+	return nil
+}
 
-	// If still no solution, return the minimum number of largest packs
-	return map[int]int{largestPack: (orderSize + largestPack - 1) / largestPack}
+// rebuildTable recomputes the table in the background after the catalogue changes.
+// Changes made while a rebuild runs are coalesced: the running rebuild goes round
+// once more for the latest sizes instead of another one being started.
+func (ps *packageService) rebuildTable() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.rebuilding {
+		ps.stale = true
+		return
+	}
+	ps.rebuilding = true
+
+	go func() {
+		for {
+			ps.packTable(ps.GetPackSizes())
+
+			ps.mu.Lock()
+			if !ps.stale {
+				ps.rebuilding = false
+				ps.mu.Unlock()
+				return
+			}
+			ps.stale = false
+			ps.mu.Unlock()
+		}
+	}()
 }
 
-func copyMap(originalMap map[int]int) (newSolution map[int]int, totalSpace int) {
-	newSolution = make(map[int]int)
-	for k, v := range originalMap {
-		newSolution[k] = v
-		totalSpace += v
+// storeTable caches a table as the most recently used one, dropping the least recently
+// used beyond maxCachedTables. The caller must hold the write lock.
+func (ps *packageService) storeTable(table *packTable) {
+	ps.tables = slices.Insert(ps.tables, 0, table)
+	if len(ps.tables) > maxCachedTables {
+		ps.tables = ps.tables[:maxCachedTables]
 	}
-	return
 }
@@ -1,10 +1,17 @@
 package services_test
 
 import (
+	"Ship_Manager/internal/rates"
 	"Ship_Manager/internal/repositories"
 	"Ship_Manager/internal/services"
+	"encoding/json"
+	"errors"
+	"math"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestPackageService(t *testing.T) {
@@ -44,6 +51,27 @@ func TestPackageService(t *testing.T) {
 		}
 	})
 
+	t.Run("RemovePack", func(t *testing.T) {
+		service.ClearPacks()
+		service.AddPack(250)
+		service.AddPack(500)
+
+		if err := service.RemovePack(500); err != nil {
+			t.Errorf("Failed to remove pack: %v", err)
+		}
+		if err := service.RemovePack(500); err != repositories.ErrSizeNotFound {
+			t.Errorf("Expected ErrSizeNotFound, got %v", err)
+		}
+
+		sizes := service.GetPackSizes()
+		if len(sizes) != 1 || sizes[0] != 250 {
+			t.Errorf("Expected pack sizes [250], got %v", sizes)
+		}
+		if result, err := service.CalculatePacks(400); err != nil || !reflect.DeepEqual(result, map[int]int{250: 2}) {
+			t.Errorf("Expected the removed size to be ignored, got %v, %v", result, err)
+		}
+	})
+
 	t.Run("GetPackSizes", func(t *testing.T) {
 		service.ClearPacks()
 		service.AddPack(250)
@@ -77,9 +105,9 @@ func TestPackageService(t *testing.T) {
 		}
 
 		for _, tc := range testCases {
-			result := service.CalculatePacks(tc.order)
-			if !reflect.DeepEqual(result, tc.expected) {
-				t.Errorf("For order %d, expected %v, got %v", tc.order, tc.expected, result)
+			result, err := service.CalculatePacks(tc.order)
+			if err != nil || !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("For order %d, expected %v, got %v, %v", tc.order, tc.expected, result, err)
 			}
 		}
 	})
@@ -97,10 +125,1337 @@ func TestPackageService(t *testing.T) {
 			{18, map[int]int{5: 4}},
 		}
 		for _, tc := range testCases {
-			result := service.CalculatePacks(tc.order)
-			if !reflect.DeepEqual(result, tc.expected) {
-				t.Errorf("For order %d, expected %v, got %v", tc.order, tc.expected, result)
+			result, err := service.CalculatePacks(tc.order)
+			if err != nil || !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("For order %d, expected %v, got %v, %v", tc.order, tc.expected, result, err)
+			}
+		}
+	})
+
+	t.Run("ExplainPacks", func(t *testing.T) {
+		service.ClearPacks()
+		service.AddPack(250)
+		service.AddPack(500)
+		service.AddPack(1000)
+
+		explanation, err := service.ExplainPacks(501)
+		if err != nil {
+			t.Fatalf("ExplainPacks failed: %v", err)
+		}
+		if !reflect.DeepEqual(explanation.Chosen.Packs, map[int]int{500: 1, 250: 1}) {
+			t.Errorf("Expected {500:1 250:1} to be chosen, got %v", explanation.Chosen.Packs)
+		}
+		if explanation.Rule != services.RuleFewestPacks {
+			t.Errorf("Expected rule %q, got %q", services.RuleFewestPacks, explanation.Rule)
+		}
+
+		expected := []struct {
+			packs map[int]int
+			rule  string
+		}{
+			{map[int]int{250: 3}, services.RuleFewestPacks},
+			{map[int]int{1000: 1}, services.RuleLeastExcess},
+			{map[int]int{1000: 1, 250: 1}, services.RuleLeastExcess},
+		}
+		if len(explanation.Candidates) != len(expected) {
+			t.Fatalf("Expected %d candidates, got %+v", len(expected), explanation.Candidates)
+		}
+		for i, candidate := range explanation.Candidates {
+			if !reflect.DeepEqual(candidate.Packs, expected[i].packs) || candidate.Rule != expected[i].rule {
+				t.Errorf("Candidate %d: expected %v (%s), got %v (%s)",
+					i, expected[i].packs, expected[i].rule, candidate.Packs, candidate.Rule)
+			}
+		}
+
+		service.ClearPacks()
+		service.AddPack(5)
+		service.AddPack(12)
+
+		explanation, _ = service.ExplainPacks(18)
+		if explanation.Rule != services.RuleLeastExcess {
+			t.Errorf("Expected rule %q, got %q", services.RuleLeastExcess, explanation.Rule)
+		}
+		if len(explanation.Candidates) == 0 || explanation.Candidates[0].ExcessItems <= explanation.Chosen.ExcessItems {
+			t.Errorf("Expected runner-ups with more excess, got %+v", explanation.Candidates)
+		}
+
+		// A huge order with one size stops enumerating once the budget is spent
+		service.ClearPacks()
+		service.AddPack(1000)
+		start := time.Now()
+		if _, err := service.ExplainPacks(1_000_000_000_000); err != nil {
+			t.Fatalf("ExplainPacks failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("ExplainPacks took %v", elapsed)
+		}
+
+		// Orders that cannot be calculated are errors, not empty explanations
+		service.SetPackRule(1000, repositories.PackRule{MaxCount: 1})
+		var ruleErr *services.RuleError
+		if _, err := service.ExplainPacks(5000); !errors.As(err, &ruleErr) {
+			t.Errorf("Expected a RuleError, got %v", err)
+		}
+	})
+
+	t.Run("CalculatePacks matches a full search", func(t *testing.T) {
+		catalogues := [][]int{
+			{250, 500, 1000, 2000, 5000},
+			{5, 12},
+			{23, 31, 53},
+			{6, 9, 20},
+			{7},
+		}
+
+		for _, catalogue := range catalogues {
+			service.ClearPacks()
+			for _, size := range catalogue {
+				service.AddPack(size)
+			}
+
+			for order := 1; order <= 3000; order++ {
+				result, err := service.CalculatePacks(order)
+				if err != nil {
+					t.Fatalf("Sizes %v, order %d: %v", catalogue, order, err)
+				}
+				total, packs := summarize(result)
+				expectedTotal, expectedPacks := bruteForce(catalogue, order)
+				if total != expectedTotal || packs != expectedPacks {
+					t.Fatalf("Sizes %v, order %d: expected total %d in %d packs, got %v",
+						catalogue, order, expectedTotal, expectedPacks, result)
+				}
 			}
 		}
 	})
+
+	t.Run("Calculations while the catalogue changes", func(t *testing.T) {
+		service.ClearPacks()
+		service.AddPack(250)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for order := 1; order <= 500; order++ {
+					if _, err := service.CalculatePacks(order); err != nil {
+						t.Errorf("Order %d: %v", order, err)
+						return
+					}
+				}
+			}()
+		}
+		for size := 251; size <= 300; size++ {
+			service.AddPack(size)
+			service.RemovePack(size)
+		}
+		wg.Wait()
+
+		if packs, err := service.CalculatePacks(400); err != nil || !reflect.DeepEqual(packs, map[int]int{250: 2}) {
+			t.Errorf("CalculatePacks(400) = %v, %v", packs, err)
+		}
+	})
+}
+
+func TestCatalogueEvents(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+	events, unsubscribe := service.Subscribe()
+	defer unsubscribe()
+
+	service.AddPack(250)
+	service.AddPack(500)
+	service.RemovePack(250)
+	service.ClearPacks()
+
+	for _, expected := range []string{"[250]", "[500,250]", "[500]", "[]"} {
+		event := <-events
+		if event.Name != services.PackSizesChangedEvent || event.Data != expected {
+			t.Errorf("Expected %s event with %s, got %+v", services.PackSizesChangedEvent, expected, event)
+		}
+	}
+
+	// Failed changes are not broadcast
+	service.RemovePack(1000)
+	select {
+	case event := <-events:
+		t.Errorf("Expected no event, got %+v", event)
+	default:
+	}
+}
+
+func TestLargeCalculationEvents(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository(), services.WithLargeOrderThreshold(10000))
+	service.AddPack(500)
+	events, unsubscribe := service.Subscribe()
+	defer unsubscribe()
+
+	service.Calculate(9999)
+	service.Calculate(10001)
+	service.CalculateShipments(12000, services.ShipmentLimits{MaxPacks: 10})
+
+	for _, order := range []int{10001, 12000} {
+		event := <-events
+		var result services.CalculationResult
+		if err := json.Unmarshal([]byte(event.Data), &result); err != nil {
+			t.Fatalf("Invalid event data %q: %v", event.Data, err)
+		}
+		if event.Name != services.LargeCalculationEvent || result.OrderSize != order {
+			t.Errorf("Expected %s event for %d, got %+v", services.LargeCalculationEvent, order, event)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Errorf("Expected no more events, got %+v", event)
+	default:
+	}
+}
+
+func TestCalculateShipments(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+	service.AddPack(250)
+	service.AddPack(500)
+	service.AddPack(1000)
+
+	testCases := []struct {
+		name      string
+		order     int
+		limits    services.ShipmentLimits
+		shipments []map[int]int
+	}{
+		{"Item limit", 2600, services.ShipmentLimits{MaxItems: 1000}, []map[int]int{{1000: 1}, {1000: 1}, {500: 1, 250: 1}}},
+		{"Pack limit", 1750, services.ShipmentLimits{MaxPacks: 2}, []map[int]int{{1000: 1, 500: 1}, {250: 1}}},
+		{"Item limit below the largest pack", 600, services.ShipmentLimits{MaxItems: 400}, []map[int]int{{250: 1}, {250: 1}, {250: 1}}},
+		{"No limits", 1750, services.ShipmentLimits{}, []map[int]int{{1000: 1, 500: 1, 250: 1}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.CalculateShipments(tc.order, tc.limits)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var shipments []map[int]int
+			items := 0
+			for _, shipment := range result.Shipments {
+				shipments = append(shipments, shipment.Packs)
+				items += shipment.Total
+			}
+			if !reflect.DeepEqual(shipments, tc.shipments) {
+				t.Errorf("Expected shipments %v, got %v", tc.shipments, shipments)
+			}
+			if items != result.Total {
+				t.Errorf("Shipments hold %d items, result total is %d", items, result.Total)
+			}
+		})
+	}
+
+	t.Run("Limit smaller than every pack", func(t *testing.T) {
+		_, err := service.CalculateShipments(600, services.ShipmentLimits{MaxItems: 100})
+		if err != services.ErrShipmentLimitTooSmall {
+			t.Errorf("Expected ErrShipmentLimitTooSmall, got %v", err)
+		}
+	})
+
+	t.Run("Negative limit", func(t *testing.T) {
+		_, err := service.CalculateShipments(600, services.ShipmentLimits{MaxPacks: -1})
+		if err != services.ErrInvalidShipmentLimits {
+			t.Errorf("Expected ErrInvalidShipmentLimits, got %v", err)
+		}
+	})
+
+	t.Run("Many packs per shipment", func(t *testing.T) {
+		result, err := service.CalculateShipments(50_000_000, services.ShipmentLimits{MaxPacks: 10_000})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Shipments) != 5 || result.Shipments[4].Packs[1000] != 10_000 {
+			t.Errorf("Expected 5 shipments of 10000 packs, got %d", len(result.Shipments))
+		}
+	})
+
+	t.Run("Too many shipments", func(t *testing.T) {
+		start := time.Now()
+		_, err := service.CalculateShipments(1_000_000_000, services.ShipmentLimits{MaxPacks: 1})
+		if err != services.ErrTooManyShipments {
+			t.Errorf("Expected ErrTooManyShipments, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Rejecting the order took %v", elapsed)
+		}
+	})
+}
+
+func TestFirstFitDecreasing(t *testing.T) {
+	dimensions := map[int]services.PackDimensions{
+		250:  {Volume: 10, Weight: 5},
+		500:  {Volume: 20, Weight: 10},
+		1000: {Volume: 40, Weight: 30},
+	}
+	box := services.Container{Name: "box", Volume: 50, MaxWeight: 40}
+	pallet := services.Container{Name: "pallet", Volume: 200}
+
+	testCases := []struct {
+		name       string
+		packs      map[int]int
+		containers []services.Container
+		loads      []map[int]int
+		volume     float64 // Overall volume utilisation
+	}{
+		{"Largest first", map[int]int{250: 1, 500: 1, 1000: 2}, []services.Container{box},
+			[]map[int]int{{1000: 1, 250: 1}, {1000: 1}, {500: 1}}, 73.33},
+		{"Weight limit", map[int]int{500: 5}, []services.Container{{Name: "box", Volume: 100, MaxWeight: 30}},
+			[]map[int]int{{500: 3}, {500: 2}}, 50},
+		{"First type the pack fits in", map[int]int{250: 2}, []services.Container{box, pallet},
+			[]map[int]int{{250: 2}}, 40},
+		{"Falls back to a larger type", map[int]int{1000: 1, 250: 2}, []services.Container{{Name: "small", Volume: 30}, pallet},
+			[]map[int]int{{1000: 1, 250: 2}}, 30},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := services.FirstFitDecreasing(tc.packs, dimensions, tc.containers)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var loads []map[int]int
+			for _, load := range result.Containers {
+				loads = append(loads, load.Packs)
+			}
+			if !reflect.DeepEqual(loads, tc.loads) {
+				t.Errorf("Expected loads %v, got %v", tc.loads, loads)
+			}
+			if result.VolumeUtilisation != tc.volume {
+				t.Errorf("Expected volume utilisation %v, got %v", tc.volume, result.VolumeUtilisation)
+			}
+		})
+	}
+
+	t.Run("Utilisation", func(t *testing.T) {
+		result, _ := services.FirstFitDecreasing(map[int]int{1000: 1, 250: 1}, dimensions, []services.Container{box})
+		expected := []services.ContainerLoad{{Container: "box", Packs: map[int]int{1000: 1, 250: 1}, Volume: 50, Weight: 35, VolumeUtilisation: 100, WeightUtilisation: 87.5}}
+		if !reflect.DeepEqual(result.Containers, expected) || result.WeightUtilisation != 87.5 {
+			t.Errorf("Unexpected result %+v", result)
+		}
+	})
+
+	errorCases := []struct {
+		name       string
+		packs      map[int]int
+		containers []services.Container
+		err        error
+	}{
+		{"No containers", map[int]int{250: 1}, nil, services.ErrNoContainers},
+		{"Invalid container", map[int]int{250: 1}, []services.Container{{Name: "flat"}}, services.ErrInvalidContainer},
+		{"Missing dimensions", map[int]int{750: 1}, []services.Container{box}, services.ErrMissingDimensions},
+		{"Pack too large", map[int]int{1000: 1}, []services.Container{{Name: "envelope", Volume: 15}}, services.ErrPackTooLarge},
+		{"Pack too heavy", map[int]int{1000: 1}, []services.Container{{Name: "bag", Volume: 100, MaxWeight: 20}}, services.ErrPackTooLarge},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := services.FirstFitDecreasing(tc.packs, dimensions, tc.containers)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+
+	t.Run("Many packs", func(t *testing.T) {
+		result, err := services.FirstFitDecreasing(map[int]int{250: services.MaxContainerPacks}, dimensions, []services.Container{pallet})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Containers) != services.MaxContainerPacks/20 || result.Containers[0].Packs[250] != 20 {
+			t.Errorf("Expected %d pallets of 20 packs, got %d", services.MaxContainerPacks/20, len(result.Containers))
+		}
+
+		_, err = services.FirstFitDecreasing(map[int]int{250: services.MaxContainerPacks, 500: 1}, dimensions, []services.Container{pallet})
+		if err != services.ErrTooManyPacks {
+			t.Errorf("Expected ErrTooManyPacks, got %v", err)
+		}
+	})
+
+	t.Run("Error names the size", func(t *testing.T) {
+		_, err := services.FirstFitDecreasing(map[int]int{750: 1}, dimensions, []services.Container{box})
+		var sizeErr *services.PackSizeError
+		if !errors.As(err, &sizeErr) || sizeErr.Size != 750 {
+			t.Errorf("Expected a PackSizeError for 750, got %v", err)
+		}
+	})
+}
+
+func TestLoadContainers(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+	service.AddPack(250)
+	service.AddPack(500)
+	dimensions := map[int]services.PackDimensions{250: {Volume: 1, Weight: 1}, 500: {Volume: 2, Weight: 2}}
+	containers := []services.Container{{Name: "box", Volume: 4}}
+
+	result, err := service.LoadContainers(services.ContainerRequest{Order: 1001, Dimensions: dimensions, Containers: containers})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Packs, map[int]int{500: 2, 250: 1}) || len(result.Containers) != 2 || result.CatalogueVersion != 3 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	// Given packs are loaded as they are, without a calculation
+	result, err = service.LoadContainers(services.ContainerRequest{Packs: map[int]int{250: 4}, Dimensions: dimensions, Containers: containers})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Containers) != 1 || result.VolumeUtilisation != 100 || result.CatalogueVersion != 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if _, err := service.LoadContainers(services.ContainerRequest{Containers: containers}); err != services.ErrInvalidQuantity {
+		t.Errorf("Expected ErrInvalidQuantity, got %v", err)
+	}
+}
+
+func TestCalculateShipping(t *testing.T) {
+	tables := rates.Tables{
+		{Carrier: "Swift", Bands: []rates.Band{{Zone: "EU", MaxWeight: 300, Price: 10}, {Zone: "EU", MaxWeight: 600, Price: 20}, {Zone: "EU", MaxWeight: 1200, Price: 40}}},
+		{Carrier: "Cargo", Bands: []rates.Band{{Zone: "EU", MaxWeight: 2000, Price: 25}}},
+	}
+	service := services.NewPackageService(repositories.NewPackageRepository(), services.WithRateTables(tables))
+	service.AddPack(250)
+	service.AddPack(500)
+	service.AddPack(1000)
+	options := services.ShippingOptions{Zone: "EU", ItemWeight: 0.5, PackCost: 10}
+
+	t.Run("Quotes", func(t *testing.T) {
+		result, err := service.CalculateShipping(501, options)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := &services.ShippingEstimate{
+			Zone:          "EU",
+			Weight:        375,
+			PackagingCost: 20,
+			Quotes:        []rates.Quote{{Carrier: "Swift", Zone: "EU", MaxWeight: 600, Price: 20}, {Carrier: "Cargo", Zone: "EU", MaxWeight: 2000, Price: 25}},
+			LandedCost:    40,
+		}
+		if !reflect.DeepEqual(result.Packs, map[int]int{500: 1, 250: 1}) || !reflect.DeepEqual(result.Shipping, expected) {
+			t.Errorf("Unexpected result %+v, shipping %+v", result, result.Shipping)
+		}
+		if result.CatalogueVersion != 4 {
+			t.Errorf("Expected catalogue version 4, got %d", result.CatalogueVersion)
+		}
+	})
+
+	t.Run("Minimise landed cost", func(t *testing.T) {
+		options := options
+		options.MinimiseLandedCost = true
+		result, err := service.CalculateShipping(501, options)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// One pack of 1000 ships in the same band as 750 items and saves a pack
+		if !reflect.DeepEqual(result.Packs, map[int]int{1000: 1}) || result.Shipping.LandedCost != 30 || result.ExcessItems != 499 {
+			t.Errorf("Unexpected result %+v, shipping %+v", result, result.Shipping)
+		}
+	})
+
+	t.Run("Minimising respects the rules", func(t *testing.T) {
+		service := services.NewPackageService(repositories.NewPackageRepository(), services.WithRateTables(tables))
+		service.AddPack(250)
+		service.AddPack(500)
+		service.AddPack(1000)
+		service.SetPackRule(1000, repositories.PackRule{Disabled: true})
+
+		options := options
+		options.MinimiseLandedCost = true
+		result, err := service.CalculateShipping(501, options)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, ok := result.Packs[1000]; ok {
+			t.Errorf("Expected no disabled packs, got %v", result.Packs)
+		}
+	})
+
+	t.Run("Zone without carriers", func(t *testing.T) {
+		options := services.ShippingOptions{Zone: "APAC", ItemWeight: 1}
+		result, err := service.CalculateShipping(501, options)
+		if err != nil || len(result.Shipping.Quotes) != 0 || result.Shipping.LandedCost != 0 {
+			t.Errorf("Expected an estimate without quotes, got %+v, %v", result.Shipping, err)
+		}
+
+		options.MinimiseLandedCost = true
+		if _, err := service.CalculateShipping(501, options); err != services.ErrNoQuote {
+			t.Errorf("Expected ErrNoQuote, got %v", err)
+		}
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		for _, options := range []services.ShippingOptions{{ItemWeight: 1}, {Zone: "EU"}, {Zone: "EU", ItemWeight: 1, PackCost: -1}} {
+			if _, err := service.CalculateShipping(501, options); err != services.ErrInvalidShippingOptions {
+				t.Errorf("%+v: expected ErrInvalidShippingOptions, got %v", options, err)
+			}
+		}
+	})
+
+	t.Run("No rate tables", func(t *testing.T) {
+		service := services.NewPackageService(repositories.NewPackageRepository())
+		if _, err := service.CalculateShipping(501, options); err != services.ErrNoRateTables {
+			t.Errorf("Expected ErrNoRateTables, got %v", err)
+		}
+	})
+}
+
+func TestCalculateOrder(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+	service.AddPack(250)
+	service.AddPack(500)
+
+	result, err := service.CalculateOrder([]services.OrderLine{
+		{SKU: "BOLT", Quantity: 501},
+		{SKU: "WATER", Quantity: 30, Sizes: []int{12, 24}, Costs: map[int]float64{12: 1.5, 24: 2.5}},
+		{SKU: "CAPS", Quantity: 20, Sizes: []int{24, 12, 12}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		sku   string
+		packs map[int]int
+		cost  float64
+	}{
+		{"BOLT", map[int]int{500: 1, 250: 1}, 0},
+		{"WATER", map[int]int{24: 1, 12: 1}, 4},
+		{"CAPS", map[int]int{24: 1}, 0},
+	}
+	for i, line := range result.Lines {
+		if line.SKU != expected[i].sku || !reflect.DeepEqual(line.Packs, expected[i].packs) || line.Cost != expected[i].cost {
+			t.Errorf("Line %d: expected %s %v costing %v, got %s %v costing %v",
+				i+1, expected[i].sku, expected[i].packs, expected[i].cost, line.SKU, line.Packs, line.Cost)
+		}
+	}
+
+	if result.TotalItems != 750+36+24 || result.TotalExcess != 249+6+4 || result.TotalPacks != 5 || result.TotalCost != 4 {
+		t.Errorf("Unexpected totals %+v", result)
+	}
+
+	t.Run("Invalid line", func(t *testing.T) {
+		_, err := service.CalculateOrder([]services.OrderLine{
+			{SKU: "BOLT", Quantity: 501},
+			{SKU: "WATER", Quantity: 0},
+		})
+		var lineErr *services.LineError
+		if !errors.As(err, &lineErr) || lineErr.Line != 2 || !errors.Is(err, services.ErrInvalidQuantity) {
+			t.Errorf("Expected an invalid quantity error on line 2, got %v", err)
+		}
+	})
+
+	t.Run("No lines", func(t *testing.T) {
+		if _, err := service.CalculateOrder(nil); err != services.ErrNoOrderLines {
+			t.Errorf("Expected ErrNoOrderLines, got %v", err)
+		}
+	})
+
+	t.Run("Limits", func(t *testing.T) {
+		lines := make([]services.OrderLine, services.MaxOrderLines+1)
+		for i := range lines {
+			lines[i] = services.OrderLine{SKU: "BOLT", Quantity: 1}
+		}
+		if _, err := service.CalculateOrder(lines); err != services.ErrTooManyOrderLines {
+			t.Errorf("Expected ErrTooManyOrderLines, got %v", err)
+		}
+
+		sizes := make([]int, services.MaxPackSizes+1)
+		for i := range sizes {
+			sizes[i] = i + 1
+		}
+		testCases := []struct {
+			line     services.OrderLine
+			expected error
+		}{
+			{services.OrderLine{SKU: "MANY", Quantity: 1, Sizes: sizes}, services.ErrTooManyPackSizes},
+			{services.OrderLine{SKU: "CLOSE", Quantity: 1, Sizes: []int{20000, 19999}}, services.ErrTableTooLarge},
+			{services.OrderLine{SKU: "HUGE", Quantity: 1, Sizes: []int{1 << 40}}, services.ErrTableTooLarge},
+		}
+		for _, tc := range testCases {
+			_, err := service.CalculateOrder([]services.OrderLine{{SKU: "BOLT", Quantity: 501}, tc.line})
+			var lineErr *services.LineError
+			if !errors.As(err, &lineErr) || lineErr.Line != 2 || !errors.Is(err, tc.expected) {
+				t.Errorf("%s: expected %v on line 2, got %v", tc.line.SKU, tc.expected, err)
+			}
+		}
+	})
+}
+
+func TestPackRules(t *testing.T) {
+	newService := func() services.PackageService {
+		service := services.NewPackageService(repositories.NewPackageRepository())
+		service.AddPack(250)
+		service.AddPack(500)
+		service.AddPack(1000)
+		return service
+	}
+
+	testCases := []struct {
+		name     string
+		rules    map[int]repositories.PackRule
+		order    int
+		expected map[int]int
+	}{
+		{"Maximum count", map[int]repositories.PackRule{1000: {MaxCount: 1}}, 2600, map[int]int{1000: 1, 500: 3, 250: 1}},
+		{"Minimum count", map[int]repositories.PackRule{250: {MinCount: 2}}, 1000, map[int]int{500: 1, 250: 2}},
+		{"Disabled size", map[int]repositories.PackRule{500: {Disabled: true}}, 501, map[int]int{250: 3}},
+		{"Minimums cover the order", map[int]repositories.PackRule{500: {MinCount: 2}}, 600, map[int]int{500: 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service := newService()
+			for size, rule := range tc.rules {
+				if err := service.SetPackRule(size, rule); err != nil {
+					t.Fatalf("Failed to set rule: %v", err)
+				}
+			}
+
+			if result, err := service.CalculatePacks(tc.order); err != nil || !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("For order %d, expected %v, got %v, %v", tc.order, tc.expected, result, err)
+			}
+			if err := service.CheckRules(tc.order); err != nil {
+				t.Errorf("Unexpected rule error: %v", err)
+			}
+		})
+	}
+
+	t.Run("Maximum counts too low", func(t *testing.T) {
+		service := newService()
+		for _, size := range []int{250, 500, 1000} {
+			service.SetPackRule(size, repositories.PackRule{MaxCount: 1})
+		}
+
+		var ruleErr *services.RuleError
+		if result, err := service.CalculatePacks(2000); !errors.As(err, &ruleErr) || len(result) != 0 {
+			t.Errorf("Expected a rule error and no packs, got %v, %v", result, err)
+		}
+		if err := service.CheckRules(2000); !errors.As(err, &ruleErr) || !strings.Contains(err.Error(), "at most 1750") {
+			t.Errorf("Expected a rule error explaining the limit, got %v", err)
+		}
+	})
+
+	t.Run("Order too large for the rules", func(t *testing.T) {
+		service := newService()
+		service.SetPackRule(250, repositories.PackRule{MinCount: 1})
+
+		if _, err := service.Calculate(100000); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		var ruleErr *services.RuleError
+		if _, err := service.Calculate(1 << 30); !errors.As(err, &ruleErr) || !strings.Contains(err.Error(), "orders of up to") {
+			t.Errorf("Expected a rule error about the order size, got %v", err)
+		}
+	})
+
+	t.Run("Disabled size with a minimum", func(t *testing.T) {
+		service := newService()
+		service.SetPackRule(500, repositories.PackRule{MinCount: 1, Disabled: true})
+
+		var ruleErr *services.RuleError
+		if err := service.CheckRules(100); !errors.As(err, &ruleErr) || !strings.Contains(err.Error(), "disabled") {
+			t.Errorf("Expected a rule error about the disabled size, got %v", err)
+		}
+	})
+
+	t.Run("Invalid rule", func(t *testing.T) {
+		service := newService()
+		if err := service.SetPackRule(500, repositories.PackRule{MinCount: 3, MaxCount: 2}); err != services.ErrInvalidPackRule {
+			t.Errorf("Expected ErrInvalidPackRule, got %v", err)
+		}
+		if err := service.SetPackRule(750, repositories.PackRule{MaxCount: 2}); err != repositories.ErrSizeNotFound {
+			t.Errorf("Expected ErrSizeNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Matches a full search", func(t *testing.T) {
+		sizes := []int{3, 5, 7}
+		rules := []map[int]repositories.PackRule{
+			{7: {MaxCount: 2}},
+			{3: {MinCount: 1}, 7: {MaxCount: 1}},
+			{5: {Disabled: true}, 7: {MinCount: 1, MaxCount: 3}},
+			{3: {MaxCount: 2}, 5: {MaxCount: 2}, 7: {MaxCount: 2}},
+		}
+
+		for _, ruleSet := range rules {
+			service := services.NewPackageService(repositories.NewPackageRepository())
+			for _, size := range sizes {
+				service.AddPack(size)
+			}
+			for size, rule := range ruleSet {
+				service.SetPackRule(size, rule)
+			}
+
+			for order := 1; order <= 40; order++ {
+				// Orders the rules cannot meet have no packs, as in the brute force
+				result, _ := service.CalculatePacks(order)
+				total, packs := summarize(result)
+				expectedTotal, expectedPacks := bruteForceWithRules(sizes, ruleSet, order)
+				if total != expectedTotal || packs != expectedPacks {
+					t.Fatalf("Rules %v, order %d: expected total %d in %d packs, got total %d in %d packs",
+						ruleSet, order, expectedTotal, expectedPacks, total, packs)
+				}
+			}
+		}
+	})
+}
+
+// bruteForceWithRules tries every count of three sizes up to 20 packs each.
+func bruteForceWithRules(sizes []int, rules map[int]repositories.PackRule, order int) (total, packs int) {
+	allowed := func(size, count int) bool {
+		rule := rules[size]
+		return !(rule.Disabled && count > 0) && count >= rule.MinCount && (rule.MaxCount == 0 || count <= rule.MaxCount)
+	}
+
+	total, packs = -1, 0
+	for a := 0; a <= 20; a++ {
+		for b := 0; b <= 20; b++ {
+			for c := 0; c <= 20; c++ {
+				if !allowed(sizes[0], a) || !allowed(sizes[1], b) || !allowed(sizes[2], c) {
+					continue
+				}
+				sum, count := a*sizes[0]+b*sizes[1]+c*sizes[2], a+b+c
+				if count == 0 || sum < order {
+					continue
+				}
+				if total < 0 || sum < total || (sum == total && count < packs) {
+					total, packs = sum, count
+				}
+			}
+		}
+	}
+	if total < 0 {
+		return 0, 0
+	}
+	return total, packs
+}
+
+func TestCalculateExact(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+
+	if _, err := service.CalculateExact(10); err != services.ErrNoPackSizes {
+		t.Errorf("Expected ErrNoPackSizes, got %v", err)
+	}
+
+	service.AddPack(5)
+	service.AddPack(12)
+
+	packs, err := service.CalculateExact(29)
+	if err != nil || !reflect.DeepEqual(packs, map[int]int{12: 2, 5: 1}) {
+		t.Errorf("Expected {12:2 5:1}, got %v (%v)", packs, err)
+	}
+
+	_, err = service.CalculateExact(18)
+	var fit *services.NoExactFitError
+	if !errors.Is(err, services.ErrNoExactFit) || !errors.As(err, &fit) {
+		t.Fatalf("Expected ErrNoExactFit, got %v", err)
+	}
+	if fit.Below != 17 || fit.Above != 20 {
+		t.Errorf("Expected nearest quantities 17 and 20, got %d and %d", fit.Below, fit.Above)
+	}
+
+	t.Run("Matches a full search", func(t *testing.T) {
+		for _, catalogue := range [][]int{{5, 12}, {23, 31, 53}, {6, 9, 20}, {250, 500, 1000}} {
+			service.ClearPacks()
+			for _, size := range catalogue {
+				service.AddPack(size)
+			}
+
+			reachable := reachableTotals(catalogue, 3000)
+			for order := 1; order <= 2000; order++ {
+				packs, err := service.CalculateExact(order)
+				if reachable[order] {
+					if total, _ := summarize(packs); err != nil || total != order {
+						t.Fatalf("Sizes %v, order %d: expected an exact fit, got %v (%v)", catalogue, order, packs, err)
+					}
+					continue
+				}
+
+				below, above := 0, 0
+				for t := order - 1; t > 0 && below == 0; t-- {
+					if reachable[t] {
+						below = t
+					}
+				}
+				for t := order + 1; above == 0; t++ {
+					if reachable[t] {
+						above = t
+					}
+				}
+				if !errors.As(err, &fit) || fit.Below != below || fit.Above != above {
+					t.Fatalf("Sizes %v, order %d: expected nearest %d and %d, got %v", catalogue, order, below, above, err)
+				}
+			}
+		}
+	})
+
+	t.Run("With rules", func(t *testing.T) {
+		service.ClearPacks()
+		service.AddPack(5)
+		service.AddPack(12)
+		service.SetPackRule(12, repositories.PackRule{MaxCount: 1})
+
+		if packs, err := service.CalculateExact(22); err != nil || !reflect.DeepEqual(packs, map[int]int{12: 1, 5: 2}) {
+			t.Errorf("Expected {12:1 5:2}, got %v (%v)", packs, err)
+		}
+		// 24 would need two 12-packs
+		if _, err := service.CalculateExact(24); !errors.As(err, &fit) || fit.Below != 22 || fit.Above != 25 {
+			t.Errorf("Expected nearest quantities 22 and 25, got %v", err)
+		}
+	})
+}
+
+// reachableTotals marks every total up to limit that some combination of sizes adds up to.
+func reachableTotals(sizes []int, limit int) []bool {
+	reachable := make([]bool, limit+1)
+	reachable[0] = true
+	for t := 1; t <= limit; t++ {
+		for _, size := range sizes {
+			if t >= size && reachable[t-size] {
+				reachable[t] = true
+				break
+			}
+		}
+	}
+	return reachable
+}
+
+func TestCompareCatalogue(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+	service.AddPack(250)
+	service.AddPack(500)
+	service.AddPack(1000)
+
+	orders := []int{700, 700, 1600}
+	costs := map[int]float64{250: 1, 500: 1.5, 750: 2, 1000: 2.5}
+	result, err := service.CompareCatalogue([]int{250, 750, 500, 1000}, orders, costs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Current: 700 -> 500+250, 1600 -> 1000+500+250
+	if result.Current.TotalExcess != 50+50+150 || result.Current.TotalPacks != 7 || result.Current.TotalCost != 2.5+2.5+5 {
+		t.Errorf("Unexpected current metrics %+v", result.Current)
+	}
+	// Proposed: 700 -> 750, 1600 -> 1000+750
+	if result.Proposed.TotalExcess != 50+50+150 || result.Proposed.TotalPacks != 4 || result.Proposed.TotalCost != 2+2+4.5 {
+		t.Errorf("Unexpected proposed metrics %+v", result.Proposed)
+	}
+	if math.Abs(result.PacksChange+1) > 1e-9 || result.ExcessChange != 0 {
+		t.Errorf("Unexpected changes %+v", result)
+	}
+
+	// The live catalogue is untouched
+	if sizes := service.GetPackSizes(); !reflect.DeepEqual(sizes, []int{1000, 500, 250}) {
+		t.Errorf("Expected the catalogue to be unchanged, got %v", sizes)
+	}
+
+	// Rules on the current sizes are not applied, so both sides are measured alike
+	service.SetPackRule(250, repositories.PackRule{Disabled: true})
+	result, err = service.CompareCatalogue([]int{250, 500, 1000}, orders, nil)
+	if err != nil || !reflect.DeepEqual(result.Current, result.Proposed) {
+		t.Errorf("Expected the same metrics for the same sizes, got %+v (%v)", result, err)
+	}
+	service.SetPackRule(250, repositories.PackRule{})
+
+	// Orders a catalogue cannot calculate are counted apart instead of as perfect fits
+	empty := services.NewPackageService(repositories.NewPackageRepository())
+	result, err = empty.CompareCatalogue([]int{250}, orders, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Current.Unsolvable != 3 || result.Current.TotalPacks != 0 || result.Current.AverageExcess != 0 {
+		t.Errorf("Expected every order to be unsolvable, got %+v", result.Current)
+	}
+	if result.Proposed.Unsolvable != 0 || result.Proposed.TotalPacks != 3+3+7 {
+		t.Errorf("Unexpected proposed metrics %+v", result.Proposed)
+	}
+
+	if _, err := service.CompareCatalogue([]int{250}, nil, nil); err != services.ErrNoOrders {
+		t.Errorf("Expected ErrNoOrders, got %v", err)
+	}
+	if _, err := service.CompareCatalogue([]int{0, 250}, orders, nil); err != services.ErrInvalidPackSize {
+		t.Errorf("Expected ErrInvalidPackSize, got %v", err)
+	}
+	if _, err := service.CompareCatalogue([]int{20000, 19999}, orders, nil); err != services.ErrTableTooLarge {
+		t.Errorf("Expected ErrTableTooLarge, got %v", err)
+	}
+	if _, err := service.CompareCatalogue(make([]int, services.MaxPackSizes+1), orders, nil); err != services.ErrTooManyPackSizes {
+		t.Errorf("Expected ErrTooManyPackSizes, got %v", err)
+	}
+	if _, err := service.CompareCatalogue([]int{250}, make([]int, services.MaxSampleOrders+1), nil); err != services.ErrTooManyOrders {
+		t.Errorf("Expected ErrTooManyOrders, got %v", err)
+	}
+}
+
+func TestOptimizeCatalogue(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+
+	t.Run("Ranks every catalogue", func(t *testing.T) {
+		recommendations, err := service.OptimizeCatalogue(services.OptimizeRequest{
+			Orders:     []int{250, 500, 750},
+			K:          1,
+			Candidates: []int{250, 500, 750},
+			Objective:  services.ObjectivePacks,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var sizes [][]int
+		for i, recommendation := range recommendations {
+			if recommendation.Rank != i+1 {
+				t.Errorf("Expected rank %d, got %d", i+1, recommendation.Rank)
+			}
+			sizes = append(sizes, recommendation.Sizes)
+		}
+		// Packs: 750 -> 1+1+1, 500 -> 1+1+2, 250 -> 1+2+3
+		if !reflect.DeepEqual(sizes, [][]int{{750}, {500}, {250}}) {
+			t.Errorf("Unexpected ranking %v", sizes)
+		}
+		if recommendations[0].TotalPacks != 3 || recommendations[0].TotalExcess != 500+250 {
+			t.Errorf("Unexpected metrics %+v", recommendations[0].CatalogueMetrics)
+		}
+	})
+
+	t.Run("Minimises excess by default", func(t *testing.T) {
+		service.AddPack(1000)
+		recommendations, err := service.OptimizeCatalogue(services.OptimizeRequest{
+			Orders:          []int{300, 700, 1000},
+			K:               2,
+			Recommendations: 1,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// The candidates are the current catalogue plus the sample orders; 1000 -> 700+300
+		if len(recommendations) != 1 || !reflect.DeepEqual(recommendations[0].Sizes, []int{700, 300}) {
+			t.Fatalf("Unexpected recommendations %+v", recommendations)
+		}
+		if recommendations[0].TotalExcess != 0 || recommendations[0].TotalPacks != 4 {
+			t.Errorf("Unexpected metrics %+v", recommendations[0].CatalogueMetrics)
+		}
+	})
+
+	t.Run("Searches large candidate sets", func(t *testing.T) {
+		var candidates []int
+		for size := 10; size <= 600; size += 10 {
+			candidates = append(candidates, size)
+		}
+		orders := []int{120, 350, 480, 120, 350}
+		recommendations, err := service.OptimizeCatalogue(services.OptimizeRequest{
+			Orders:     orders,
+			K:          3,
+			Candidates: candidates,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(recommendations) != 5 {
+			t.Fatalf("Expected 5 recommendations, got %d", len(recommendations))
+		}
+		for i, recommendation := range recommendations {
+			if len(recommendation.Sizes) != 3 {
+				t.Errorf("Expected 3 sizes, got %v", recommendation.Sizes)
+			}
+			if i > 0 && recommendation.TotalExcess < recommendations[i-1].TotalExcess {
+				t.Errorf("Recommendations are not ranked by excess: %+v", recommendations)
+			}
+		}
+		// 120, 350 and 480 fit every order exactly
+		if best := recommendations[0]; best.TotalExcess != 0 || best.TotalPacks != len(orders) {
+			t.Errorf("Unexpected best recommendation %+v", best.CatalogueMetrics)
+		}
+	})
+
+	t.Run("Skips catalogues too large to calculate with", func(t *testing.T) {
+		recommendations, err := service.OptimizeCatalogue(services.OptimizeRequest{
+			Orders:     []int{19999, 20000, 39999},
+			K:          2,
+			Candidates: []int{20000, 19999, 10000},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// Only 20000 and 10000 are far enough apart for a table
+		if len(recommendations) != 1 || !reflect.DeepEqual(recommendations[0].Sizes, []int{20000, 10000}) {
+			t.Errorf("Expected only {20000, 10000}, got %+v", recommendations)
+		}
+	})
+
+	t.Run("Defaults to the most frequent orders", func(t *testing.T) {
+		orders := make([]int, 0, 2*services.MaxCandidates)
+		for order := 1; order <= 2*services.MaxCandidates; order++ {
+			orders = append(orders, order*7)
+		}
+		orders = append(orders, 700, 700)
+		recommendations, err := service.OptimizeCatalogue(services.OptimizeRequest{Orders: orders, K: 1, Recommendations: 1})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(recommendations) != 1 {
+			t.Fatalf("Expected a recommendation, got %+v", recommendations)
+		}
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		if _, err := service.OptimizeCatalogue(services.OptimizeRequest{K: 1}); err != services.ErrNoOrders {
+			t.Errorf("Expected ErrNoOrders, got %v", err)
+		}
+		if _, err := service.OptimizeCatalogue(services.OptimizeRequest{Orders: []int{100}, K: 3, Candidates: []int{50, 100}}); err != services.ErrInvalidCatalogueSize {
+			t.Errorf("Expected ErrInvalidCatalogueSize, got %v", err)
+		}
+		if _, err := service.OptimizeCatalogue(services.OptimizeRequest{Orders: []int{100}, K: 1, Candidates: make([]int, services.MaxCandidates+1)}); err != services.ErrTooManyCandidates {
+			t.Errorf("Expected ErrTooManyCandidates, got %v", err)
+		}
+		if _, err := service.OptimizeCatalogue(services.OptimizeRequest{Orders: []int{100}, K: 1, Objective: "cost"}); err != services.ErrInvalidObjective {
+			t.Errorf("Expected ErrInvalidObjective, got %v", err)
+		}
+	})
+}
+
+func TestCatalogueVersions(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+	service.AddPack(250)
+	service.AddPack(500)
+	service.AddPack(1000)
+	service.SetPackRule(500, repositories.PackRule{MaxCount: 1})
+
+	t.Run("Calculations record the version", func(t *testing.T) {
+		result, err := service.Calculate(1001)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.CatalogueVersion != 5 || !reflect.DeepEqual(result.Packs, map[int]int{1000: 1, 250: 1}) {
+			t.Errorf("Unexpected result %+v", result)
+		}
+
+		shipped, _ := service.CalculateShipments(1001, services.ShipmentLimits{MaxPacks: 1})
+		if shipped.CatalogueVersion != 5 {
+			t.Errorf("Expected shipments to record version 5, got %d", shipped.CatalogueVersion)
+		}
+		if explanation, _ := service.ExplainPacks(1001); explanation.Chosen.CatalogueVersion != 5 {
+			t.Errorf("Expected the explanation to record version 5, got %d", explanation.Chosen.CatalogueVersion)
+		}
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		diff, err := service.DiffVersions(2, 5)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(diff.Added, []int{1000, 500}) || len(diff.Removed) != 0 || len(diff.RuleChanges) != 0 {
+			t.Errorf("Unexpected diff %+v", diff)
+		}
+
+		// 500 is removed and added back without its rule
+		service.ClearPacks()
+		service.AddPack(500)
+		diff, _ = service.DiffVersions(5, 7)
+		expected := []services.RuleChange{{Size: 500, From: repositories.PackRule{MaxCount: 1}}}
+		if len(diff.Added) != 0 || !reflect.DeepEqual(diff.Removed, []int{1000, 250}) || !reflect.DeepEqual(diff.RuleChanges, expected) {
+			t.Errorf("Unexpected diff %+v", diff)
+		}
+
+		if _, err := service.DiffVersions(1, 99); err != repositories.ErrVersionNotFound {
+			t.Errorf("Expected ErrVersionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		events, unsubscribe := service.Subscribe()
+		defer unsubscribe()
+
+		if err := service.RollbackCatalogue(5); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sizes := service.GetPackSizes(); !reflect.DeepEqual(sizes, []int{1000, 500, 250}) {
+			t.Errorf("GetPackSizes() = %v after rollback", sizes)
+		}
+		if rules := service.GetPackRules(); rules[500].MaxCount != 1 {
+			t.Errorf("GetPackRules() = %v after rollback", rules)
+		}
+		if event := <-events; event.Name != services.PackSizesChangedEvent {
+			t.Errorf("Unexpected event %+v", event)
+		}
+
+		versions := service.ListVersions()
+		if last := versions[len(versions)-1]; last.ID != 8 || last.Change != "rolled back to version 5" {
+			t.Errorf("Unexpected latest version %+v", last)
+		}
+		if err := service.RollbackCatalogue(99); err != repositories.ErrVersionNotFound {
+			t.Errorf("Expected ErrVersionNotFound, got %v", err)
+		}
+	})
+}
+
+func TestPackagingLevels(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+	for _, level := range []struct {
+		name     string
+		quantity int
+		unit     string
+	}{{"pack", 12, ""}, {"case", 4, "pack"}, {"pallet", 10, "case"}} {
+		if err := service.AddPackagingLevel(level.name, level.quantity, level.unit); err != nil {
+			t.Fatalf("AddPackagingLevel(%s) failed: %v", level.name, err)
+		}
+	}
+	if sizes := service.GetPackSizes(); !reflect.DeepEqual(sizes, []int{480, 48, 12}) {
+		t.Errorf("GetPackSizes() = %v", sizes)
+	}
+
+	t.Run("Breakdown", func(t *testing.T) {
+		result, err := service.Calculate(2*480 + 3*48 + 5)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := []services.LevelCount{
+			{Level: "pallet", Size: 480, Count: 2, Quantity: 10, Unit: "case"},
+			{Level: "case", Size: 48, Count: 3, Quantity: 4, Unit: "pack"},
+			{Level: "pack", Size: 12, Count: 1, Quantity: 12, Unit: repositories.ItemUnit},
+		}
+		if !reflect.DeepEqual(result.Levels, expected) {
+			t.Errorf("Levels = %+v", result.Levels)
+		}
+		if got := services.DescribeLevels(result.Levels); got != "2 pallets + 3 cases + 1 pack" {
+			t.Errorf("DescribeLevels() = %q", got)
+		}
+
+		shipped, _ := service.CalculateShipments(490, services.ShipmentLimits{MaxItems: 480})
+		if got := services.DescribeLevels(shipped.Levels); got != "1 pallet + 1 pack" {
+			t.Errorf("Shipment levels = %q", got)
+		}
+	})
+
+	t.Run("Sizes without a level", func(t *testing.T) {
+		service := services.NewPackageService(repositories.NewPackageRepository())
+		service.AddPack(7)
+		if result, _ := service.Calculate(14); result.Levels != nil {
+			t.Errorf("Expected a flat result without levels, got %+v", result.Levels)
+		}
+
+		service.AddPackagingLevel("case", 10, "")
+		result, _ := service.Calculate(17)
+		if got := services.DescribeLevels(result.Levels); got != "1 case + 1 x 7" {
+			t.Errorf("DescribeLevels() = %q", got)
+		}
+	})
+
+	t.Run("Invalid levels", func(t *testing.T) {
+		if err := service.AddPackagingLevel("", 4, ""); err != services.ErrInvalidLevel {
+			t.Errorf("Expected ErrInvalidLevel, got %v", err)
+		}
+		if err := service.AddPackagingLevel("single", 1, ""); err != services.ErrInvalidLevel {
+			t.Errorf("Expected ErrInvalidLevel, got %v", err)
+		}
+		if err := service.AddPackagingLevel("truck", 20, "container"); err != repositories.ErrLevelNotFound {
+			t.Errorf("Expected ErrLevelNotFound, got %v", err)
+		}
+		if err := service.RemovePackagingLevel("case"); err != repositories.ErrLevelInUse {
+			t.Errorf("Expected ErrLevelInUse, got %v", err)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		events, unsubscribe := service.Subscribe()
+		defer unsubscribe()
+
+		if err := service.RemovePackagingLevel("pallet"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if event := <-events; event.Data != "[48,12]" {
+			t.Errorf("Unexpected event %+v", event)
+		}
+		if levels := service.GetPackagingLevels(); len(levels) != 2 || levels[0].Name != "case" {
+			t.Errorf("GetPackagingLevels() = %+v", levels)
+		}
+	})
+}
+
+func TestScheduledCatalogue(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+	now := time.Now()
+	nextWeek := now.AddDate(0, 0, 7)
+
+	service.AddPack(250)
+	service.AddPack(500)
+	if err := service.AddScheduledPack(750, repositories.Schedule{EffectiveFrom: nextWeek}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := service.SchedulePackRemoval(250, nextWeek); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if sizes := service.GetPackSizes(); !reflect.DeepEqual(sizes, []int{500, 250}) {
+		t.Errorf("GetPackSizes() = %v", sizes)
+	}
+	if sizes := service.GetPackSizesAt(nextWeek); !reflect.DeepEqual(sizes, []int{750, 500}) {
+		t.Errorf("GetPackSizesAt(next week) = %v", sizes)
+	}
+
+	// Today 700 needs 500+250; next week the new 750-pack covers it
+	if packs, err := service.CalculatePacks(700); err != nil || !reflect.DeepEqual(packs, map[int]int{500: 1, 250: 1}) {
+		t.Errorf("CalculatePacks(700) = %v, %v", packs, err)
+	}
+	result, err := service.CalculateAt(700, nextWeek)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Packs, map[int]int{750: 1}) || result.CatalogueVersion != 5 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	// Rules of sizes that are not available yet are ignored
+	service.SetPackRule(750, repositories.PackRule{MinCount: 1})
+	if err := service.CheckRules(100); err != nil {
+		t.Errorf("Expected the rule of the scheduled size to be ignored, got %v", err)
+	}
+
+	if err := service.AddScheduledPack(1000, repositories.Schedule{EffectiveFrom: nextWeek, EffectiveUntil: now}); err != services.ErrInvalidSchedule {
+		t.Errorf("Expected ErrInvalidSchedule, got %v", err)
+	}
+	if err := service.SchedulePackRemoval(750, now); err != services.ErrInvalidSchedule {
+		t.Errorf("Expected ErrInvalidSchedule, got %v", err)
+	}
+	if err := service.SchedulePackRemoval(1000, nextWeek); err != repositories.ErrSizeNotFound {
+		t.Errorf("Expected ErrSizeNotFound, got %v", err)
+	}
+
+	diff, _ := service.DiffVersions(4, 5)
+	if len(diff.ScheduleChanges) != 1 || diff.ScheduleChanges[0].Size != 250 || !diff.ScheduleChanges[0].To.EffectiveUntil.Equal(nextWeek) {
+		t.Errorf("Unexpected schedule changes %+v", diff.ScheduleChanges)
+	}
+}
+
+func TestScheduleBoundaries(t *testing.T) {
+	service := services.NewPackageService(repositories.NewPackageRepository())
+	defer service.Close()
+	events, unsubscribe := service.Subscribe()
+	defer unsubscribe()
+
+	service.AddPack(500)
+	service.AddScheduledPack(250, repositories.Schedule{EffectiveFrom: time.Now().Add(50 * time.Millisecond)})
+	<-events
+	<-events
+
+	// The size becomes available without the catalogue being edited
+	select {
+	case event := <-events:
+		if event.Name != services.PackSizesChangedEvent || event.Data != "[500,250]" {
+			t.Errorf("Expected %s event with [500,250], got %+v", services.PackSizesChangedEvent, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an event when the scheduled size became available")
+	}
+	if packs, err := service.CalculatePacks(250); err != nil || !reflect.DeepEqual(packs, map[int]int{250: 1}) {
+		t.Errorf("CalculatePacks(250) = %v, %v", packs, err)
+	}
+
+	// Closed services stop watching the schedules
+	service.SchedulePackRemoval(250, time.Now().Add(50*time.Millisecond))
+	<-events
+	service.Close()
+	select {
+	case event := <-events:
+		t.Errorf("Expected no event after Close, got %+v", event)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestTableSizeLimit(t *testing.T) {
+	repo := repositories.NewPackageRepository()
+	service := services.NewPackageService(repo)
+
+	// Sizes close to each other need a table of about the square of the largest
+	if err := service.AddPack(20000); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.AddPack(19999); !errors.Is(err, services.ErrTableTooLarge) {
+		t.Errorf("Expected ErrTableTooLarge, got %v", err)
+	}
+	if err := service.AddScheduledPack(19999, repositories.Schedule{EffectiveFrom: time.Now().Add(time.Hour)}); !errors.Is(err, services.ErrTableTooLarge) {
+		t.Errorf("Expected ErrTableTooLarge for a scheduled size, got %v", err)
+	}
+	if sizes := service.GetPackSizes(); !reflect.DeepEqual(sizes, []int{20000}) {
+		t.Errorf("Expected the rejected sizes not to be added, got %v", sizes)
+	}
+
+	// Catalogues that got there anyway are calculated per order, up to the limit
+	repo.Add(19999)
+	for _, order := range []int{1, 19999, 20001, 59998, 400000} {
+		result, err := service.Calculate(order)
+		if err != nil {
+			t.Fatalf("Calculate(%d) failed: %v", order, err)
+		}
+		if total, packs := bruteForce([]int{20000, 19999}, order); result.Total != total || result.PacksCount != packs {
+			t.Errorf("Calculate(%d) = %d items in %d packs, want %d in %d", order, result.Total, result.PacksCount, total, packs)
+		}
+	}
+	if _, err := service.Calculate(1_000_000_000); !errors.Is(err, services.ErrOrderTooLarge) {
+		t.Errorf("Expected ErrOrderTooLarge, got %v", err)
+	}
+	if _, err := service.CalculateExact(1_000_000_000); !errors.Is(err, services.ErrOrderTooLarge) {
+		t.Errorf("Expected ErrOrderTooLarge in exact mode, got %v", err)
+	}
+}
+
+func TestNewCalculationResult(t *testing.T) {
+	result := services.NewCalculationResult(501, map[int]int{500: 1, 250: 1})
+
+	expected := services.CalculationResult{
+		Packs:       map[int]int{500: 1, 250: 1},
+		Total:       750,
+		OrderSize:   501,
+		ExcessItems: 249,
+		PacksCount:  2,
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+}
+
+func summarize(result map[int]int) (total, packs int) {
+	for size, count := range result {
+		total += size * count
+		packs += count
+	}
+	return
+}
+
+// bruteForce finds the smallest total covering the order and the fewest packs
+// reaching it by filling every total up to order+largest.
+func bruteForce(sizes []int, order int) (total, packs int) {
+	largest := 0
+	smallest := sizes[0]
+	for _, size := range sizes {
+		largest = max(largest, size)
+		smallest = min(smallest, size)
+	}
+	if order < smallest {
+		return smallest, 1
+	}
+
+	best := make([]int, order+largest+1)
+	for i := 1; i < len(best); i++ {
+		best[i] = -1
+		for _, size := range sizes {
+			if i >= size && best[i-size] >= 0 && (best[i] < 0 || best[i-size]+1 < best[i]) {
+				best[i] = best[i-size] + 1
+			}
+		}
+	}
+	for i := order; i < len(best); i++ {
+		if best[i] >= 0 {
+			return i, best[i]
+		}
+	}
+	return 0, 0
 }
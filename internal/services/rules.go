@@ -0,0 +1,250 @@
+package services
+
+import (
+	"Ship_Manager/internal/repositories"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidPackRule is returned when a rule has negative counts or a maximum below its minimum.
+var ErrInvalidPackRule = errors.New("invalid pack rule")
+
+// RuleError explains why the pack rules cannot all be met for an order.
+type RuleError struct {
+	Reasons []string
+}
+
+func (e *RuleError) Error() string {
+	return "pack rules cannot be met: " + strings.Join(e.Reasons, "; ")
+}
+
+func (ps *packageService) SetPackRule(size int, rule repositories.PackRule) error {
+	if rule.MinCount < 0 || rule.MaxCount < 0 || (rule.MaxCount > 0 && rule.MaxCount < rule.MinCount) {
+		return ErrInvalidPackRule
+	}
+	if err := ps.repository.SetRule(size, rule); err != nil {
+		return err
+	}
+	ps.catalogueChanged()
+	return nil
+}
+
+func (ps *packageService) GetPackRules() map[int]repositories.PackRule {
+	return ps.repository.GetRules()
+}
+
+func (ps *packageService) CheckRules(orderSize int) error {
+	c := ps.activeCatalogue()
+	if len(c.sizes) == 0 {
+		return nil
+	}
+	_, err := ps.solveWith(c.sizes, c.rules, orderSize)
+	return err
+}
+
+// solveWith calculates the packs for an order from the given sizes and rules.
+// Without rules the precomputed table is used.
+func (ps *packageService) solveWith(packSizes []int, rules map[int]repositories.PackRule, orderSize int) (map[int]int, error) {
+	if len(packSizes) == 0 {
+		return map[int]int{}, nil
+	}
+
+	if len(rules) == 0 {
+		table, err := ps.packTable(packSizes).covering(orderSize)
+		if err != nil {
+			return nil, err
+		}
+		return table.solve(orderSize), nil
+	}
+	return solveWithRules(packSizes, rules, orderSize)
+}
+
+// followsRules reports whether a combination respects every pack rule.
+func followsRules(packs map[int]int, rules map[int]repositories.PackRule) bool {
+	for size, rule := range rules {
+		count := packs[size]
+		if (rule.Disabled && count > 0) || count < rule.MinCount || (rule.MaxCount > 0 && count > rule.MaxCount) {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleTable is a bounded knapsack over every total up to a limit for a catalogue
+// with rules. Minimum counts are placed up front in base; the table covers the
+// packs added on top of them. Unlike packTable it grows with the order, so it is
+// only used when rules are configured.
+type ruleTable struct {
+	base      map[int]int
+	baseTotal int
+	sizes     []int   // Enabled pack sizes in descending order
+	limits    []int   // Packs that may be added per size on top of base, -1 for unlimited
+	best      []int   // Fewest extra packs reaching a total exactly, -1 if unreachable
+	used      [][]int // used[i][t] is how many packs of sizes[i] the best way to reach t uses
+}
+
+// newRuleTable prepares the rules for the given sizes, which must be sorted in descending order.
+// It returns a *RuleError if the rules contradict each other.
+func newRuleTable(packSizes []int, rules map[int]repositories.PackRule) (*ruleTable, error) {
+	var reasons []string
+	rt := &ruleTable{base: make(map[int]int)}
+
+	for _, size := range packSizes {
+		rule := rules[size]
+		if rule.Disabled {
+			if rule.MinCount > 0 {
+				reasons = append(reasons, fmt.Sprintf("pack size %d is disabled but must be used at least %d times", size, rule.MinCount))
+			}
+			continue
+		}
+
+		if rule.MinCount > 0 {
+			rt.base[size] = rule.MinCount
+			rt.baseTotal += rule.MinCount * size
+		}
+		limit := -1
+		if rule.MaxCount > 0 {
+			limit = rule.MaxCount - rule.MinCount
+		}
+		rt.sizes = append(rt.sizes, size)
+		rt.limits = append(rt.limits, limit)
+	}
+
+	if len(rt.sizes) == 0 {
+		reasons = append(reasons, "every pack size is disabled")
+	}
+	if len(reasons) > 0 {
+		return nil, &RuleError{Reasons: reasons}
+	}
+	return rt, nil
+}
+
+// capacity returns the most items the extra packs can hold, or -1 if unlimited.
+func (rt *ruleTable) capacity() int {
+	capacity := 0
+	for i, limit := range rt.limits {
+		if limit < 0 {
+			return -1
+		}
+		capacity += limit * rt.sizes[i]
+	}
+	return capacity
+}
+
+// fill computes the fewest extra packs for every total up to upper.
+//
+// Each size is added with at most its limit. For a residue class modulo the size,
+// the candidates for total r+j*size are best[r+k*size]+(j-k) for k in a window of
+// limit+1 steps, so a monotone queue gives the minimum in constant amortised time.
+func (rt *ruleTable) fill(upper int) {
+	best := make([]int, upper+1)
+	for t := 1; t <= upper; t++ {
+		best[t] = -1
+	}
+	rt.used = make([][]int, len(rt.sizes))
+
+	for i, size := range rt.sizes {
+		limit := rt.limits[i]
+		if limit < 0 {
+			limit = upper / size
+		}
+		next := make([]int, upper+1)
+		rt.used[i] = make([]int, upper+1)
+
+		for r := 0; r < size && r <= upper; r++ {
+			var queue []int // Indices j with increasing best[r+j*size]-j
+			for j := 0; r+j*size <= upper; j++ {
+				t := r + j*size
+				if best[t] >= 0 {
+					for len(queue) > 0 && best[r+queue[len(queue)-1]*size]-queue[len(queue)-1] >= best[t]-j {
+						queue = queue[:len(queue)-1]
+					}
+					queue = append(queue, j)
+				}
+				for len(queue) > 0 && queue[0] < j-limit {
+					queue = queue[1:]
+				}
+
+				if len(queue) == 0 {
+					next[t] = -1
+					continue
+				}
+				from := queue[0]
+				next[t] = best[r+from*size] + j - from
+				rt.used[i][t] = j - from
+			}
+		}
+		best = next
+	}
+	rt.best = best
+}
+
+// reachable reports whether the extra packs can add up to exactly t.
+func (rt *ruleTable) reachable(t int) bool {
+	return t >= 0 && t < len(rt.best) && rt.best[t] >= 0
+}
+
+// combination returns base plus the best extra packs adding up to exactly t.
+func (rt *ruleTable) combination(t int) map[int]int {
+	result := make(map[int]int)
+	for size, count := range rt.base {
+		result[size] = count
+	}
+	for i := len(rt.sizes) - 1; i >= 0; i-- {
+		if count := rt.used[i][t]; count > 0 {
+			result[rt.sizes[i]] += count
+			t -= count * rt.sizes[i]
+		}
+	}
+	return result
+}
+
+// limitsError explains that the maximum counts cannot hold the order.
+func (rt *ruleTable) limitsError(orderSize int, rules map[int]repositories.PackRule) *RuleError {
+	var limited []string
+	for _, size := range rt.sizes {
+		limited = append(limited, fmt.Sprintf("%dx%d", rules[size].MaxCount, size))
+	}
+	return &RuleError{Reasons: []string{fmt.Sprintf(
+		"the order needs %d items but the maximum counts (%s) allow at most %d",
+		orderSize, strings.Join(limited, " "), rt.baseTotal+rt.capacity(),
+	)}}
+}
+
+// solveWithRules finds the smallest total covering the order, then the fewest packs,
+// while using every size between its minimum and maximum count.
+func solveWithRules(packSizes []int, rules map[int]repositories.PackRule, orderSize int) (map[int]int, error) {
+	rt, err := newRuleTable(packSizes, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	// Minimum counts alone may already cover the order
+	target := orderSize - rt.baseTotal
+	if target <= 0 && rt.baseTotal > 0 {
+		return rt.base, nil
+	}
+	target = max(target, 1)
+
+	if capacity := rt.capacity(); capacity >= 0 && capacity < target {
+		return nil, rt.limitsError(orderSize, rules)
+	}
+
+	// fill holds a count per size and two totals for every total up to upper
+	upper := target + rt.sizes[0]
+	if limit := maxTableSize/(len(rt.sizes)+2) - rt.sizes[0]; upper > maxTableSize/(len(rt.sizes)+2) {
+		return nil, &RuleError{Reasons: []string{fmt.Sprintf(
+			"the order needs %d items but pack rules can only be applied to orders of up to %d items",
+			orderSize, rt.baseTotal+max(limit, 0),
+		)}}
+	}
+
+	rt.fill(upper)
+	for t := target; t < len(rt.best); t++ {
+		if rt.reachable(t) {
+			return rt.combination(t), nil
+		}
+	}
+	return nil, &RuleError{Reasons: []string{"no combination of the allowed pack counts covers the order"}}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"Ship_Manager/internal/repositories"
+	"errors"
+	"time"
+)
+
+// ErrInvalidSchedule is returned when a pack size would stop being available before it starts.
+var ErrInvalidSchedule = errors.New("effectiveUntil must be after effectiveFrom")
+
+// catalogueSnapshot is the part of one catalogue version that is available at a given time.
+type catalogueSnapshot struct {
+	version int
+	sizes   []int
+	rules   map[int]repositories.PackRule
+	levels  []repositories.PackagingLevel
+}
+
+// catalogueAt returns the sizes and rules in effect at t, taken from a single version.
+func (ps *packageService) catalogueAt(t time.Time) catalogueSnapshot {
+	v := ps.repository.CurrentVersion()
+	return catalogueSnapshot{
+		version: v.ID,
+		sizes:   v.SizesAt(t),
+		rules:   v.RulesAt(t),
+		levels:  v.Levels,
+	}
+}
+
+// activeCatalogue returns the sizes and rules in effect now.
+func (ps *packageService) activeCatalogue() catalogueSnapshot {
+	return ps.catalogueAt(time.Now())
+}
+
+// nextBoundary returns the first moment after t at which a scheduled size becomes
+// available or is withdrawn, or the zero time if no schedule changes after t.
+func nextBoundary(schedules map[int]repositories.Schedule, t time.Time) time.Time {
+	var next time.Time
+	for _, schedule := range schedules {
+		for _, boundary := range []time.Time{schedule.EffectiveFrom, schedule.EffectiveUntil} {
+			if boundary.After(t) && (next.IsZero() || boundary.Before(next)) {
+				next = boundary
+			}
+		}
+	}
+	return next
+}
+
+// watchSchedules arranges for catalogueChanged to run at the next schedule boundary,
+// where the sizes available change without the catalogue being edited.
+func (ps *packageService) watchSchedules() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.boundary != nil {
+		ps.boundary.Stop()
+		ps.boundary = nil
+	}
+	if ps.closed {
+		return
+	}
+	if next := nextBoundary(ps.repository.GetSchedules(), time.Now()); !next.IsZero() {
+		ps.boundary = time.AfterFunc(time.Until(next), ps.catalogueChanged)
+	}
+}
+
+func (ps *packageService) AddScheduledPack(size int, schedule repositories.Schedule) error {
+	if !schedule.EffectiveFrom.IsZero() && !schedule.EffectiveUntil.IsZero() && !schedule.EffectiveUntil.After(schedule.EffectiveFrom) {
+		return ErrInvalidSchedule
+	}
+	if err := ps.checkCatalogueSize(size); err != nil {
+		return err
+	}
+	if err := ps.repository.AddScheduled(size, schedule); err != nil {
+		return err
+	}
+	ps.catalogueChanged()
+	return nil
+}
+
+func (ps *packageService) SchedulePackRemoval(size int, at time.Time) error {
+	schedule := ps.repository.GetSchedules()[size]
+	if !schedule.EffectiveFrom.IsZero() && !at.After(schedule.EffectiveFrom) {
+		return ErrInvalidSchedule
+	}
+
+	schedule.EffectiveUntil = at
+	if err := ps.repository.SetSchedule(size, schedule); err != nil {
+		return err
+	}
+	ps.catalogueChanged()
+	return nil
+}
+
+func (ps *packageService) GetPackSchedules() map[int]repositories.Schedule {
+	return ps.repository.GetSchedules()
+}
+
+func (ps *packageService) GetPackSizesAt(t time.Time) []int {
+	return ps.repository.GetSizesAt(t)
+}
+
+func (ps *packageService) CalculateAt(orderSize int, shipDate time.Time) (CalculationResult, error) {
+	c := ps.catalogueAt(shipDate)
+	packs, err := ps.solveWith(c.sizes, c.rules, orderSize)
+	if err != nil {
+		return CalculationResult{}, err
+	}
+
+	result := NewCalculationResult(orderSize, packs)
+	result.CatalogueVersion = c.version
+	result.Levels = levelBreakdown(packs, c.levels)
+	ps.calculated(result)
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MaxShipments bounds the shipments an order may be split into.
+const MaxShipments = 10000
+
+// ErrShipmentLimitTooSmall is returned when no pack size fits within the per-shipment item limit.
+var ErrShipmentLimitTooSmall = errors.New("no pack size fits within the shipment limit")
+
+// ErrInvalidShipmentLimits is returned when a shipment limit is negative.
+var ErrInvalidShipmentLimits = errors.New("shipment limits must not be negative")
+
+// ErrTooManyShipments is returned when the packs need more than MaxShipments shipments.
+var ErrTooManyShipments = fmt.Errorf("the packs need more than %d shipments", MaxShipments)
+
+// ShipmentLimits caps what a single shipment may hold. Zero means unlimited.
+type ShipmentLimits struct {
+	MaxItems int `json:"maxItems"` // Maximum number of items per shipment
+	MaxPacks int `json:"maxPacks"` // Maximum number of packs per shipment
+}
+
+// Shipment is one part of an order that is sent on its own.
+type Shipment struct {
+	Packs      map[int]int `json:"packs"`      // Map of pack sizes to the number of packs in this shipment
+	Total      int         `json:"total"`      // Number of items in this shipment
+	PacksCount int         `json:"packsCount"` // Number of packs in this shipment
+}
+
+func (ps *packageService) CalculateShipments(orderSize int, limits ShipmentLimits) (CalculationResult, error) {
+	if limits.MaxItems < 0 || limits.MaxPacks < 0 {
+		return CalculationResult{}, ErrInvalidShipmentLimits
+	}
+
+	// Packs larger than a shipment can never be sent, so they are left out of the solution.
+	c := ps.activeCatalogue()
+	packSizes := c.sizes
+	if limits.MaxItems > 0 && len(packSizes) > 0 {
+		allowed := make([]int, 0, len(packSizes))
+		for _, size := range packSizes {
+			if size <= limits.MaxItems {
+				allowed = append(allowed, size)
+			}
+		}
+		if len(allowed) == 0 {
+			return CalculationResult{}, ErrShipmentLimitTooSmall
+		}
+		packSizes = allowed
+	}
+
+	packs, err := ps.solveWith(packSizes, c.rules, orderSize)
+	if err != nil {
+		return CalculationResult{}, err
+	}
+
+	shipments, err := splitShipments(packs, limits)
+	if err != nil {
+		return CalculationResult{}, err
+	}
+
+	result := NewCalculationResult(orderSize, packs)
+	result.CatalogueVersion = c.version
+	result.Shipments = shipments
+	result.Levels = levelBreakdown(packs, c.levels)
+	ps.calculated(result)
+	return result, nil
+}
+
+// splitShipments distributes the packs over shipments using first-fit decreasing:
+// the largest packs are placed first, each into the first shipment with room for it.
+// The packs of a size are placed in bulk, filling each shipment before the next.
+// It returns ErrTooManyShipments rather than split the packs into more than MaxShipments.
+func splitShipments(packs map[int]int, limits ShipmentLimits) ([]Shipment, error) {
+	var shipments []Shipment
+	for _, size := range sortedSizes(packs) {
+		remaining := packs[size]
+		for i := range shipments {
+			if remaining == 0 {
+				break
+			}
+			count := min(remaining, shipmentRoom(shipments[i], size, limits))
+			shipments[i].add(size, count)
+			remaining -= count
+		}
+		if remaining == 0 {
+			continue
+		}
+
+		perShipment := shipmentRoom(Shipment{}, size, limits)
+		needed := remaining / perShipment
+		if remaining%perShipment != 0 {
+			needed++
+		}
+		if len(shipments)+needed > MaxShipments {
+			return nil, ErrTooManyShipments
+		}
+		for remaining > 0 {
+			count := min(remaining, perShipment)
+			shipment := Shipment{Packs: make(map[int]int)}
+			shipment.add(size, count)
+			shipments = append(shipments, shipment)
+			remaining -= count
+		}
+	}
+	return shipments, nil
+}
+
+// shipmentRoom returns how many more packs of the size fit in the shipment.
+// Without limits that is every pack there can be.
+func shipmentRoom(shipment Shipment, size int, limits ShipmentLimits) int {
+	room := math.MaxInt
+	if limits.MaxItems > 0 {
+		room = min(room, max(limits.MaxItems-shipment.Total, 0)/size)
+	}
+	if limits.MaxPacks > 0 {
+		room = min(room, max(limits.MaxPacks-shipment.PacksCount, 0))
+	}
+	return room
+}
+
+func (s *Shipment) add(size, count int) {
+	if count == 0 {
+		return
+	}
+	s.Packs[size] += count
+	s.Total += size * count
+	s.PacksCount += count
+}
+
+// sortedSizes returns the pack sizes used in packs, largest first.
+func sortedSizes(packs map[int]int) []int {
+	sizes := make([]int, 0, len(packs))
+	for size := range packs {
+		sizes = append(sizes, size)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	return sizes
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"Ship_Manager/internal/rates"
+	"errors"
+)
+
+var (
+	// ErrNoRateTables is returned when shipping is estimated without any carrier rate tables.
+	ErrNoRateTables = errors.New("no carrier rate tables loaded")
+	// ErrInvalidShippingOptions is returned when shipping options have no zone, no item weight or negative values.
+	ErrInvalidShippingOptions = errors.New("shipping needs a zone and a positive item weight, and pack weight and cost must not be negative")
+	// ErrNoQuote is returned when no carrier ships any suitable pack combination to the zone.
+	ErrNoQuote = errors.New("no carrier ships the order to this zone")
+)
+
+// maxLandedCostCandidates bounds the pack combinations compared when minimising the landed cost.
+const maxLandedCostCandidates = 1000
+
+// ShippingOptions describes how an order is shipped. Weights use the units of the rate tables.
+type ShippingOptions struct {
+	Zone       string  `json:"zone"`       // Destination zone of the rate tables
+	ItemWeight float64 `json:"itemWeight"` // Weight of one item
+	PackWeight float64 `json:"packWeight"` // Weight of one empty pack, whatever its size
+	PackCost   float64 `json:"packCost"`   // Cost of one pack, whatever its size
+
+	// MinimiseLandedCost chooses the packs with the lowest packaging and shipping cost
+	// instead of the fewest items.
+	MinimiseLandedCost bool `json:"minimiseLandedCost"`
+}
+
+// ShippingEstimate is the cost of shipping the packs of a result.
+type ShippingEstimate struct {
+	Zone          string        `json:"zone"`
+	Weight        float64       `json:"weight"`        // Weight of the items and packs
+	PackagingCost float64       `json:"packagingCost"` // Cost of the packs
+	Quotes        []rates.Quote `json:"quotes"`        // Price of every carrier that ships the weight to the zone, cheapest first
+	LandedCost    float64       `json:"landedCost"`    // Packaging cost plus the cheapest quote, 0 without quotes
+}
+
+// WithRateTables sets the carrier rate tables that shipping costs are estimated from.
+func WithRateTables(tables rates.Tables) Option {
+	return func(ps *packageService) {
+		ps.rates = tables
+	}
+}
+
+func (ps *packageService) CalculateShipping(orderSize int, options ShippingOptions) (CalculationResult, error) {
+	if len(ps.rates) == 0 {
+		return CalculationResult{}, ErrNoRateTables
+	}
+	if options.Zone == "" || options.ItemWeight <= 0 || options.PackWeight < 0 || options.PackCost < 0 {
+		return CalculationResult{}, ErrInvalidShippingOptions
+	}
+
+	c := ps.activeCatalogue()
+	packs, err := ps.solveWith(c.sizes, c.rules, orderSize)
+	if err != nil {
+		return CalculationResult{}, err
+	}
+	result := NewCalculationResult(orderSize, packs)
+	estimate := ps.estimateShipping(result, options)
+
+	if options.MinimiseLandedCost && len(c.sizes) > 0 {
+		found := len(estimate.Quotes) > 0
+		for _, candidate := range ps.landedCostCandidates(c, orderSize) {
+			candidateEstimate := ps.estimateShipping(candidate, options)
+			if len(candidateEstimate.Quotes) == 0 {
+				continue
+			}
+			if !found || candidateEstimate.LandedCost < estimate.LandedCost {
+				result, estimate, found = candidate, candidateEstimate, true
+			}
+		}
+		if !found {
+			return CalculationResult{}, ErrNoQuote
+		}
+	}
+
+	result.CatalogueVersion = c.version
+	result.Levels = levelBreakdown(result.Packs, c.levels)
+	result.Shipping = &estimate
+	ps.calculated(result)
+	return result, nil
+}
+
+// landedCostCandidates returns the combinations with the fewest packs for every total from
+// the order up to one largest pack above it, smallest total first. Larger totals are not
+// worth shipping: dropping one largest pack would still cover the order with less weight.
+func (ps *packageService) landedCostCandidates(c catalogueSnapshot, orderSize int) []CalculationResult {
+	table, err := ps.packTable(c.sizes).covering(orderSize + c.sizes[0])
+	if err != nil {
+		return nil
+	}
+	limit := orderSize + table.largest
+
+	var candidates []CalculationResult
+	for total := orderSize; total < limit && len(candidates) < maxLandedCostCandidates; {
+		candidate := NewCalculationResult(orderSize, table.solve(total))
+		if candidate.Total < total || candidate.Total >= limit {
+			break
+		}
+		if followsRules(candidate.Packs, c.rules) {
+			candidates = append(candidates, candidate)
+		}
+		total = candidate.Total + 1
+	}
+	return candidates
+}
+
+// estimateShipping prices the packs of a result with every carrier.
+func (ps *packageService) estimateShipping(result CalculationResult, options ShippingOptions) ShippingEstimate {
+	estimate := ShippingEstimate{
+		Zone:          options.Zone,
+		Weight:        float64(result.Total)*options.ItemWeight + float64(result.PacksCount)*options.PackWeight,
+		PackagingCost: float64(result.PacksCount) * options.PackCost,
+	}
+	estimate.Quotes = ps.rates.Quote(options.Zone, estimate.Weight)
+	if len(estimate.Quotes) > 0 {
+		estimate.LandedCost = estimate.PackagingCost + estimate.Quotes[0].Price
+	}
+	return estimate
+}
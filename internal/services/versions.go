@@ -0,0 +1,84 @@
+package services
+
+import (
+	"Ship_Manager/internal/repositories"
+	"slices"
+)
+
+// RuleChange describes how the rule of a pack size differs between two versions.
+// A zero rule means the size had no restriction.
+type RuleChange struct {
+	Size int                   `json:"size"`
+	From repositories.PackRule `json:"from"`
+	To   repositories.PackRule `json:"to"`
+}
+
+// ScheduleChange describes how the availability window of a pack size differs between two versions.
+// A zero schedule means the size was always available.
+type ScheduleChange struct {
+	Size int                   `json:"size"`
+	From repositories.Schedule `json:"from"`
+	To   repositories.Schedule `json:"to"`
+}
+
+// VersionDiff lists the differences between two catalogue versions.
+type VersionDiff struct {
+	From        repositories.CatalogueVersion `json:"from"`
+	To          repositories.CatalogueVersion `json:"to"`
+	Added       []int                         `json:"added"`       // Sizes in To but not in From, in descending order
+	Removed     []int                         `json:"removed"`     // Sizes in From but not in To, in descending order
+	RuleChanges []RuleChange                  `json:"ruleChanges"` // Changed rules of sizes in both versions, in descending order of size
+
+	ScheduleChanges []ScheduleChange `json:"scheduleChanges"` // Changed windows of sizes in both versions, in descending order of size
+}
+
+func (ps *packageService) ListVersions() []repositories.CatalogueVersion {
+	return ps.repository.Versions()
+}
+
+func (ps *packageService) DiffVersions(from, to int) (VersionDiff, error) {
+	fromVersion, err := ps.repository.Version(from)
+	if err != nil {
+		return VersionDiff{}, err
+	}
+	toVersion, err := ps.repository.Version(to)
+	if err != nil {
+		return VersionDiff{}, err
+	}
+
+	diff := VersionDiff{
+		From:        fromVersion,
+		To:          toVersion,
+		Added:       []int{},
+		Removed:     []int{},
+		RuleChanges: []RuleChange{},
+
+		ScheduleChanges: []ScheduleChange{},
+	}
+	for _, size := range toVersion.Sizes {
+		if !slices.Contains(fromVersion.Sizes, size) {
+			diff.Added = append(diff.Added, size)
+		}
+	}
+	for _, size := range fromVersion.Sizes {
+		if !slices.Contains(toVersion.Sizes, size) {
+			diff.Removed = append(diff.Removed, size)
+			continue
+		}
+		if before, after := fromVersion.Rules[size], toVersion.Rules[size]; before != after {
+			diff.RuleChanges = append(diff.RuleChanges, RuleChange{Size: size, From: before, To: after})
+		}
+		if before, after := fromVersion.Schedules[size], toVersion.Schedules[size]; !before.Equal(after) {
+			diff.ScheduleChanges = append(diff.ScheduleChanges, ScheduleChange{Size: size, From: before, To: after})
+		}
+	}
+	return diff, nil
+}
+
+func (ps *packageService) RollbackCatalogue(id int) error {
+	if err := ps.repository.Restore(id); err != nil {
+		return err
+	}
+	ps.catalogueChanged()
+	return nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxSampleOrders bounds the sample orders of an analysis.
+const MaxSampleOrders = 10000
+
+var (
+	// ErrNoOrders is returned when an analysis is given no sample orders.
+	ErrNoOrders = errors.New("no sample orders given")
+	// ErrTooManyOrders is returned when an analysis is given more than MaxSampleOrders sample orders.
+	ErrTooManyOrders = fmt.Errorf("more than %d sample orders given", MaxSampleOrders)
+)
+
+// CatalogueMetrics summarises how a catalogue performs over a sample of orders.
+// Orders the catalogue cannot calculate are counted as unsolvable and left out of the
+// totals and averages.
+type CatalogueMetrics struct {
+	Sizes         []int   `json:"sizes"`
+	Orders        int     `json:"orders"`        // Number of sample orders
+	Unsolvable    int     `json:"unsolvable"`    // Sample orders the catalogue cannot calculate
+	TotalExcess   int     `json:"totalExcess"`   // Items shipped in excess over the solved orders
+	TotalPacks    int     `json:"totalPacks"`    // Packs used over the solved orders
+	TotalCost     float64 `json:"totalCost"`     // Cost of the packs over the solved orders
+	AverageExcess float64 `json:"averageExcess"` // Excess items per solved order
+	AveragePacks  float64 `json:"averagePacks"`  // Packs per solved order
+	AverageCost   float64 `json:"averageCost"`   // Pack cost per solved order
+}
+
+// WhatIfResult compares the current catalogue with a proposed one.
+// The changes are the proposed averages minus the current ones, so negative is better.
+type WhatIfResult struct {
+	Current      CatalogueMetrics `json:"current"`
+	Proposed     CatalogueMetrics `json:"proposed"`
+	ExcessChange float64          `json:"excessChange"`
+	PacksChange  float64          `json:"packsChange"`
+	CostChange   float64          `json:"costChange"`
+}
+
+func (ps *packageService) CompareCatalogue(proposed []int, orders []int, costs map[int]float64) (WhatIfResult, error) {
+	if len(proposed) > MaxPackSizes {
+		return WhatIfResult{}, ErrTooManyPackSizes
+	}
+	sizes, err := normalizeSizes(proposed)
+	if err != nil {
+		return WhatIfResult{}, err
+	}
+	if err := checkTableSize(sizes); err != nil {
+		return WhatIfResult{}, err
+	}
+	if err := validateOrders(orders); err != nil {
+		return WhatIfResult{}, err
+	}
+
+	// Both catalogues are measured by their pack sizes alone, with the same solver,
+	// so rules on the current sizes do not skew the comparison
+	currentSizes := ps.GetPackSizes()
+	current := evaluateCatalogue(currentSizes, orders, costs, tableSolver(ps.packTable(currentSizes)))
+	candidate := evaluateCatalogue(sizes, orders, costs, tableSolver(newPackTable(sizes)))
+
+	return WhatIfResult{
+		Current:      current,
+		Proposed:     candidate,
+		ExcessChange: candidate.AverageExcess - current.AverageExcess,
+		PacksChange:  candidate.AveragePacks - current.AveragePacks,
+		CostChange:   candidate.AverageCost - current.AverageCost,
+	}, nil
+}
+
+// validateOrders checks that a sample of orders is usable for an analysis.
+func validateOrders(orders []int) error {
+	if len(orders) == 0 {
+		return ErrNoOrders
+	}
+	if len(orders) > MaxSampleOrders {
+		return ErrTooManyOrders
+	}
+	for _, order := range orders {
+		if order <= 0 {
+			return ErrInvalidQuantity
+		}
+	}
+	return nil
+}
+
+// tableSolver solves orders with a table, or with a dense table covering the order
+// where the table is partial. It returns ErrNoPackSizes for a table without sizes.
+func tableSolver(table *packTable) func(int) (map[int]int, error) {
+	return func(order int) (map[int]int, error) {
+		if len(table.sizes) == 0 {
+			return nil, ErrNoPackSizes
+		}
+		covering, err := table.covering(order)
+		if err != nil {
+			return nil, err
+		}
+		return covering.solve(order), nil
+	}
+}
+
+// evaluateCatalogue runs every sample order through solve and aggregates the results.
+// Orders solve returns an error for are counted as unsolvable.
+func evaluateCatalogue(sizes []int, orders []int, costs map[int]float64, solve func(int) (map[int]int, error)) CatalogueMetrics {
+	metrics := CatalogueMetrics{Sizes: sizes, Orders: len(orders)}
+	if metrics.Sizes == nil {
+		metrics.Sizes = []int{}
+	}
+
+	for _, order := range orders {
+		packs, err := solve(order)
+		if err != nil {
+			metrics.Unsolvable++
+			continue
+		}
+		result := NewCalculationResult(order, packs)
+		metrics.TotalExcess += result.ExcessItems
+		metrics.TotalPacks += result.PacksCount
+		for size, count := range packs {
+			metrics.TotalCost += float64(count) * costs[size]
+		}
+	}
+
+	solved := metrics.Orders - metrics.Unsolvable
+	if solved == 0 {
+		return metrics
+	}
+	n := float64(solved)
+	metrics.AverageExcess = float64(metrics.TotalExcess) / n
+	metrics.AveragePacks = float64(metrics.TotalPacks) / n
+	metrics.AverageCost = metrics.TotalCost / n
+	return metrics
+}
@@ -0,0 +1,71 @@
+package slips
+
+import "errors"
+
+// ErrUnencodable is returned when a barcode is asked for text outside printable ASCII.
+var ErrUnencodable = errors.New("only printable ASCII characters can be encoded")
+
+// QuietZone is the blank margin, in modules, on either side of a barcode.
+const QuietZone = 10
+
+// code128 holds the widths of the bars and spaces of every Code 128 symbol, starting
+// with a bar. Symbols 0 to 102 are data, 103 to 105 start codes and 106 the stop code.
+var code128 = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// Bar is one bar of a barcode, positioned and sized in modules.
+type Bar struct {
+	X     int
+	Width int
+}
+
+// Barcode is a one-dimensional barcode. Width includes the quiet zones.
+type Barcode struct {
+	Bars  []Bar
+	Width int
+}
+
+// Code128 encodes text as a Code 128 barcode using code set B, which covers printable ASCII.
+func Code128(text string) (Barcode, error) {
+	symbols := []int{code128StartB}
+	checksum := code128StartB
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c < ' ' || c > '~' {
+			return Barcode{}, ErrUnencodable
+		}
+		symbol := int(c - ' ')
+		symbols = append(symbols, symbol)
+		checksum += (i + 1) * symbol
+	}
+	symbols = append(symbols, checksum%103, code128Stop)
+
+	barcode := Barcode{Width: QuietZone}
+	for _, symbol := range symbols {
+		for i, width := range code128[symbol] {
+			modules := int(width - '0')
+			if i%2 == 0 {
+				barcode.Bars = append(barcode.Bars, Bar{X: barcode.Width, Width: modules})
+			}
+			barcode.Width += modules
+		}
+	}
+	barcode.Width += QuietZone
+	return barcode, nil
+}
@@ -0,0 +1,220 @@
+package slips
+
+import (
+	"Ship_Manager/internal/services"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A4 page size and margin, in points.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 50
+)
+
+const (
+	barcodeHeight = 50
+	maxModule     = 1.5 // Widest module of the barcode, in points
+)
+
+// WritePDF writes the slip as a PDF of A4 pages, using the standard Helvetica fonts
+// so that no fonts need to be embedded.
+func (s Slip) WritePDF(w io.Writer) error {
+	doc := &document{}
+	doc.newPage()
+
+	doc.space(24)
+	doc.text(margin, fontBold, 20, "Packing slip")
+	doc.space(20)
+	doc.text(margin, fontRegular, 12, "Order "+s.OrderID)
+
+	module := min(maxModule, float64(pageWidth-2*margin)/float64(s.Barcode.Width))
+	doc.space(barcodeHeight + 8)
+	for _, bar := range s.Barcode.Bars {
+		doc.rect(margin+float64(bar.X)*module, doc.y, float64(bar.Width)*module, barcodeHeight)
+	}
+	doc.space(14)
+	doc.text(margin+QuietZone*module, fontRegular, 10, s.OrderID)
+
+	doc.space(10)
+	result := s.Result
+	summary := [][2]string{
+		{"Order size", strconv.Itoa(result.OrderSize)},
+		{"Total items", strconv.Itoa(result.Total)},
+		{"Excess items", strconv.Itoa(result.ExcessItems)},
+		{"Packs", strconv.Itoa(result.PacksCount)},
+	}
+	if result.CatalogueVersion > 0 {
+		summary = append(summary, [2]string{"Catalogue version", strconv.Itoa(result.CatalogueVersion)})
+	}
+	for _, row := range summary {
+		doc.space(16)
+		doc.text(margin, fontBold, 11, row[0])
+		doc.text(margin+150, fontRegular, 11, row[1])
+	}
+	if len(result.Levels) > 0 {
+		doc.space(16)
+		doc.text(margin, fontRegular, 11, services.DescribeLevels(result.Levels))
+	}
+
+	doc.space(32)
+	doc.text(margin, fontBold, 14, "Pick list")
+	if len(s.Lines) == 0 {
+		doc.space(18)
+		doc.text(margin, fontRegular, 11, "No packs to pick.")
+	} else {
+		columns := []string{"Pack size", "Count", "Items"}
+		if s.HasLevels() {
+			columns = append([]string{"Level"}, columns...)
+		}
+		doc.space(20)
+		for i, column := range columns {
+			doc.text(margin+30+float64(i)*110, fontBold, 11, column)
+		}
+		doc.rect(margin, doc.y-5, pageWidth-2*margin, 0.5)
+
+		for _, line := range s.Lines {
+			cells := []string{strconv.Itoa(line.Size), strconv.Itoa(line.Count), strconv.Itoa(line.Items)}
+			if s.HasLevels() {
+				cells = append([]string{line.Level}, cells...)
+			}
+			doc.space(20)
+			doc.box(margin, doc.y-2, 11)
+			for i, cell := range cells {
+				doc.text(margin+30+float64(i)*110, fontRegular, 11, cell)
+			}
+		}
+	}
+
+	if len(result.Shipments) > 0 {
+		doc.space(32)
+		doc.text(margin, fontBold, 14, fmt.Sprintf("%d shipments", len(result.Shipments)))
+		for i, shipment := range result.Shipments {
+			doc.space(16)
+			doc.text(margin, fontRegular, 11, fmt.Sprintf("%d. %s (%d items, %d packs)", i+1, describePacks(shipment.Packs), shipment.Total, shipment.PacksCount))
+		}
+	}
+
+	_, err := w.Write(doc.bytes())
+	return err
+}
+
+// describePacks formats packs as "1x500 1x250", largest size first.
+func describePacks(packs map[int]int) string {
+	sizes := make([]int, 0, len(packs))
+	for size := range packs {
+		sizes = append(sizes, size)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+
+	parts := make([]string, len(sizes))
+	for i, size := range sizes {
+		parts[i] = fmt.Sprintf("%dx%d", packs[size], size)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Fonts of the page resources.
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// document lays out text and rectangles from the top of a page down,
+// starting a new page when the current one is full.
+type document struct {
+	pages []*bytes.Buffer // Content stream of each page
+	y     float64         // Current position, from the bottom of the page
+}
+
+func (d *document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+// space moves down by height, onto a new page when it does not fit.
+func (d *document) space(height float64) {
+	if d.y-height < margin {
+		d.newPage()
+	}
+	d.y -= height
+}
+
+func (d *document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// text writes s with its baseline at the current position.
+func (d *document) text(x float64, font string, size float64, s string) {
+	fmt.Fprintf(d.page(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.y, escape(s))
+}
+
+// rect fills a rectangle whose bottom left corner is at x, y.
+func (d *document) rect(x, y, width, height float64) {
+	fmt.Fprintf(d.page(), "%.2f %.2f %.2f %.2f re f\n", x, y, width, height)
+}
+
+// box outlines a square, for ticking off by hand.
+func (d *document) box(x, y, size float64) {
+	fmt.Fprintf(d.page(), "0.75 w %.2f %.2f %.2f %.2f re S\n", x, y, size, size)
+}
+
+// bytes assembles the pages into a PDF file: the catalog, the page tree and the two
+// fonts, then a page object and a content stream per page, and the cross-reference table.
+func (d *document) bytes() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // Page tree, once the pages are numbered
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	kids := make([]string, len(d.pages))
+	for i, content := range d.pages {
+		page := len(objects) + 1
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, fontRegular, fontBold, page+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// escape encodes text as the body of a PDF string in WinAnsiEncoding, which matches
+// Latin-1 for the characters printed here. Other characters are replaced with "?".
+func escape(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b = append(b, '\\', byte(r))
+		case r < ' ':
+			b = append(b, ' ')
+		case r < 0x7f || (r >= 0xa0 && r <= 0xff):
+			b = append(b, byte(r))
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
@@ -0,0 +1,95 @@
+// Package slips prints packing slips: the pick list of the packs of a calculation,
+// its totals and a barcode of the order ID, as an HTML page or a PDF.
+package slips
+
+import (
+	"Ship_Manager/internal/services"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// MaxOrderIDLength bounds order IDs so that their barcode fits the width of a page.
+const MaxOrderIDLength = 40
+
+// ErrInvalidOrderID is returned when an order ID is empty, too long or cannot be encoded as a barcode.
+var ErrInvalidOrderID = errors.New("order ID must be 1 to 40 printable ASCII characters")
+
+// Line is one row of the pick list: the packs of one size to pick.
+type Line struct {
+	Level string // Name of the packaging level of the size, if any
+	Size  int    // Number of items in one pack
+	Count int    // Number of packs to pick
+	Items int    // Number of items in those packs
+}
+
+// Slip is a packing slip for one order.
+type Slip struct {
+	OrderID string
+	Result  services.CalculationResult
+	Lines   []Line // Largest size first
+	Barcode Barcode
+}
+
+// New prepares the packing slip of a calculation result.
+func New(orderID string, result services.CalculationResult) (Slip, error) {
+	orderID = strings.TrimSpace(orderID)
+	if err := CheckOrderID(orderID); err != nil {
+		return Slip{}, err
+	}
+	barcode, err := Code128(orderID)
+	if err != nil {
+		return Slip{}, err
+	}
+
+	levels := make(map[int]string, len(result.Levels))
+	for _, level := range result.Levels {
+		levels[level.Size] = level.Level
+	}
+
+	slip := Slip{OrderID: orderID, Result: result, Barcode: barcode}
+	for size, count := range result.Packs {
+		if count > 0 {
+			slip.Lines = append(slip.Lines, Line{Level: levels[size], Size: size, Count: count, Items: size * count})
+		}
+	}
+	sort.Slice(slip.Lines, func(i, j int) bool { return slip.Lines[i].Size > slip.Lines[j].Size })
+	return slip, nil
+}
+
+// CheckOrderID returns ErrInvalidOrderID unless the order ID can be printed as a barcode.
+func CheckOrderID(orderID string) error {
+	if orderID == "" || len(orderID) > MaxOrderIDLength {
+		return ErrInvalidOrderID
+	}
+	for i := 0; i < len(orderID); i++ {
+		if orderID[i] < ' ' || orderID[i] > '~' {
+			return ErrInvalidOrderID
+		}
+	}
+	return nil
+}
+
+// HasLevels reports whether any line has a packaging level, so the level column is worth printing.
+func (s Slip) HasLevels() bool {
+	for _, line := range s.Lines {
+		if line.Level != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// FileName is a name for the slip's PDF made of the order ID, e.g. "packing-slip-SO-1042.pdf".
+// Characters that are not safe in file names are replaced with hyphens.
+func (s Slip) FileName() string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, s.OrderID)
+	return "packing-slip-" + name + ".pdf"
+}
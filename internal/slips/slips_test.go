@@ -0,0 +1,144 @@
+package slips
+
+import (
+	"Ship_Manager/internal/services"
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCode128(t *testing.T) {
+	for symbol, pattern := range code128 {
+		modules := 0
+		for _, width := range pattern {
+			modules += int(width - '0')
+		}
+		want := 11
+		if symbol == code128Stop {
+			want = 13
+		}
+		if modules != want {
+			t.Errorf("Symbol %d is %d modules wide, want %d", symbol, modules, want)
+		}
+	}
+
+	// Bars of start B, "A" (33), the checksum (104 + 33) % 103 = 34 and stop
+	barcode, err := Code128("A")
+	if err != nil {
+		t.Fatalf("Code128 failed: %v", err)
+	}
+	var widths []int
+	for _, bar := range barcode.Bars {
+		widths = append(widths, bar.Width)
+	}
+	want := []int{2, 1, 1, 1, 1, 2, 1, 1, 2, 2, 3, 1, 2}
+	if !reflect.DeepEqual(widths, want) {
+		t.Errorf("Bar widths = %v, want %v", widths, want)
+	}
+	if barcode.Bars[0].X != QuietZone {
+		t.Errorf("First bar at %d, want %d", barcode.Bars[0].X, QuietZone)
+	}
+	if wantWidth := 2*QuietZone + 3*11 + 13; barcode.Width != wantWidth {
+		t.Errorf("Width = %d, want %d", barcode.Width, wantWidth)
+	}
+
+	if _, err := Code128("café"); err != ErrUnencodable {
+		t.Errorf("Expected ErrUnencodable, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	result := services.NewCalculationResult(1300, map[int]int{500: 2, 250: 1, 1000: 0})
+	result.Levels = []services.LevelCount{{Level: "case", Size: 500, Count: 2}, {Size: 250, Count: 1}}
+
+	slip, err := New(" SO-1042 ", result)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if slip.OrderID != "SO-1042" {
+		t.Errorf("OrderID = %q, want SO-1042", slip.OrderID)
+	}
+	wantLines := []Line{{Level: "case", Size: 500, Count: 2, Items: 1000}, {Size: 250, Count: 1, Items: 250}}
+	if !reflect.DeepEqual(slip.Lines, wantLines) {
+		t.Errorf("Lines = %+v, want %+v", slip.Lines, wantLines)
+	}
+	if !slip.HasLevels() {
+		t.Error("Expected the slip to have levels")
+	}
+
+	for _, orderID := range []string{"", "  ", strings.Repeat("X", MaxOrderIDLength+1), "Bestellung-ü"} {
+		if _, err := New(orderID, result); err != ErrInvalidOrderID {
+			t.Errorf("New(%q): expected ErrInvalidOrderID, got %v", orderID, err)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	slip := Slip{OrderID: `SO 1/42"x`}
+	if got, want := slip.FileName(), "packing-slip-SO-1-42-x.pdf"; got != want {
+		t.Errorf("FileName() = %q, want %q", got, want)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	result := services.NewCalculationResult(12001, map[int]int{5000: 2, 2000: 1, 250: 1})
+	result.Shipments = []services.Shipment{{Packs: map[int]int{5000: 2}, Total: 10000, PacksCount: 2}, {Packs: map[int]int{2000: 1, 250: 1}, Total: 2250, PacksCount: 2}}
+	slip, err := New("SO-(7)", result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := slip.WritePDF(&b); err != nil {
+		t.Fatalf("WritePDF failed: %v", err)
+	}
+	pdf := b.String()
+
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("Not a PDF file: %q", pdf[:min(len(pdf), 20)])
+	}
+	for _, text := range []string{`(Order SO-\(7\))`, "(Pick list)", "(2 shipments)", "(1. 2x5000 \\(10000 items, 2 packs\\))", "(Excess items)"} {
+		if !strings.Contains(pdf, text) {
+			t.Errorf("Expected the PDF to contain %s", text)
+		}
+	}
+
+	// Every cross-reference entry points at its object
+	xref := regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllStringSubmatch(pdf, -1)
+	if len(xref) != 6 {
+		t.Fatalf("Expected 6 objects, got %d", len(xref))
+	}
+	for i, entry := range xref {
+		offset, _ := strconv.Atoi(entry[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(pdf[offset:], want) {
+			t.Errorf("Object %d is not at offset %d", i+1, offset)
+		}
+	}
+	start := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	if offset, _ := strconv.Atoi(start[1]); !strings.HasPrefix(pdf[offset:], "xref\n") {
+		t.Errorf("startxref %d does not point at the cross-reference table", offset)
+	}
+}
+
+func TestWritePDFPages(t *testing.T) {
+	packs := make(map[int]int)
+	for size := 1; size <= 60; size++ {
+		packs[size] = 1
+	}
+	slip, err := New("SO-1", services.NewCalculationResult(1830, packs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := slip.WritePDF(&b); err != nil {
+		t.Fatalf("WritePDF failed: %v", err)
+	}
+	if !strings.Contains(b.String(), "/Count 2 >>") {
+		t.Error("Expected a long pick list to continue on a second page")
+	}
+}
@@ -0,0 +1,74 @@
+package tenants
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Handler routes every request to the handler of its tenant. Each tenant's handler
+// is built on first use, so tenants share no state unless the build function does.
+type Handler struct {
+	config Config
+	build  func(tenant string) http.Handler
+
+	mu       sync.Mutex
+	handlers map[string]http.Handler
+}
+
+// NewHandler creates a Handler that resolves tenants with config and builds their handlers with build.
+func NewHandler(config Config, build func(tenant string) http.Handler) *Handler {
+	return &Handler{
+		config:   config,
+		build:    build,
+		handlers: make(map[string]http.Handler),
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenant, err := h.config.Resolve(r)
+	switch err {
+	case nil:
+	case ErrUnknownAPIKey, ErrAPIKeyRequired:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case ErrTenantMismatch:
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case ErrUnknownTenant:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set(HeaderName, tenant)
+	h.handler(tenant).ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+}
+
+// handler returns the handler of a tenant, building it on first use.
+func (h *Handler) handler(tenant string) http.Handler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	handler, ok := h.handlers[tenant]
+	if !ok {
+		handler = h.build(tenant)
+		h.handlers[tenant] = handler
+	}
+	return handler
+}
+
+// Tenants returns the tenants that have been served so far, in alphabetical order.
+func (h *Handler) Tenants() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	tenants := make([]string, 0, len(h.handlers))
+	for tenant := range h.handlers {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
@@ -0,0 +1,177 @@
+// Package tenants resolves which customer account a request belongs to and keeps
+// the data of every account apart.
+package tenants
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultTenant is used when a request names no tenant and API keys are not required.
+const DefaultTenant = "default"
+
+// HeaderName is the request header that names a tenant explicitly.
+const HeaderName = "X-Tenant-ID"
+
+// APIKeyHeader is the request header carrying an API key. A bearer token in the
+// Authorization header is accepted as well.
+const APIKeyHeader = "X-API-Key"
+
+var (
+	// ErrUnknownAPIKey is returned when a request carries an API key that is not configured.
+	ErrUnknownAPIKey = errors.New("unknown API key")
+	// ErrAPIKeyRequired is returned when API keys are required and the request has none.
+	ErrAPIKeyRequired = errors.New("API key required")
+	// ErrInvalidTenant is returned for a tenant ID that is not a lower-case DNS label.
+	ErrInvalidTenant = errors.New("invalid tenant ID")
+	// ErrUnknownTenant is returned for a tenant that is not in the configured list.
+	ErrUnknownTenant = errors.New("unknown tenant")
+	// ErrTenantMismatch is returned when the API key belongs to a different tenant than the one named.
+	ErrTenantMismatch = errors.New("API key does not belong to the requested tenant")
+)
+
+var tenantID = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Config describes how tenants are identified.
+type Config struct {
+	// BaseDomain enables tenants as subdomains, e.g. "ships.example.com" maps
+	// "acme.ships.example.com" to the tenant "acme". Empty disables subdomains.
+	BaseDomain string
+
+	// APIKeys maps each API key to the tenant it belongs to.
+	APIKeys map[string]string
+
+	// Tenants lists the tenants that the header or subdomain can select without an API key.
+	// Other tenants are only reached with one of their API keys, and tenants that own
+	// an API key always need it.
+	Tenants []string
+
+	// RequireAPIKey rejects requests that do not carry a configured API key,
+	// so the header and subdomain alone cannot select a tenant.
+	RequireAPIKey bool
+}
+
+// Resolve returns the tenant of a request. An API key takes precedence, then the
+// X-Tenant-ID header, then the subdomain; without any of them the DefaultTenant is used.
+func (c Config) Resolve(r *http.Request) (string, error) {
+	return c.ResolveCredentials(APIKey(r.Header), r.Header.Get(HeaderName), r.Host)
+}
+
+// ResolveCredentials returns the tenant for an API key, a tenant named explicitly
+// and the host a request was sent to, any of which may be empty. It applies the
+// same precedence as Resolve for transports other than HTTP. A tenant named without
+// an API key must be listed in Tenants and own no API key.
+func (c Config) ResolveCredentials(key, named, host string) (string, error) {
+	if named == "" {
+		named = c.subdomain(host)
+	}
+
+	if key != "" {
+		tenant, ok := c.APIKeys[key]
+		if !ok {
+			return "", ErrUnknownAPIKey
+		}
+		if named != "" && named != tenant {
+			return "", ErrTenantMismatch
+		}
+		return tenant, nil
+	}
+	if c.RequireAPIKey {
+		return "", ErrAPIKeyRequired
+	}
+
+	if named == "" {
+		return DefaultTenant, nil
+	}
+	if !tenantID.MatchString(named) {
+		return "", ErrInvalidTenant
+	}
+	if c.hasAPIKey(named) {
+		return "", ErrAPIKeyRequired
+	}
+	if !slices.Contains(c.Tenants, named) {
+		return "", ErrUnknownTenant
+	}
+	return named, nil
+}
+
+// hasAPIKey reports whether an API key belongs to the tenant.
+func (c Config) hasAPIKey(tenant string) bool {
+	for _, owner := range c.APIKeys {
+		if owner == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// subdomain returns the label in front of the base domain, or "" if there is none.
+func (c Config) subdomain(host string) string {
+	if c.BaseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	label, ok := strings.CutSuffix(host, "."+strings.ToLower(c.BaseDomain))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// APIKey returns the API key from X-API-Key or an Authorization bearer token.
+func APIKey(header http.Header) string {
+	if key := header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+type contextKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenant)
+}
+
+// FromContext returns the tenant stored in ctx, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(contextKey{}).(string)
+	return tenant
+}
+
+// ConfigFromEnv reads the tenant configuration from environment variables:
+// TENANT_BASE_DOMAIN, TENANT_IDS (comma separated), TENANT_API_KEYS
+// (comma separated key=tenant pairs) and TENANT_REQUIRE_API_KEY ("true").
+func ConfigFromEnv(getenv func(string) string) Config {
+	config := Config{
+		BaseDomain:    getenv("TENANT_BASE_DOMAIN"),
+		RequireAPIKey: getenv("TENANT_REQUIRE_API_KEY") == "true",
+	}
+	for _, tenant := range strings.Split(getenv("TENANT_IDS"), ",") {
+		if tenant = strings.TrimSpace(tenant); tenant != "" {
+			config.Tenants = append(config.Tenants, tenant)
+		}
+	}
+	for _, pair := range strings.Split(getenv("TENANT_API_KEYS"), ",") {
+		key, tenant, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" || tenant == "" {
+			continue
+		}
+		if config.APIKeys == nil {
+			config.APIKeys = make(map[string]string)
+		}
+		config.APIKeys[key] = tenant
+	}
+	return config
+}
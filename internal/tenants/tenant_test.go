@@ -0,0 +1,145 @@
+package tenants
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	config := Config{
+		BaseDomain: "ships.example.com",
+		APIKeys:    map[string]string{"acme-key": "acme", "globex-key": "globex"},
+		Tenants:    []string{"initech", "hooli", "acme"},
+	}
+
+	tests := []struct {
+		name    string
+		host    string
+		headers map[string]string
+		want    string
+		err     error
+	}{
+		{name: "Default tenant", host: "ships.example.com", want: DefaultTenant},
+		{name: "Subdomain", host: "initech.ships.example.com:8080", want: "initech"},
+		{name: "Nested subdomain is ignored", host: "a.initech.ships.example.com", want: DefaultTenant},
+		{name: "Other domain is ignored", host: "initech.example.org", want: DefaultTenant},
+		{name: "Header", host: "localhost", headers: map[string]string{HeaderName: "hooli"}, want: "hooli"},
+		{name: "Header wins over subdomain", host: "initech.ships.example.com", headers: map[string]string{HeaderName: "hooli"}, want: "hooli"},
+		{name: "Unlisted tenant", host: "localhost", headers: map[string]string{HeaderName: "umbrella"}, err: ErrUnknownTenant},
+		{name: "Tenant with an API key", host: "localhost", headers: map[string]string{HeaderName: "globex"}, err: ErrAPIKeyRequired},
+		{name: "Listed tenant with an API key", host: "acme.ships.example.com", err: ErrAPIKeyRequired},
+		{name: "API key", host: "localhost", headers: map[string]string{APIKeyHeader: "acme-key"}, want: "acme"},
+		{name: "Bearer token", host: "localhost", headers: map[string]string{"Authorization": "Bearer globex-key"}, want: "globex"},
+		{name: "API key matching the subdomain", host: "acme.ships.example.com", headers: map[string]string{APIKeyHeader: "acme-key"}, want: "acme"},
+		{name: "API key for another tenant", host: "globex.ships.example.com", headers: map[string]string{APIKeyHeader: "acme-key"}, err: ErrTenantMismatch},
+		{name: "API key for another tenant header", host: "localhost", headers: map[string]string{APIKeyHeader: "acme-key", HeaderName: "globex"}, err: ErrTenantMismatch},
+		{name: "Unknown API key", host: "localhost", headers: map[string]string{APIKeyHeader: "stolen"}, err: ErrUnknownAPIKey},
+		{name: "Invalid tenant", host: "localhost", headers: map[string]string{HeaderName: "../acme"}, err: ErrInvalidTenant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/pack-sizes", nil)
+			r.Host = tt.host
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+
+			got, err := config.Resolve(r)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Known tenants only", func(t *testing.T) {
+		config := Config{Tenants: []string{"acme"}}
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(HeaderName, "globex")
+		if _, err := config.Resolve(r); err != ErrUnknownTenant {
+			t.Errorf("Expected ErrUnknownTenant, got %v", err)
+		}
+	})
+
+	t.Run("API key required", func(t *testing.T) {
+		config := Config{APIKeys: map[string]string{"acme-key": "acme"}, RequireAPIKey: true}
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(HeaderName, "acme")
+		if _, err := config.Resolve(r); err != ErrAPIKeyRequired {
+			t.Errorf("Expected ErrAPIKeyRequired, got %v", err)
+		}
+	})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"TENANT_BASE_DOMAIN":     "ships.example.com",
+		"TENANT_IDS":             "acme, globex",
+		"TENANT_API_KEYS":        "k1=acme,k2=globex,broken",
+		"TENANT_REQUIRE_API_KEY": "true",
+	}
+	config := ConfigFromEnv(func(name string) string { return env[name] })
+
+	expected := Config{
+		BaseDomain:    "ships.example.com",
+		Tenants:       []string{"acme", "globex"},
+		APIKeys:       map[string]string{"k1": "acme", "k2": "globex"},
+		RequireAPIKey: true,
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("ConfigFromEnv() = %+v, want %+v", config, expected)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler := NewHandler(Config{APIKeys: map[string]string{"acme-key": "acme"}, Tenants: []string{"globex"}}, func(tenant string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if FromContext(r.Context()) != tenant {
+				t.Errorf("Handler of %q served a request for %q", tenant, FromContext(r.Context()))
+			}
+			w.Write([]byte(tenant))
+		})
+	})
+
+	serve := func(headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+		return rr
+	}
+
+	if rr := serve(map[string]string{APIKeyHeader: "acme-key"}); rr.Body.String() != "acme" || rr.Header().Get(HeaderName) != "acme" {
+		t.Errorf("Unexpected response %q for the acme key", rr.Body.String())
+	}
+	if rr := serve(map[string]string{HeaderName: "globex"}); rr.Body.String() != "globex" {
+		t.Errorf("Unexpected response %q for globex", rr.Body.String())
+	}
+	if rr := serve(nil); rr.Body.String() != DefaultTenant {
+		t.Errorf("Unexpected response %q without a tenant", rr.Body.String())
+	}
+
+	statuses := map[int]map[string]string{
+		http.StatusUnauthorized: {APIKeyHeader: "stolen"},
+		http.StatusForbidden:    {APIKeyHeader: "acme-key", HeaderName: "globex"},
+		http.StatusBadRequest:   {HeaderName: "Not A Tenant"},
+		http.StatusNotFound:     {HeaderName: "initech"},
+	}
+	for status, headers := range statuses {
+		if rr := serve(headers); rr.Code != status {
+			t.Errorf("Expected status %d for %v, got %d", status, headers, rr.Code)
+		}
+	}
+
+	if tenants := handler.Tenants(); !reflect.DeepEqual(tenants, []string{"acme", DefaultTenant, "globex"}) {
+		t.Errorf("Tenants() = %v", tenants)
+	}
+}
@@ -0,0 +1,351 @@
+package webhooks
+
+import (
+	"Ship_Manager/internal/events"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Defaults for a Dispatcher.
+const (
+	DefaultMaxAttempts  = 8
+	DefaultBaseDelay    = time.Second
+	DefaultMaxDelay     = time.Hour
+	DefaultPollInterval = time.Second
+
+	// maxLogEntries is how many finished deliveries are kept in the log.
+	maxLogEntries = 1000
+)
+
+var (
+	// ErrInvalidURL is returned when a subscription URL is not an absolute http or https URL.
+	ErrInvalidURL = errors.New("webhook URL must be an absolute http or https URL")
+	// ErrMissingSecret is returned when a subscription has no signing secret.
+	ErrMissingSecret = errors.New("webhook secret is required")
+	// ErrPrivateURL is returned when a subscription URL points at a loopback, link-local or
+	// private address and the dispatcher does not allow private targets.
+	ErrPrivateURL = errors.New("webhook URL must not point at a loopback, link-local or private address")
+)
+
+// Dispatcher queues events for the matching subscriptions and delivers them,
+// retrying failed deliveries with exponential backoff.
+type Dispatcher struct {
+	outbox *Outbox
+	client *http.Client
+	tenant string
+
+	MaxAttempts int           // Attempts before a delivery is marked failed
+	BaseDelay   time.Duration // Delay before the first retry, doubled for every further one
+	MaxDelay    time.Duration // Upper bound for the delay between attempts
+
+	// AllowPrivateTargets lets subscriptions post to loopback, link-local and private
+	// addresses, which are refused by default so that subscribers cannot reach internal services.
+	AllowPrivateTargets bool
+
+	now  func() time.Time
+	wake chan struct{}
+}
+
+// NewDispatcher creates a Dispatcher for the tenant's outbox. A nil client uses one with a
+// 10 second timeout that refuses to connect to private addresses unless AllowPrivateTargets is set,
+// whatever the host names of the subscriptions resolve to.
+func NewDispatcher(outbox *Outbox, client *http.Client, tenant string) *Dispatcher {
+	d := &Dispatcher{
+		outbox:      outbox,
+		client:      client,
+		tenant:      tenant,
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   DefaultBaseDelay,
+		MaxDelay:    DefaultMaxDelay,
+		now:         time.Now,
+		wake:        make(chan struct{}, 1),
+	}
+	if d.client == nil {
+		dialer := &net.Dialer{Timeout: 10 * time.Second, Control: d.checkAddress}
+		d.client = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 10 * time.Second},
+		}
+	}
+	return d
+}
+
+// checkAddress refuses connections to private addresses, as a net.Dialer Control function.
+func (d *Dispatcher) checkAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !d.AllowPrivateTargets && isPrivate(ip) {
+		return ErrPrivateURL
+	}
+	return nil
+}
+
+// isPrivate reports whether ip is a loopback, link-local, private or unspecified address.
+func isPrivate(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsPrivate() || ip.IsUnspecified()
+}
+
+// Subscribe validates and stores a new subscription. Its ID and creation time are assigned here.
+// Unless AllowPrivateTargets is set, URLs naming localhost or a private address are refused;
+// host names resolving to private addresses are refused when the delivery connects.
+func (d *Dispatcher) Subscribe(sub Subscription) (Subscription, error) {
+	target, err := url.Parse(sub.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return Subscription{}, ErrInvalidURL
+	}
+	if !d.AllowPrivateTargets {
+		host := strings.ToLower(strings.TrimSuffix(target.Hostname(), "."))
+		ip, err := netip.ParseAddr(host)
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") || (err == nil && isPrivate(ip)) {
+			return Subscription{}, ErrPrivateURL
+		}
+	}
+	if sub.Secret == "" {
+		return Subscription{}, ErrMissingSecret
+	}
+
+	sub.ID = newID()
+	sub.CreatedAt = d.now()
+	err = d.outbox.update(func(state *outboxState) error {
+		state.Subscriptions = append(state.Subscriptions, sub)
+		return nil
+	})
+	return sub, err
+}
+
+// Unsubscribe removes a subscription and drops its pending deliveries.
+// It returns ErrSubscriptionNotFound if there is no subscription with the ID.
+func (d *Dispatcher) Unsubscribe(id string) error {
+	return d.outbox.update(func(state *outboxState) error {
+		index := slices.IndexFunc(state.Subscriptions, func(sub Subscription) bool { return sub.ID == id })
+		if index < 0 {
+			return ErrSubscriptionNotFound
+		}
+		state.Subscriptions = slices.Delete(state.Subscriptions, index, index+1)
+		state.Deliveries = slices.DeleteFunc(state.Deliveries, func(delivery Delivery) bool {
+			return delivery.SubscriptionID == id && delivery.Status == StatusPending
+		})
+		return nil
+	})
+}
+
+// Subscriptions returns every subscription without its secret.
+func (d *Dispatcher) Subscriptions() []Subscription {
+	subs := d.outbox.Subscriptions()
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs
+}
+
+// Deliveries returns the delivery log, oldest first.
+func (d *Dispatcher) Deliveries() []Delivery {
+	return d.outbox.Deliveries()
+}
+
+// payload is the JSON body posted for an event.
+type payload struct {
+	ID        string          `json:"id"`
+	Event     string          `json:"event"`
+	Tenant    string          `json:"tenant"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Publish queues an event for every subscription that wants it. Data must be valid JSON.
+func (d *Dispatcher) Publish(event string, data json.RawMessage) error {
+	now := d.now()
+	err := d.outbox.update(func(state *outboxState) error {
+		for _, sub := range state.Subscriptions {
+			if len(sub.Events) > 0 && !slices.Contains(sub.Events, event) {
+				continue
+			}
+			id := newID()
+			body, err := json.Marshal(payload{ID: id, Event: event, Tenant: d.tenant, CreatedAt: now, Data: data})
+			if err != nil {
+				return err
+			}
+			state.Deliveries = append(state.Deliveries, Delivery{
+				ID:             id,
+				SubscriptionID: sub.ID,
+				Event:          event,
+				Payload:        body,
+				Status:         StatusPending,
+				NextAttempt:    now,
+				CreatedAt:      now,
+			})
+		}
+		return nil
+	})
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return err
+}
+
+// Run delivers due events until ctx is cancelled, checking the outbox at every
+// poll interval and whenever an event is published.
+func (d *Dispatcher) Run(ctx context.Context, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.DeliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DeliverDue attempts every pending delivery whose next attempt is due and
+// returns the number of attempts made.
+func (d *Dispatcher) DeliverDue(ctx context.Context) int {
+	now := d.now()
+
+	type attempt struct {
+		delivery Delivery
+		sub      Subscription
+	}
+	var due []attempt
+	d.outbox.mu.Lock()
+	for _, delivery := range d.outbox.state.Deliveries {
+		if delivery.Status != StatusPending || delivery.NextAttempt.After(now) {
+			continue
+		}
+		index := slices.IndexFunc(d.outbox.state.Subscriptions, func(sub Subscription) bool { return sub.ID == delivery.SubscriptionID })
+		if index >= 0 {
+			due = append(due, attempt{delivery, d.outbox.state.Subscriptions[index]})
+		}
+	}
+	d.outbox.mu.Unlock()
+
+	for _, a := range due {
+		code, err := d.send(ctx, a.sub, a.delivery)
+		d.record(a.delivery.ID, code, err)
+	}
+	return len(due)
+}
+
+// send posts one delivery and returns the response status code.
+func (d *Dispatcher) send(ctx context.Context, sub Subscription, delivery Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// record stores the outcome of an attempt and schedules the next one if it failed.
+func (d *Dispatcher) record(id string, code int, sendErr error) {
+	now := d.now()
+	d.outbox.update(func(state *outboxState) error {
+		index := slices.IndexFunc(state.Deliveries, func(delivery Delivery) bool { return delivery.ID == id })
+		if index < 0 {
+			return nil
+		}
+		delivery := &state.Deliveries[index]
+		delivery.Attempts++
+		delivery.ResponseCode = code
+
+		switch {
+		case sendErr == nil:
+			delivery.Status = StatusDelivered
+			delivery.DeliveredAt = now
+			delivery.LastError = ""
+		case delivery.Attempts >= d.MaxAttempts:
+			delivery.Status = StatusFailed
+			delivery.LastError = sendErr.Error()
+		default:
+			delivery.LastError = sendErr.Error()
+			delivery.NextAttempt = now.Add(d.backoff(delivery.Attempts))
+		}
+
+		trimLog(state)
+		return nil
+	})
+}
+
+// backoff returns the delay after the given number of failed attempts:
+// BaseDelay, then twice as long for every further attempt, up to MaxDelay.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.BaseDelay
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= d.MaxDelay {
+			return d.MaxDelay
+		}
+	}
+	return delay
+}
+
+// trimLog drops the oldest finished deliveries beyond maxLogEntries. Pending deliveries are always kept.
+func trimLog(state *outboxState) {
+	finished := 0
+	for _, delivery := range state.Deliveries {
+		if delivery.Status != StatusPending {
+			finished++
+		}
+	}
+	for i := 0; finished > maxLogEntries && i < len(state.Deliveries); {
+		if state.Deliveries[i].Status != StatusPending {
+			state.Deliveries = slices.Delete(state.Deliveries, i, i+1)
+			finished--
+			continue
+		}
+		i++
+	}
+}
+
+// newID returns a random 16 byte hex identifier.
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Enqueue publishes an event of the package service, whose data is JSON. It is meant to be
+// registered with the service's HandleEvents, so that every event reaches the outbox.
+func (d *Dispatcher) Enqueue(event events.Event) {
+	d.Publish(event.Name, json.RawMessage(event.Data))
+}
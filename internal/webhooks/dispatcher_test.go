@@ -0,0 +1,291 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver records the deliveries posted to it and answers with the queued status codes, then 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	if len(rc.statuses) > 0 {
+		w.WriteHeader(rc.statuses[0])
+		rc.statuses = rc.statuses[1:]
+	}
+}
+
+func newTestDispatcher(t *testing.T, path string) (*Dispatcher, *time.Time) {
+	t.Helper()
+	outbox, err := NewOutbox(path)
+	if err != nil {
+		t.Fatalf("NewOutbox: %v", err)
+	}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	d := NewDispatcher(outbox, nil, "acme")
+	d.now = func() time.Time { return now }
+	d.AllowPrivateTargets = true // The test receivers listen on loopback
+	return d, &now
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"event":"packSizesChanged"}`)
+	signature := Sign("secret", "1700000000", body)
+
+	if !Verify("secret", "1700000000", body, signature) {
+		t.Errorf("Expected signature %q to verify", signature)
+	}
+	if Verify("other", "1700000000", body, signature) {
+		t.Error("Expected a different secret to fail")
+	}
+	if Verify("secret", "1700000001", body, signature) {
+		t.Error("Expected a different timestamp to fail")
+	}
+	if Verify("secret", "1700000000", []byte(`{}`), signature) {
+		t.Error("Expected a different body to fail")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	d, _ := newTestDispatcher(t, "")
+
+	tests := []struct {
+		name string
+		sub  Subscription
+		err  error
+	}{
+		{name: "Valid", sub: Subscription{URL: "https://example.com/hook", Secret: "s"}},
+		{name: "Relative URL", sub: Subscription{URL: "/hook", Secret: "s"}, err: ErrInvalidURL},
+		{name: "Other scheme", sub: Subscription{URL: "ftp://example.com", Secret: "s"}, err: ErrInvalidURL},
+		{name: "Missing secret", sub: Subscription{URL: "https://example.com/hook"}, err: ErrMissingSecret},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := d.Subscribe(tt.sub)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if err == nil && sub.ID == "" {
+				t.Error("Expected an ID to be assigned")
+			}
+		})
+	}
+
+	for _, sub := range d.Subscriptions() {
+		if sub.Secret != "" {
+			t.Errorf("Expected secret of %s to be hidden", sub.ID)
+		}
+	}
+}
+
+func TestPrivateTargets(t *testing.T) {
+	d, _ := newTestDispatcher(t, "")
+	d.AllowPrivateTargets = false
+
+	for _, target := range []string{
+		"http://localhost:8080/hook",
+		"http://api.localhost/hook",
+		"http://127.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		if _, err := d.Subscribe(Subscription{URL: target, Secret: "s"}); err != ErrPrivateURL {
+			t.Errorf("Subscribe(%s): expected ErrPrivateURL, got %v", target, err)
+		}
+	}
+
+	// Host names are checked once resolved, when the delivery connects
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	d.outbox.update(func(state *outboxState) error {
+		state.Subscriptions = append(state.Subscriptions, Subscription{ID: "sub", URL: server.URL, Secret: "s"})
+		return nil
+	})
+	d.Publish("packSizesChanged", json.RawMessage(`[]`))
+	d.DeliverDue(context.Background())
+
+	if len(rc.requests) != 0 {
+		t.Errorf("Expected no request to reach the loopback receiver, got %d", len(rc.requests))
+	}
+	if deliveries := d.Deliveries(); len(deliveries) != 1 || !strings.Contains(deliveries[0].LastError, ErrPrivateURL.Error()) {
+		t.Errorf("Expected the delivery to fail on the private address, got %+v", deliveries)
+	}
+}
+
+func TestDeliverDue(t *testing.T) {
+	t.Run("Delivers signed payloads to matching subscriptions", func(t *testing.T) {
+		rc := &receiver{}
+		server := httptest.NewServer(rc)
+		defer server.Close()
+
+		d, now := newTestDispatcher(t, "")
+		all, _ := d.Subscribe(Subscription{URL: server.URL + "/all", Secret: "all-secret"})
+		d.Subscribe(Subscription{URL: server.URL + "/large", Secret: "large-secret", Events: []string{"largeCalculation"}})
+
+		d.Publish("packSizesChanged", json.RawMessage(`[500,250]`))
+		if n := d.DeliverDue(context.Background()); n != 1 {
+			t.Fatalf("Expected 1 attempt, got %d", n)
+		}
+
+		req, body := rc.requests[0], rc.bodies[0]
+		if req.URL.Path != "/all" {
+			t.Errorf("Expected delivery to /all, got %s", req.URL.Path)
+		}
+		if req.Header.Get(EventHeader) != "packSizesChanged" {
+			t.Errorf("Unexpected event header %q", req.Header.Get(EventHeader))
+		}
+		if !Verify("all-secret", req.Header.Get(TimestampHeader), body, req.Header.Get(SignatureHeader)) {
+			t.Error("Expected the signature to verify")
+		}
+
+		var got payload
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("Invalid payload: %v", err)
+		}
+		if got.Tenant != "acme" || string(got.Data) != `[500,250]` || !got.CreatedAt.Equal(*now) {
+			t.Errorf("Unexpected payload %+v", got)
+		}
+
+		delivery := d.Deliveries()[0]
+		if delivery.SubscriptionID != all.ID || delivery.Status != StatusDelivered || delivery.Attempts != 1 || delivery.ResponseCode != http.StatusOK {
+			t.Errorf("Unexpected delivery %+v", delivery)
+		}
+	})
+
+	t.Run("Retries with exponential backoff", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusServiceUnavailable}}
+		server := httptest.NewServer(rc)
+		defer server.Close()
+
+		d, now := newTestDispatcher(t, "")
+		d.Subscribe(Subscription{URL: server.URL, Secret: "s"})
+		d.Publish("packSizesChanged", json.RawMessage(`[]`))
+
+		d.DeliverDue(context.Background())
+		delivery := d.Deliveries()[0]
+		if delivery.Status != StatusPending || delivery.ResponseCode != http.StatusInternalServerError {
+			t.Fatalf("Expected a pending retry, got %+v", delivery)
+		}
+		if want := now.Add(d.BaseDelay); !delivery.NextAttempt.Equal(want) {
+			t.Errorf("Expected next attempt at %v, got %v", want, delivery.NextAttempt)
+		}
+
+		if n := d.DeliverDue(context.Background()); n != 0 {
+			t.Errorf("Expected no attempt before the retry is due, got %d", n)
+		}
+
+		*now = now.Add(d.BaseDelay)
+		d.DeliverDue(context.Background())
+		if want := now.Add(2 * d.BaseDelay); !d.Deliveries()[0].NextAttempt.Equal(want) {
+			t.Errorf("Expected next attempt at %v, got %v", want, d.Deliveries()[0].NextAttempt)
+		}
+
+		*now = now.Add(2 * d.BaseDelay)
+		d.DeliverDue(context.Background())
+		delivery = d.Deliveries()[0]
+		if delivery.Status != StatusDelivered || delivery.Attempts != 3 || delivery.LastError != "" {
+			t.Errorf("Expected delivery after 3 attempts, got %+v", delivery)
+		}
+	})
+
+	t.Run("Fails after the maximum attempts", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusBadGateway, http.StatusBadGateway}}
+		server := httptest.NewServer(rc)
+		defer server.Close()
+
+		d, now := newTestDispatcher(t, "")
+		d.MaxAttempts = 2
+		d.Subscribe(Subscription{URL: server.URL, Secret: "s"})
+		d.Publish("packSizesChanged", json.RawMessage(`[]`))
+
+		for range 3 {
+			d.DeliverDue(context.Background())
+			*now = now.Add(d.MaxDelay)
+		}
+
+		delivery := d.Deliveries()[0]
+		if delivery.Status != StatusFailed || delivery.Attempts != 2 || delivery.LastError == "" {
+			t.Errorf("Expected a failed delivery after 2 attempts, got %+v", delivery)
+		}
+		if len(rc.requests) != 2 {
+			t.Errorf("Expected 2 requests, got %d", len(rc.requests))
+		}
+	})
+
+	t.Run("Unsubscribe drops pending deliveries", func(t *testing.T) {
+		d, _ := newTestDispatcher(t, "")
+		sub, _ := d.Subscribe(Subscription{URL: "http://127.0.0.1:1", Secret: "s"})
+		d.Publish("packSizesChanged", json.RawMessage(`[]`))
+
+		if err := d.Unsubscribe(sub.ID); err != nil {
+			t.Fatalf("Unsubscribe: %v", err)
+		}
+		if len(d.Deliveries()) != 0 {
+			t.Errorf("Expected no deliveries, got %+v", d.Deliveries())
+		}
+		if err := d.Unsubscribe(sub.ID); err != ErrSubscriptionNotFound {
+			t.Errorf("Expected ErrSubscriptionNotFound, got %v", err)
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(nil, nil, "")
+	d.BaseDelay = time.Second
+	d.MaxDelay = 10 * time.Second
+
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 30: 10 * time.Second} {
+		if got := d.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestOutboxPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acme.json")
+
+	d, _ := newTestDispatcher(t, path)
+	sub, _ := d.Subscribe(Subscription{URL: "https://example.com/hook", Secret: "s", Events: []string{"largeCalculation"}})
+	d.Publish("largeCalculation", json.RawMessage(`{"order":10000}`))
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the outbox holding the secrets to be readable by its owner only, got %v %v", info.Mode(), err)
+	}
+
+	reloaded, _ := newTestDispatcher(t, path)
+	subs := reloaded.outbox.Subscriptions()
+	if len(subs) != 1 || subs[0].ID != sub.ID || subs[0].Secret != "s" {
+		t.Errorf("Expected the subscription to be reloaded, got %+v", subs)
+	}
+	deliveries := reloaded.Deliveries()
+	if len(deliveries) != 1 || deliveries[0].Status != StatusPending || deliveries[0].Event != "largeCalculation" {
+		t.Errorf("Expected the pending delivery to be reloaded, got %+v", deliveries)
+	}
+}
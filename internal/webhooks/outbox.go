@@ -0,0 +1,136 @@
+// Package webhooks notifies external systems of catalogue events by posting
+// signed JSON to subscribed URLs, retrying failed deliveries from an outbox.
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Delivery states.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// ErrSubscriptionNotFound is returned when a subscription ID does not exist.
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+// Subscription asks for the given events to be posted to a URL.
+type Subscription struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"` // Event names to deliver, every event when empty
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Delivery is one event queued for one subscription, together with the outcome of every attempt.
+type Delivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscriptionId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"` // Body posted to the subscriber
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttempt    time.Time       `json:"nextAttempt"`
+	LastError      string          `json:"lastError,omitempty"`
+	ResponseCode   int             `json:"responseCode,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    time.Time       `json:"deliveredAt"`
+}
+
+// outboxState is what the outbox writes to disk.
+type outboxState struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	Deliveries    []Delivery     `json:"deliveries"`
+}
+
+// Outbox holds the subscriptions and the queue of deliveries. When it has a path
+// every change is written to that file, so pending deliveries survive a restart.
+// The file holds the signing secrets in plain text, so it is only readable by its owner.
+type Outbox struct {
+	path string
+
+	mu    sync.Mutex
+	state outboxState
+}
+
+// NewOutbox creates an outbox stored in the file at path, loading it if it exists.
+// An empty path keeps the outbox in memory.
+func NewOutbox(path string) (*Outbox, error) {
+	o := &Outbox{path: path}
+	if path == "" {
+		return o, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &o.state); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// update applies change under the lock and persists the result.
+func (o *Outbox) update(change func(state *outboxState) error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := change(&o.state); err != nil {
+		return err
+	}
+	return o.save()
+}
+
+// save writes the state to a temporary file and renames it over the outbox file.
+// Temporary files are created with mode 0600, which the outbox file keeps.
+// The caller must hold the lock.
+func (o *Outbox) save() error {
+	if o.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(o.state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), filepath.Base(o.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), o.path)
+}
+
+// Subscriptions returns every subscription, oldest first.
+func (o *Outbox) Subscriptions() []Subscription {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Subscription{}, o.state.Subscriptions...)
+}
+
+// Deliveries returns the delivery log, oldest first.
+func (o *Outbox) Deliveries() []Delivery {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Delivery{}, o.state.Deliveries...)
+}
@@ -0,0 +1,34 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Headers sent with every delivery.
+const (
+	SignatureHeader = "X-Webhook-Signature" // "sha256=" followed by the hex HMAC of timestamp + "." + body
+	TimestampHeader = "X-Webhook-Timestamp" // Unix time of the attempt, part of the signed content
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Sign returns the signature header value for a delivery body sent at the given Unix timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for the body and timestamp.
+// Receivers should also reject timestamps that are too old to prevent replays.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
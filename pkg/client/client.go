@@ -0,0 +1,409 @@
+// Package client is a Go client for the Ship Manager HTTP API, following the
+// OpenAPI document served at /openapi.json.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the HTTP API of a Ship Manager server.
+type Client struct {
+	BaseURL    string       // Server address, e.g. "http://localhost:8080"
+	HTTPClient *http.Client // http.DefaultClient when nil
+	APIKey     string       // Sent as X-API-Key when set
+	Tenant     string       // Sent as X-Tenant-ID when set
+}
+
+// New returns a client for the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Error is a response with an unexpected status code.
+type Error struct {
+	StatusCode int
+	Message    string       // Error message, or the trimmed response body when it is not JSON
+	Fields     []FieldError // Invalid request fields, if any
+
+	body []byte
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// PackSizes returns the pack sizes available now, in descending order.
+func (c *Client) PackSizes(ctx context.Context) ([]int, error) {
+	return c.PackSizesAt(ctx, time.Time{})
+}
+
+// PackSizesAt returns the pack sizes available at t, in descending order.
+func (c *Client) PackSizesAt(ctx context.Context, t time.Time) ([]int, error) {
+	query := url.Values{}
+	if !t.IsZero() {
+		query.Set("at", t.Format(time.RFC3339))
+	}
+	var sizes []int
+	err := c.do(ctx, http.MethodGet, "/pack-sizes", query, nil, &sizes, nil)
+	return sizes, err
+}
+
+// AddPack adds a pack size.
+func (c *Client) AddPack(ctx context.Context, size int) error {
+	return c.AddScheduledPack(ctx, size, Schedule{})
+}
+
+// AddScheduledPack adds a pack size that is only available within the schedule.
+func (c *Client) AddScheduledPack(ctx context.Context, size int, schedule Schedule) error {
+	form := url.Values{"size": {strconv.Itoa(size)}}
+	setDate(form, "effectiveFrom", schedule.EffectiveFrom)
+	setDate(form, "effectiveUntil", schedule.EffectiveUntil)
+	return c.postForm(ctx, "/pack-sizes", form, nil)
+}
+
+// RemovePack removes a pack size.
+func (c *Client) RemovePack(ctx context.Context, size int) error {
+	return c.do(ctx, http.MethodDelete, "/pack-sizes/"+strconv.Itoa(size), nil, nil, nil, nil)
+}
+
+// SchedulePackRemoval keeps a pack size available until at.
+func (c *Client) SchedulePackRemoval(ctx context.Context, size int, at time.Time) error {
+	query := url.Values{}
+	setDate(query, "effectiveFrom", at)
+	return c.do(ctx, http.MethodDelete, "/pack-sizes/"+strconv.Itoa(size), query, nil, nil, nil)
+}
+
+// ClearPacks removes every pack size.
+func (c *Client) ClearPacks(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, "/pack-sizes", nil, nil, nil, nil)
+}
+
+// Calculate returns the packs needed for an order.
+func (c *Client) Calculate(ctx context.Context, order int) (CalculationResult, error) {
+	return c.CalculateAt(ctx, order, time.Time{})
+}
+
+// CalculateAt returns the packs needed for an order shipped at shipDate,
+// using the pack sizes available then.
+func (c *Client) CalculateAt(ctx context.Context, order int, shipDate time.Time) (CalculationResult, error) {
+	form := url.Values{"order": {strconv.Itoa(order)}, "levels": {"true"}}
+	setDate(form, "shipDate", shipDate)
+
+	var result CalculationResult
+	var header http.Header
+	if err := c.do(ctx, http.MethodPost, "/calculate", nil, form, &result, &header); err != nil {
+		return CalculationResult{}, err
+	}
+	result.CatalogueVersion, _ = strconv.Atoi(header.Get("X-Catalogue-Version"))
+	return result, nil
+}
+
+// CalculateShipments returns the packs needed for an order split into shipments within the limits.
+func (c *Client) CalculateShipments(ctx context.Context, order int, limits ShipmentLimits) (CalculationResult, error) {
+	form := url.Values{"order": {strconv.Itoa(order)}}
+	if limits.MaxItems > 0 {
+		form.Set("maxItemsPerShipment", strconv.Itoa(limits.MaxItems))
+	}
+	if limits.MaxPacks > 0 {
+		form.Set("maxPacksPerShipment", strconv.Itoa(limits.MaxPacks))
+	}
+	var result CalculationResult
+	err := c.postForm(ctx, "/calculate", form, &result)
+	return result, err
+}
+
+// CalculateShipping returns the packs needed for an order with a shipping quote per carrier.
+func (c *Client) CalculateShipping(ctx context.Context, order int, options ShippingOptions) (CalculationResult, error) {
+	form := url.Values{
+		"order":      {strconv.Itoa(order)},
+		"zone":       {options.Zone},
+		"itemWeight": {strconv.FormatFloat(options.ItemWeight, 'f', -1, 64)},
+		"packWeight": {strconv.FormatFloat(options.PackWeight, 'f', -1, 64)},
+		"packCost":   {strconv.FormatFloat(options.PackCost, 'f', -1, 64)},
+	}
+	if options.MinimiseLandedCost {
+		form.Set("objective", "landedCost")
+	}
+	var result CalculationResult
+	err := c.postForm(ctx, "/calculate", form, &result)
+	return result, err
+}
+
+// CalculateExact returns packs adding up to exactly the order.
+// When there are none the error is a *NoExactFit with the nearest quantities that fit.
+func (c *Client) CalculateExact(ctx context.Context, order int) (CalculationResult, error) {
+	form := url.Values{"order": {strconv.Itoa(order)}, "mode": {"exact"}}
+	var packs map[int]int
+	err := c.postForm(ctx, "/calculate", form, &packs)
+	if apiErr, ok := err.(*Error); ok && apiErr.StatusCode == http.StatusUnprocessableEntity {
+		var fit NoExactFit
+		if json.Unmarshal(apiErr.body, &fit) == nil {
+			return CalculationResult{}, &fit
+		}
+	}
+	if err != nil {
+		return CalculationResult{}, err
+	}
+	return newCalculationResult(order, packs), nil
+}
+
+// Explain returns the packs needed for an order together with the runner-up combinations.
+func (c *Client) Explain(ctx context.Context, order int) (Explanation, error) {
+	var explanation Explanation
+	err := c.postForm(ctx, "/calculate", url.Values{"order": {strconv.Itoa(order)}, "explain": {"true"}}, &explanation)
+	return explanation, err
+}
+
+// CalculateOrder calculates a multi-line order.
+func (c *Client) CalculateOrder(ctx context.Context, lines []OrderLine) (OrderResult, error) {
+	var result OrderResult
+	err := c.postJSON(ctx, "/calculate-order", map[string][]OrderLine{"lines": lines}, &result)
+	return result, err
+}
+
+// WhatIf compares a proposed catalogue with the current one.
+func (c *Client) WhatIf(ctx context.Context, req WhatIfRequest) (WhatIfResult, error) {
+	var result WhatIfResult
+	err := c.postJSON(ctx, "/what-if", req, &result)
+	return result, err
+}
+
+// Optimize recommends the best catalogues of K pack sizes, best first.
+func (c *Client) Optimize(ctx context.Context, req OptimizeRequest) ([]Recommendation, error) {
+	var recommendations []Recommendation
+	err := c.postJSON(ctx, "/optimize", req, &recommendations)
+	return recommendations, err
+}
+
+// LoadContainers loads packs into containers by volume and weight.
+func (c *Client) LoadContainers(ctx context.Context, req ContainerRequest) (ContainerResult, error) {
+	var result ContainerResult
+	err := c.postJSON(ctx, "/load-containers", req, &result)
+	return result, err
+}
+
+// PackingSlipPDF returns the packing slip of a calculation result as a PDF: the pick list
+// of its packs, the totals and a barcode of orderID.
+func (c *Client) PackingSlipPDF(ctx context.Context, orderID string, result CalculationResult) ([]byte, error) {
+	body, err := json.Marshal(struct {
+		OrderID string            `json:"orderId"`
+		Result  CalculationResult `json:"result"`
+	}{orderID, result})
+	if err != nil {
+		return nil, err
+	}
+	var pdf []byte
+	err = c.send(ctx, http.MethodPost, "/packing-slip", url.Values{"format": {"pdf"}}, bytes.NewReader(body), "application/json", &pdf, nil)
+	return pdf, err
+}
+
+// PackRules returns the usage rules by pack size.
+func (c *Client) PackRules(ctx context.Context) (map[int]PackRule, error) {
+	var rules map[int]PackRule
+	err := c.do(ctx, http.MethodGet, "/pack-rules", nil, nil, &rules, nil)
+	return rules, err
+}
+
+// SetPackRule sets the usage rule of a pack size and returns the updated rules.
+func (c *Client) SetPackRule(ctx context.Context, size int, rule PackRule) (map[int]PackRule, error) {
+	form := url.Values{
+		"size":     {strconv.Itoa(size)},
+		"minCount": {strconv.Itoa(rule.MinCount)},
+		"maxCount": {strconv.Itoa(rule.MaxCount)},
+		"disabled": {strconv.FormatBool(rule.Disabled)},
+	}
+	var rules map[int]PackRule
+	err := c.postForm(ctx, "/pack-rules", form, &rules)
+	return rules, err
+}
+
+// PackagingLevels returns the packaging levels, largest first.
+func (c *Client) PackagingLevels(ctx context.Context) ([]PackagingLevel, error) {
+	var levels []PackagingLevel
+	err := c.do(ctx, http.MethodGet, "/packaging-levels", nil, nil, &levels, nil)
+	return levels, err
+}
+
+// AddPackagingLevel defines a level of quantity units, the name of a smaller level or
+// "item", and returns the updated levels. The level's pack size is added if it is missing.
+func (c *Client) AddPackagingLevel(ctx context.Context, name string, quantity int, unit string) ([]PackagingLevel, error) {
+	form := url.Values{
+		"name":     {name},
+		"quantity": {strconv.Itoa(quantity)},
+		"unit":     {unit},
+	}
+	var levels []PackagingLevel
+	err := c.postForm(ctx, "/packaging-levels", form, &levels)
+	return levels, err
+}
+
+// RemovePackagingLevel removes a packaging level and its pack size.
+func (c *Client) RemovePackagingLevel(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/packaging-levels/"+url.PathEscape(name), nil, nil, nil, nil)
+}
+
+// PackSchedules returns the availability windows of the scheduled pack sizes.
+func (c *Client) PackSchedules(ctx context.Context) (map[int]Schedule, error) {
+	var schedules map[int]Schedule
+	err := c.do(ctx, http.MethodGet, "/pack-schedules", nil, nil, &schedules, nil)
+	return schedules, err
+}
+
+// Versions returns the catalogue versions kept in the history, oldest first.
+func (c *Client) Versions(ctx context.Context) ([]CatalogueVersion, error) {
+	var versions []CatalogueVersion
+	err := c.do(ctx, http.MethodGet, "/versions", nil, nil, &versions, nil)
+	return versions, err
+}
+
+// VersionDiff compares two catalogue versions.
+func (c *Client) VersionDiff(ctx context.Context, from, to int) (VersionDiff, error) {
+	query := url.Values{"from": {strconv.Itoa(from)}, "to": {strconv.Itoa(to)}}
+	var diff VersionDiff
+	err := c.do(ctx, http.MethodGet, "/versions/diff", query, nil, &diff, nil)
+	return diff, err
+}
+
+// Rollback restores an earlier catalogue version and returns the version recording it.
+func (c *Client) Rollback(ctx context.Context, version int) (CatalogueVersion, error) {
+	var restored CatalogueVersion
+	err := c.postForm(ctx, "/rollback", url.Values{"version": {strconv.Itoa(version)}}, &restored)
+	return restored, err
+}
+
+// Webhooks returns the webhook subscriptions, without their secrets.
+func (c *Client) Webhooks(ctx context.Context) ([]Subscription, error) {
+	var subs []Subscription
+	err := c.do(ctx, http.MethodGet, "/webhooks", nil, nil, &subs, nil)
+	return subs, err
+}
+
+// Subscribe creates a webhook subscription.
+func (c *Client) Subscribe(ctx context.Context, sub Subscription) (Subscription, error) {
+	var created Subscription
+	err := c.postJSON(ctx, "/webhooks", sub, &created)
+	return created, err
+}
+
+// Unsubscribe removes a webhook subscription.
+func (c *Client) Unsubscribe(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/webhooks/"+url.PathEscape(id), nil, nil, nil, nil)
+}
+
+// Deliveries returns the webhook delivery log, optionally only the deliveries in one status.
+func (c *Client) Deliveries(ctx context.Context, status string) ([]Delivery, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	var deliveries []Delivery
+	err := c.do(ctx, http.MethodGet, "/webhooks/deliveries", query, nil, &deliveries, nil)
+	return deliveries, err
+}
+
+func (c *Client) postForm(ctx context.Context, path string, form url.Values, out any) error {
+	return c.do(ctx, http.MethodPost, path, nil, form, out, nil)
+}
+
+func (c *Client) postJSON(ctx context.Context, path string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, http.MethodPost, path, nil, bytes.NewReader(body), "application/json", out, nil)
+}
+
+// do sends a request with an optional form body.
+func (c *Client) do(ctx context.Context, method, path string, query, form url.Values, out any, header *http.Header) error {
+	if form == nil {
+		return c.send(ctx, method, path, query, nil, "", out, header)
+	}
+	return c.send(ctx, method, path, query, strings.NewReader(form.Encode()), "application/x-www-form-urlencoded", out, header)
+}
+
+// send performs a request, decodes a JSON response into out when it is not nil, or
+// reads the raw body when out is a *[]byte, and stores the response header in header
+// when it is not nil.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string, out any, header *http.Header) error {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+	if c.Tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.Tenant)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		apiErr := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body)), body: body}
+		var structured struct {
+			Error  string       `json:"error"`
+			Fields []FieldError `json:"fields"`
+		}
+		if json.Unmarshal(body, &structured) == nil && structured.Error != "" {
+			apiErr.Message = structured.Error
+			apiErr.Fields = structured.Fields
+		}
+		return apiErr
+	}
+	if header != nil {
+		*header = resp.Header
+	}
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*out, err = io.ReadAll(resp.Body)
+		return err
+	default:
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+// setDate adds a date form value unless t is zero.
+func setDate(form url.Values, name string, t time.Time) {
+	if !t.IsZero() {
+		form.Set(name, t.Format(time.RFC3339))
+	}
+}
+
+// newCalculationResult summarises the packs returned for an order.
+func newCalculationResult(order int, packs map[int]int) CalculationResult {
+	result := CalculationResult{Packs: packs, OrderSize: order}
+	for size, count := range packs {
+		result.Total += size * count
+		result.PacksCount += count
+	}
+	result.ExcessItems = max(result.Total-order, 0)
+	return result
+}
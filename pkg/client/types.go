@@ -0,0 +1,289 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The types below mirror the schemas of the OpenAPI document at /openapi.json.
+
+// CalculationResult is the outcome of a calculation.
+type CalculationResult struct {
+	Packs            map[int]int       `json:"packs"`               // Number of packs by pack size
+	Total            int               `json:"total"`               // Total number of items that will be shipped
+	OrderSize        int               `json:"orderSize"`           // Original order size
+	ExcessItems      int               `json:"excessItems"`         // Number of items shipped in excess of the order
+	PacksCount       int               `json:"packsCount"`          // Total number of packs used
+	Shipments        []Shipment        `json:"shipments,omitempty"` // Split of the packs into shipments, when limits apply
+	Levels           []LevelCount      `json:"levels,omitempty"`    // Packs by packaging level, when the catalogue has levels
+	Shipping         *ShippingEstimate `json:"shipping,omitempty"`  // Cost of shipping the packs, when requested
+	CatalogueVersion int               `json:"catalogueVersion,omitempty"`
+}
+
+// ShippingOptions asks for the shipping cost of an order. Weights use the units of the
+// server's rate tables; PackWeight and PackCost apply to one pack of any size.
+type ShippingOptions struct {
+	Zone               string
+	ItemWeight         float64
+	PackWeight         float64
+	PackCost           float64
+	MinimiseLandedCost bool // Choose the packs with the lowest packaging and shipping cost instead of the fewest items
+}
+
+// ShippingEstimate is the cost of shipping the packs of a result.
+type ShippingEstimate struct {
+	Zone          string  `json:"zone"`
+	Weight        float64 `json:"weight"`
+	PackagingCost float64 `json:"packagingCost"`
+	Quotes        []Quote `json:"quotes"` // Cheapest first
+	LandedCost    float64 `json:"landedCost"`
+}
+
+// Quote is the price a carrier asks for a shipment.
+type Quote struct {
+	Carrier   string  `json:"carrier"`
+	Zone      string  `json:"zone"`
+	MaxWeight float64 `json:"maxWeight"` // Upper limit of the weight band
+	Price     float64 `json:"price"`
+}
+
+// LevelCount is the number of packs of one packaging level. Sizes without a level have no Level.
+type LevelCount struct {
+	Level    string `json:"level,omitempty"`
+	Size     int    `json:"size"`
+	Count    int    `json:"count"`
+	Quantity int    `json:"quantity,omitempty"`
+	Unit     string `json:"unit,omitempty"`
+}
+
+// PackagingLevel names a pack size as a number of units of a smaller level or of items.
+type PackagingLevel struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Unit     string `json:"unit"`
+	Size     int    `json:"size"` // Number of items, which is the level's pack size
+}
+
+// Shipment is one part of an order split by shipment limits.
+type Shipment struct {
+	Packs      map[int]int `json:"packs"`
+	Total      int         `json:"total"`
+	PacksCount int         `json:"packsCount"`
+}
+
+// ShipmentLimits caps the size of each shipment; zero means unlimited.
+type ShipmentLimits struct {
+	MaxItems int
+	MaxPacks int
+}
+
+// Explanation is a calculation together with its runner-up combinations.
+type Explanation struct {
+	Chosen     CalculationResult `json:"chosen"`
+	Candidates []Candidate       `json:"candidates"`
+	Rule       string            `json:"rule"`
+}
+
+// Candidate is a runner-up combination and the rule that ranked the chosen one above it.
+type Candidate struct {
+	CalculationResult
+	Rule string `json:"rule"`
+}
+
+// NoExactFit is returned in exact mode when no combination matches the order.
+type NoExactFit struct {
+	Message string `json:"error"`
+	Below   int    `json:"below"` // Largest quantity below the order that fits exactly, 0 if none
+	Above   int    `json:"above"` // Smallest quantity above the order that fits exactly, 0 if none
+}
+
+func (e *NoExactFit) Error() string {
+	return e.Message
+}
+
+// FieldError points at a request field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// OrderLine is one line of a multi-line order.
+type OrderLine struct {
+	SKU      string          `json:"sku"`
+	Quantity int             `json:"quantity"`
+	Sizes    []int           `json:"sizes,omitempty"`
+	Costs    map[int]float64 `json:"costs,omitempty"`
+}
+
+// OrderResult is the outcome of a multi-line order.
+type OrderResult struct {
+	Lines       []LineResult `json:"lines"`
+	TotalItems  int          `json:"totalItems"`
+	TotalExcess int          `json:"totalExcess"`
+	TotalPacks  int          `json:"totalPacks"`
+	TotalCost   float64      `json:"totalCost"`
+}
+
+// LineResult is the outcome of one order line.
+type LineResult struct {
+	SKU string `json:"sku"`
+	CalculationResult
+	Cost float64 `json:"cost"`
+}
+
+// CatalogueMetrics summarises how a catalogue performs over a sample of orders.
+type CatalogueMetrics struct {
+	Sizes         []int   `json:"sizes"`
+	Orders        int     `json:"orders"`
+	Unsolvable    int     `json:"unsolvable"` // Orders left out of the totals and averages
+	TotalExcess   int     `json:"totalExcess"`
+	TotalPacks    int     `json:"totalPacks"`
+	TotalCost     float64 `json:"totalCost"`
+	AverageExcess float64 `json:"averageExcess"`
+	AveragePacks  float64 `json:"averagePacks"`
+	AverageCost   float64 `json:"averageCost"`
+}
+
+// WhatIfRequest proposes a catalogue to compare with the current one.
+type WhatIfRequest struct {
+	Sizes  []int           `json:"sizes"`
+	Orders []int           `json:"orders"`
+	Costs  map[int]float64 `json:"costs,omitempty"`
+}
+
+// WhatIfResult compares the current catalogue with a proposed one.
+type WhatIfResult struct {
+	Current      CatalogueMetrics `json:"current"`
+	Proposed     CatalogueMetrics `json:"proposed"`
+	ExcessChange float64          `json:"excessChange"`
+	PacksChange  float64          `json:"packsChange"`
+	CostChange   float64          `json:"costChange"`
+}
+
+// OptimizeRequest asks for the best catalogues of K pack sizes.
+type OptimizeRequest struct {
+	Orders          []int  `json:"orders"`
+	K               int    `json:"k"`
+	Candidates      []int  `json:"candidates,omitempty"`
+	Objective       string `json:"objective,omitempty"` // "excess" (default) or "packs"
+	Recommendations int    `json:"recommendations,omitempty"`
+}
+
+// Recommendation is a ranked catalogue.
+type Recommendation struct {
+	Rank int `json:"rank"`
+	CatalogueMetrics
+}
+
+// PackDimensions is the volume and weight of one pack, in the same units as the containers.
+type PackDimensions struct {
+	Volume float64 `json:"volume"`
+	Weight float64 `json:"weight"`
+}
+
+// Container is a type of carrier box or pallet. A zero MaxWeight places no weight limit.
+type Container struct {
+	Name      string  `json:"name"`
+	Volume    float64 `json:"volume"`
+	MaxWeight float64 `json:"maxWeight,omitempty"`
+}
+
+// ContainerRequest asks for packs to be loaded into containers. When Packs is empty
+// the packs are calculated for Order.
+type ContainerRequest struct {
+	Order      int                    `json:"order,omitempty"`
+	Packs      map[int]int            `json:"packs,omitempty"`
+	Dimensions map[int]PackDimensions `json:"dimensions"`
+	Containers []Container            `json:"containers"` // In order of preference
+}
+
+// ContainerLoad is one container and the packs loaded into it.
+type ContainerLoad struct {
+	Container         string      `json:"container"`
+	Packs             map[int]int `json:"packs"`
+	Volume            float64     `json:"volume"`
+	Weight            float64     `json:"weight"`
+	VolumeUtilisation float64     `json:"volumeUtilisation"` // Percentage of the container's volume used
+	WeightUtilisation float64     `json:"weightUtilisation"` // Percentage of its weight limit used, 0 when unlimited
+}
+
+// ContainerResult is the assignment of packs to containers.
+type ContainerResult struct {
+	Packs             map[int]int     `json:"packs"`
+	Containers        []ContainerLoad `json:"containers"`
+	VolumeUtilisation float64         `json:"volumeUtilisation"`
+	WeightUtilisation float64         `json:"weightUtilisation"`
+	CatalogueVersion  int             `json:"catalogueVersion,omitempty"`
+}
+
+// PackRule restricts how often a pack size may be used in a single order.
+type PackRule struct {
+	MinCount int  `json:"minCount"`
+	MaxCount int  `json:"maxCount"`
+	Disabled bool `json:"disabled"`
+}
+
+// Schedule limits when a pack size is available. Zero times place no limit.
+type Schedule struct {
+	EffectiveFrom  time.Time `json:"effectiveFrom"`
+	EffectiveUntil time.Time `json:"effectiveUntil"`
+}
+
+// CatalogueVersion is a snapshot of the catalogue after a change.
+type CatalogueVersion struct {
+	ID        int              `json:"id"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Change    string           `json:"change"`
+	Sizes     []int            `json:"sizes"`
+	Rules     map[int]PackRule `json:"rules"`
+	Schedules map[int]Schedule `json:"schedules"`
+	Levels    []PackagingLevel `json:"levels"`
+}
+
+// VersionDiff lists the differences between two catalogue versions.
+type VersionDiff struct {
+	From            CatalogueVersion `json:"from"`
+	To              CatalogueVersion `json:"to"`
+	Added           []int            `json:"added"`
+	Removed         []int            `json:"removed"`
+	RuleChanges     []RuleChange     `json:"ruleChanges"`
+	ScheduleChanges []ScheduleChange `json:"scheduleChanges"`
+}
+
+// RuleChange is a changed rule of a size in both versions.
+type RuleChange struct {
+	Size int      `json:"size"`
+	From PackRule `json:"from"`
+	To   PackRule `json:"to"`
+}
+
+// ScheduleChange is a changed window of a size in both versions.
+type ScheduleChange struct {
+	Size int      `json:"size"`
+	From Schedule `json:"from"`
+	To   Schedule `json:"to"`
+}
+
+// Subscription is a webhook subscription. The secret is only sent, never returned.
+type Subscription struct {
+	ID        string    `json:"id,omitempty"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events,omitempty"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+// Delivery is an entry of the webhook delivery log.
+type Delivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscriptionId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttempt    time.Time       `json:"nextAttempt"`
+	LastError      string          `json:"lastError,omitempty"`
+	ResponseCode   int             `json:"responseCode,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    time.Time       `json:"deliveredAt"`
+}